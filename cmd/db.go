@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var dbMigrateTo string
+var dbMigrateDSN string
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy the SQLite database to PostgreSQL",
+	Long: `Copy all the data in the SQLite database at db_path to the
+PostgreSQL database. The existing data in the PostgreSQL database is
+replaced. The connection string is taken from db_dsn unless --dsn is
+passed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbMigrateTo != utils.Postgres {
+			log.Fatalf("Invalid database %s, only postgres is supported", dbMigrateTo)
+		}
+
+		dsn := dbMigrateDSN
+		if dsn == "" {
+			dsn = config.GetDBDSN()
+		}
+		if dsn == "" {
+			log.Fatal("PostgreSQL connection string is missing, set db_dsn in the config or pass --dsn")
+		}
+
+		path := config.GetDBPath()
+		if !utils.FileExists(path) {
+			log.Fatalf("SQLite database %s doesn't exist", path)
+		}
+
+		from, err := utils.OpenSQLite(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		to, err := utils.OpenPostgres(dsn)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Infof("Copying %s to PostgreSQL", path)
+		err = model.CopyDatabase(from, to)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if config.GetDBDSN() == "" {
+			log.Info("Set db_dsn in the config to use the PostgreSQL database")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbMigrateCmd.Flags().StringVar(&dbMigrateTo, "to", "", "database to copy to, only postgres is supported")
+	dbMigrateCmd.Flags().StringVar(&dbMigrateDSN, "dsn", "", "PostgreSQL connection string, defaults to db_dsn in the config")
+	err := dbMigrateCmd.MarkFlagRequired("to")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/server"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var doctorFormat string
+var doctorSkipSync bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the journal for issues",
+	Long: `Check the journal for issues using the built-in and the custom doctor rules.
+Exits with status 1 if any error level issue is found.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !lo.Contains([]string{"table", "json", "sarif"}, doctorFormat) {
+			log.Fatalf("Invalid format %s, expected one of table, json or sarif", doctorFormat)
+		}
+
+		db, err := utils.OpenDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !doctorSkipSync {
+			_, message, err := model.SyncJournal(db)
+			if err != nil {
+				log.Fatal(message)
+			}
+		}
+
+		issues := lo.Map(server.Diagnose(db), func(issue server.Issue, _ int) doctorIssue {
+			return doctorIssue{
+				Level:       lo.Ternary(issue.Level == server.ERROR, "error", "warning"),
+				Rule:        ruleId(issue.Summary),
+				Summary:     stripHTML(issue.Summary),
+				Description: stripHTML(issue.Description),
+				Details:     stripHTML(issue.Details),
+				FileName:    issue.FileName,
+				Line:        issue.Line,
+			}
+		})
+
+		switch doctorFormat {
+		case "json":
+			err = writeJSON(os.Stdout, map[string]any{"issues": issues})
+		case "sarif":
+			err = writeJSON(os.Stdout, buildSarif(issues, filepath.Dir(config.GetJournalPath())))
+		default:
+			err = writeIssuesTable(os.Stdout, issues)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if lo.SomeBy(issues, func(issue doctorIssue) bool { return issue.Level == "error" }) {
+			os.Exit(1)
+		}
+	},
+}
+
+type doctorIssue struct {
+	Level       string `json:"level"`
+	Rule        string `json:"rule"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Details     string `json:"details"`
+	FileName    string `json:"file_name,omitempty"`
+	Line        uint64 `json:"line,omitempty"`
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+	nonWord    = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func stripHTML(text string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(html.UnescapeString(htmlTag.ReplaceAllString(text, "")), " "))
+}
+
+func ruleId(summary string) string {
+	return strings.Trim(nonWord.ReplaceAllString(strings.ToLower(stripHTML(summary)), "-"), "-")
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func writeIssuesTable(w io.Writer, issues []doctorIssue) error {
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(w, "No issues found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LEVEL\tRULE\tLOCATION\tDETAILS")
+	for _, issue := range issues {
+		location := "-"
+		if issue.FileName != "" {
+			location = fmt.Sprintf("%s:%d", issue.FileName, issue.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", issue.Level, issue.Summary, location, issue.Details)
+	}
+	return tw.Flush()
+}
+
+// buildSarif converts the issues to SARIF 2.1.0, the locations are
+// relative to the journal directory
+func buildSarif(issues []doctorIssue, journalDir string) map[string]any {
+	rules := []map[string]any{}
+	for _, issue := range lo.UniqBy(issues, func(issue doctorIssue) string { return issue.Rule }) {
+		rules = append(rules, map[string]any{
+			"id":                   issue.Rule,
+			"name":                 issue.Summary,
+			"shortDescription":     map[string]any{"text": issue.Summary},
+			"fullDescription":      map[string]any{"text": lo.Ternary(issue.Description != "", issue.Description, issue.Summary)},
+			"defaultConfiguration": map[string]any{"level": issue.Level},
+		})
+	}
+
+	results := lo.Map(issues, func(issue doctorIssue, _ int) map[string]any {
+		result := map[string]any{
+			"ruleId":  issue.Rule,
+			"level":   issue.Level,
+			"message": map[string]any{"text": issue.Details},
+		}
+		if issue.FileName != "" {
+			result["locations"] = []map[string]any{{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]any{
+						"uri":       filepath.ToSlash(issue.FileName),
+						"uriBaseId": "JOURNAL_DIR",
+					},
+					"region": map[string]any{"startLine": issue.Line},
+				},
+			}}
+		}
+		return result
+	})
+
+	basePath := filepath.ToSlash(journalDir) + "/"
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	baseUri := url.URL{Scheme: "file", Path: basePath}
+	return map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":           "paisa",
+					"informationUri": "https://paisa.fyi",
+					"rules":          rules,
+				},
+			},
+			"originalUriBaseIds": map[string]any{
+				"JOURNAL_DIR": map[string]any{"uri": baseUri.String()},
+			},
+			"results": results,
+		}},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVarP(&doctorFormat, "format", "f", "table", "output format, one of table, json or sarif")
+	doctorCmd.Flags().BoolVar(&doctorSkipSync, "skip-sync", false, "skip syncing the journal before checking")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ananthakumaran/paisa/internal/importer"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var importTemplate string
+var importReverse bool
+var importIncludeDuplicates bool
+var importSkipSync bool
+
+var importCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Convert a statement to ledger transactions",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := utils.OpenDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !importSkipSync {
+			_, message, err := model.SyncJournal(db)
+			if err != nil {
+				log.Fatal(message)
+			}
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		result, err := importer.Import(db, importTemplate, args[0], data, importReverse)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if result.Duplicates > 0 && !importIncludeDuplicates {
+			log.Warnf("Skipped %d transactions already present in the journal", result.Duplicates)
+		}
+
+		fmt.Println(result.Ledger(importIncludeDuplicates))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importTemplate, "template", "t", "", "name of the import template")
+	importCmd.Flags().BoolVarP(&importReverse, "reverse", "r", false, "reverse the order of the transactions")
+	importCmd.Flags().BoolVar(&importIncludeDuplicates, "include-duplicates", false, "include the transactions already present in the journal")
+	importCmd.Flags().BoolVar(&importSkipSync, "skip-sync", false, "skip syncing the journal before importing")
+	err := importCmd.MarkFlagRequired("template")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/server"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var queryFormat string
+var queryGroup string
+var querySkipSync bool
+
+var queryCmd = &cobra.Command{
+	Use:   "query [filter...]",
+	Short: "Query the postings",
+	Long: `Query the postings using the hledger style filter expression, for example
+
+  paisa query acct:Expenses:Food date:2024-01.. payee:/swiggy/ amt:'>500' tag:trip=goa status:'*'
+
+Prints the matching postings with the running balance, or the register
+grouped by day, month, year, account or payee if --group is set.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !lo.Contains([]string{"table", "json"}, queryFormat) {
+			log.Fatalf("Invalid format %s, expected one of table or json", queryFormat)
+		}
+
+		db, err := utils.OpenDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !querySkipSync {
+			_, message, err := model.SyncJournal(db)
+			if err != nil {
+				log.Fatal(message)
+			}
+		}
+
+		result, err := server.RunQuery(db, joinFilter(args), queryGroup)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		switch {
+		case queryFormat == "json":
+			err = writeJSON(os.Stdout, result)
+		case queryGroup != "":
+			err = writeRegisterTable(os.Stdout, result)
+		default:
+			err = writePostingsTable(os.Stdout, result)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// joinFilter quotes back the arguments with whitespace, which the shell
+// would have unquoted, so the filter tokenizes the same way
+func joinFilter(args []string) string {
+	return strings.Join(lo.Map(args, func(arg string, _ int) string {
+		if !strings.ContainsAny(arg, " \t") {
+			return arg
+		}
+		quote := lo.Ternary(strings.Contains(arg, `"`), `'`, `"`)
+		return quote + arg + quote
+	}), " ")
+}
+
+func writePostingsTable(w io.Writer, result server.QueryResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tPAYEE\tACCOUNT\tAMOUNT\tBALANCE")
+	for _, p := range result.Postings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.Date.Format("2006-01-02"), p.Payee, p.Account, p.Amount.StringFixed(2), p.Balance.StringFixed(2))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMODITY\tQUANTITY\tAMOUNT")
+	for _, total := range result.Totals {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", total.Commodity, total.Quantity.String(), total.Amount.StringFixed(2))
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%s\n", result.Total.StringFixed(2))
+	return tw.Flush()
+}
+
+func writeRegisterTable(w io.Writer, result server.QueryResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tPOSTINGS\tAMOUNT\tBALANCE\n", strings.ToUpper(result.Group))
+	for _, row := range result.Register {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", row.Key, row.Count, row.Amount.StringFixed(2), row.Balance.StringFixed(2))
+	}
+	return tw.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().StringVarP(&queryFormat, "format", "f", "table", "output format, one of table or json")
+	queryCmd.Flags().StringVarP(&queryGroup, "group", "g", "", "print the register grouped by day, month, year, account or payee")
+	queryCmd.Flags().BoolVar(&querySkipSync, "skip-sync", false, "skip syncing the journal before querying")
+}
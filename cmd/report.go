@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/export"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/server"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var reportFormat string
+var reportFrom string
+var reportTo string
+var reportAccount string
+var reportTable string
+var reportCurrency string
+var reportOutput string
+var reportSkipSync bool
+
+var reportCmd = &cobra.Command{
+	Use:   "report <name>",
+	Short: "Print a report",
+	Long: `Print one of the networth, expense, income, cash_flow,
+income_statement, budget, gain, capital_gains, lot_selection, harvest,
+allocation, recurring, credit_cards, liabilities_balance,
+liabilities_interest, liabilities_repayment or loans reports as a table,
+JSON, CSV, XLSX or Parquet.
+
+Some of the reports have more than one table, all of them are included
+in the table, JSON and XLSX formats, with a sheet for each table in
+XLSX. CSV and Parquet have only the first table unless another one is
+selected with --table.`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: server.ReportNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !lo.Contains(append([]string{"table", "json"}, export.Formats...), reportFormat) {
+			log.Fatalf("Invalid format %s, expected one of table, json, csv, xlsx or parquet", reportFormat)
+		}
+
+		filter := server.ReportFilter{Account: reportAccount, Currency: reportCurrency}
+		if reportFrom != "" {
+			filter.From = utils.BeginningOfDay(parseReportDate(reportFrom))
+		}
+		if reportTo != "" {
+			filter.To = utils.EndOfDay(parseReportDate(reportTo))
+		}
+
+		db, err := utils.OpenDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !reportSkipSync {
+			_, message, err := model.SyncJournal(db)
+			if err != nil {
+				log.Fatal(message)
+			}
+		}
+
+		tables, err := server.BuildReport(db, args[0], filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if reportTable != "" {
+			table, found := lo.Find(tables, func(table export.Table) bool { return table.Name == reportTable })
+			if !found {
+				log.Fatalf("Invalid table %s, expected one of %s", reportTable, strings.Join(lo.Map(tables, func(table export.Table, _ int) string { return table.Name }), ", "))
+			}
+			tables = []export.Table{table}
+		}
+
+		var output io.Writer = os.Stdout
+		if reportOutput != "" {
+			file, err := os.Create(reportOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer file.Close()
+			output = file
+		}
+
+		switch reportFormat {
+		case "json":
+			err = writeJSON(output, lo.SliceToMap(tables, func(table export.Table) (string, []map[string]any) {
+				return table.Name, table.Records()
+			}))
+		case "table":
+			err = writeReportTables(output, tables)
+		default:
+			err = export.Write(output, reportFormat, tables)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func parseReportDate(value string) time.Time {
+	date, err := time.ParseInLocation("2006-01-02", value, config.TimeZone())
+	if err != nil {
+		log.Fatalf("Invalid date %s, expected YYYY-MM-DD", value)
+	}
+	return date
+}
+
+func writeReportTables(w io.Writer, tables []export.Table) error {
+	for i, table := range tables {
+		if len(tables) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "# %s\n", table.Name)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(lo.Map(table.Columns, func(column string, _ int) string {
+			return strings.ReplaceAll(column, "_", " ")
+		}), "\t")))
+		for _, row := range table.Rows {
+			fmt.Fprintln(tw, strings.Join(lo.Map(row, func(cell any, _ int) string { return export.FormatCell(cell, 2) }), "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "table", "output format, one of table, json, csv, xlsx or parquet")
+	reportCmd.Flags().StringVar(&reportFrom, "from", "", "include the rows on or after the date (YYYY-MM-DD)")
+	reportCmd.Flags().StringVar(&reportTo, "to", "", "include the rows on or before the date (YYYY-MM-DD)")
+	reportCmd.Flags().StringVarP(&reportAccount, "account", "a", "", "include only the account and its children")
+	reportCmd.Flags().StringVarP(&reportTable, "table", "t", "", "print only the table with the name")
+	reportCmd.Flags().StringVarP(&reportCurrency, "currency", "c", "", "reporting currency, defaults to the default currency")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write to the file instead of the standard output")
+	reportCmd.Flags().BoolVar(&reportSkipSync, "skip-sync", false, "skip syncing the journal before running the report")
+}
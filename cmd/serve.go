@@ -11,6 +11,7 @@ import (
 )
 
 var port int
+var watch bool
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
@@ -26,6 +27,14 @@ var serveCmd = &cobra.Command{
 		if err != nil {
 			log.Fatal(err)
 		}
+
+		if watch {
+			err = server.Watch(db)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		server.Listen(db, port)
 	},
 }
@@ -33,4 +42,5 @@ var serveCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(serveCmd)
 	serveCmd.Flags().IntVarP(&port, "port", "p", 7500, "port to listen on")
+	serveCmd.Flags().BoolVarP(&watch, "watch", "w", false, "sync automatically when the journal or sheets change")
 }
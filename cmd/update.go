@@ -23,7 +23,7 @@ var updateCmd = &cobra.Command{
 		syncAll := !updateJournal && !updateCommodities && !updatePortfolios
 
 		if syncAll || updateJournal {
-			message, err := model.SyncJournal(db)
+			_, message, err := model.SyncJournal(db)
 			if err != nil {
 				log.Fatal(message)
 			}
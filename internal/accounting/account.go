@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/samber/lo"
 	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 )
@@ -31,3 +32,16 @@ func IsLeafAccount(db *gorm.DB, account string) bool {
 func ClearCache() {
 	acache = accountCache{}
 }
+
+// InvalidateCache clears the account list only if the given accounts
+// are not all known already or if some account might have lost its
+// last posting.
+func InvalidateCache(accounts []string, removed bool) {
+	if acache.accounts == nil {
+		return
+	}
+
+	if removed || lo.SomeBy(accounts, func(account string) bool { return !slices.Contains(acache.accounts, account) }) {
+		ClearCache()
+	}
+}
@@ -120,7 +120,7 @@ func FIFO(postings []posting.Posting) []posting.Posting {
 func CostBalance(postings []posting.Posting) decimal.Decimal {
 	byAccount := lo.GroupBy(postings, func(p posting.Posting) string { return p.Account })
 	return utils.SumBy(lo.Values(byAccount), func(ps []posting.Posting) decimal.Decimal {
-		return utils.SumBy(FIFO(ps), func(p posting.Posting) decimal.Decimal {
+		return utils.SumBy(Lots(ps), func(p posting.Posting) decimal.Decimal {
 			return p.Amount
 		})
 	})
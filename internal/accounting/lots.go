@@ -0,0 +1,151 @@
+package accounting
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	c "github.com/ananthakumaran/paisa/internal/model/commodity"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+var LotSelections = []config.LotSelectionType{config.FIFO, config.LIFO, config.HIFO, config.Average, config.Specific}
+
+// Match is the part of a purchase lot consumed by a sale.
+type Match struct {
+	Purchase posting.Posting
+	Sell     posting.Posting
+}
+
+type Sale struct {
+	Posting posting.Posting
+	Matches []Match
+}
+
+func LotSelection(commodity string) config.LotSelectionType {
+	selection := c.FindByName(commodity).LotSelection
+	if selection == "" {
+		return config.FIFO
+	}
+	return selection
+}
+
+// Lots returns the purchase lots still held, matching the sales using
+// the lot selection configured for the commodity.
+func Lots(postings []posting.Posting) []posting.Posting {
+	if len(postings) == 0 || utils.IsCurrency(postings[0].Commodity) {
+		return FIFO(postings)
+	}
+
+	selection := LotSelection(postings[0].Commodity)
+	if selection == config.FIFO {
+		return FIFO(postings)
+	}
+
+	_, available := MatchLots(postings, selection)
+	return available
+}
+
+// MatchLots matches each sale with the purchase lots available at the
+// time of the sale. The lots are picked based on the selection:
+//
+//   - fifo: the oldest lot first
+//   - lifo: the latest lot first
+//   - hifo: the lot with the highest unit price first
+//   - average: the oldest lot first, after setting the unit price of all
+//     the lots to the average unit price
+//   - specific: the lots named in the Lot tag of the sale first, then the
+//     oldest lot. A lot is named by its own Lot tag or the purchase date.
+func MatchLots(postings []posting.Posting, selection config.LotSelectionType) ([]Sale, []posting.Posting) {
+	var available []posting.Posting
+	var sales []Sale
+	for _, p := range postings {
+		if p.Quantity.GreaterThan(decimal.Zero) {
+			available = append(available, p)
+			continue
+		}
+
+		if selection == config.Average {
+			available = averageCost(available)
+		}
+
+		sale := Sale{Posting: p}
+		names := lotNames(p)
+		quantity := p.Quantity.Neg()
+		for quantity.GreaterThan(decimal.Zero) && len(available) > 0 {
+			i := nextLot(available, selection, names)
+			lot := available[i]
+			q := decimal.Min(lot.Quantity, quantity)
+			sale.Matches = append(sale.Matches, Match{Purchase: lot.WithQuantity(q), Sell: p.WithQuantity(q.Neg())})
+			quantity = quantity.Sub(q)
+
+			if lot.Quantity.GreaterThan(q) {
+				lot.AddQuantity(q.Neg())
+				available[i] = lot
+			} else {
+				available = append(available[:i:i], available[i+1:]...)
+			}
+		}
+		sales = append(sales, sale)
+	}
+
+	return sales, available
+}
+
+// SaleOrder sorts the lots in the order they would be sold next.
+func SaleOrder(lots []posting.Posting, selection config.LotSelectionType) []posting.Posting {
+	lots = append([]posting.Posting{}, lots...)
+	switch selection {
+	case config.LIFO:
+		lots = lo.Reverse(lots)
+	case config.HIFO:
+		sort.SliceStable(lots, func(i, j int) bool { return lots[i].Price().GreaterThan(lots[j].Price()) })
+	case config.Average:
+		lots = averageCost(lots)
+	}
+	return lots
+}
+
+func nextLot(lots []posting.Posting, selection config.LotSelectionType, names []string) int {
+	switch selection {
+	case config.LIFO:
+		return len(lots) - 1
+	case config.HIFO:
+		highest := 0
+		for i, lot := range lots {
+			if lot.Price().GreaterThan(lots[highest].Price()) {
+				highest = i
+			}
+		}
+		return highest
+	case config.Specific:
+		for i, lot := range lots {
+			if lo.Contains(names, lot.Tag("Lot")) || lo.Contains(names, lot.Date.Format("2006-01-02")) {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+func lotNames(p posting.Posting) []string {
+	return lo.Compact(lo.Map(strings.Split(p.Tag("Lot"), ","), func(name string, _ int) string {
+		return strings.TrimSpace(name)
+	}))
+}
+
+func averageCost(lots []posting.Posting) []posting.Posting {
+	quantity := utils.SumBy(lots, func(p posting.Posting) decimal.Decimal { return p.Quantity })
+	if quantity.IsZero() {
+		return lots
+	}
+
+	price := CostSum(lots).Div(quantity)
+	return lo.Map(lots, func(p posting.Posting, _ int) posting.Posting {
+		p.Amount = p.Quantity.Mul(price)
+		return p
+	})
+}
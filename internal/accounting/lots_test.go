@@ -0,0 +1,76 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func lotPosting(date string, quantity int64, price int64, note string) posting.Posting {
+	d, _ := time.Parse("2006-01-02", date)
+	return posting.Posting{Date: d, Account: "Assets:Equity:NIFTY", Commodity: "NIFTY", Quantity: decimal.NewFromInt(quantity), Amount: decimal.NewFromInt(quantity * price), Note: note}
+}
+
+func TestMatchLots(t *testing.T) {
+	postings := []posting.Posting{
+		lotPosting("2021-01-01", 10, 100, ""),
+		lotPosting("2021-06-01", 10, 300, " Lot: june"),
+		lotPosting("2022-01-01", 10, 200, ""),
+		lotPosting("2023-01-01", -15, 400, " Lot: 2022-01-01, june"),
+	}
+
+	tests := []struct {
+		selection config.LotSelectionType
+		purchases []string
+		cost      string
+		remaining string
+	}{
+		{config.FIFO, []string{"2021-01-01", "2021-06-01"}, "2500", "3500"},
+		{config.LIFO, []string{"2022-01-01", "2021-06-01"}, "3500", "2500"},
+		{config.HIFO, []string{"2021-06-01", "2022-01-01"}, "4000", "2000"},
+		{config.Average, []string{"2021-01-01", "2021-06-01"}, "3000", "3000"},
+		{config.Specific, []string{"2021-06-01", "2022-01-01"}, "4000", "2000"},
+	}
+
+	for _, test := range tests {
+		sales, available := MatchLots(postings, test.selection)
+		assert.Len(t, sales, 1, test.selection)
+
+		var purchases []string
+		cost := decimal.Zero
+		for _, match := range sales[0].Matches {
+			purchases = append(purchases, match.Purchase.Date.Format("2006-01-02"))
+			cost = cost.Add(match.Purchase.Amount)
+			assert.True(t, match.Purchase.Quantity.Equal(match.Sell.Quantity.Neg()), test.selection)
+		}
+
+		assert.Equal(t, test.purchases, purchases, test.selection)
+		assert.Equal(t, test.cost, cost.String(), test.selection)
+		assert.Equal(t, test.remaining, CostSum(available).String(), test.selection)
+	}
+}
+
+func TestSaleOrder(t *testing.T) {
+	lots := []posting.Posting{
+		lotPosting("2021-01-01", 10, 100, ""),
+		lotPosting("2021-06-01", 10, 300, ""),
+		lotPosting("2022-01-01", 10, 200, ""),
+	}
+
+	dates := func(lots []posting.Posting) []string {
+		var dates []string
+		for _, lot := range lots {
+			dates = append(dates, lot.Date.Format("2006-01-02"))
+		}
+		return dates
+	}
+
+	assert.Equal(t, []string{"2022-01-01", "2021-06-01", "2021-01-01"}, dates(SaleOrder(lots, config.LIFO)))
+	assert.Equal(t, []string{"2021-06-01", "2022-01-01", "2021-01-01"}, dates(SaleOrder(lots, config.HIFO)))
+	assert.Equal(t, "2000", SaleOrder(lots, config.Average)[0].Amount.String())
+	assert.Equal(t, "2021-01-01", lots[0].Date.Format("2006-01-02"))
+}
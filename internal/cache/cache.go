@@ -2,11 +2,27 @@ package cache
 
 import (
 	"github.com/ananthakumaran/paisa/internal/accounting"
+	"github.com/ananthakumaran/paisa/internal/model"
 	"github.com/ananthakumaran/paisa/internal/model/transaction"
 	"github.com/ananthakumaran/paisa/internal/prediction"
 	"github.com/ananthakumaran/paisa/internal/service"
 )
 
+// Invalidate clears only the caches that depend on the rows touched by
+// a journal sync.
+func Invalidate(result model.JournalSyncResult) {
+	postings := result.Postings
+	if !postings.Changed() && !result.PricesChanged {
+		return
+	}
+
+	service.InvalidateInterestCache(postings.Accounts)
+	service.InvalidatePriceCache(result.PricesChanged, postings.Commodities)
+	accounting.InvalidateCache(postings.Accounts, postings.AccountsRemoved)
+	prediction.InvalidateCache(postings.ContentChanged)
+	transaction.InvalidateCache(postings.TransactionIDs)
+}
+
 func Clear() {
 	service.ClearInterestCache()
 	service.ClearPriceCache()
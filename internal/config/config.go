@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +30,26 @@ const (
 	UnlistedEquity TaxCategoryType = "unlisted_equity"
 )
 
+// TaxRate is the tax as a percentage of the taxable gain. Slab means
+// the gain is added to the income and taxed at the slab rate.
+type TaxRate string
+
+const Slab TaxRate = "slab"
+
+func (r TaxRate) MarshalJSON() ([]byte, error) {
+	if rate, err := strconv.ParseFloat(string(r), 64); err == nil {
+		return json.Marshal(rate)
+	}
+	return json.Marshal(string(r))
+}
+
+func (r TaxRate) MarshalYAML() (interface{}, error) {
+	if rate, err := strconv.ParseFloat(string(r), 64); err == nil {
+		return rate, nil
+	}
+	return string(r), nil
+}
+
 type CommodityType string
 
 const (
@@ -36,9 +57,42 @@ const (
 	NPS        CommodityType = "nps"
 	Stock      CommodityType = "stock"
 	Metal      CommodityType = "metal"
+	Currency   CommodityType = "currency"
 	Unknown    CommodityType = "unknown"
 )
 
+type LotSelectionType string
+
+const (
+	FIFO     LotSelectionType = "fifo"
+	LIFO     LotSelectionType = "lifo"
+	HIFO     LotSelectionType = "hifo"
+	Average  LotSelectionType = "average"
+	Specific LotSelectionType = "specific"
+)
+
+type DoctorRuleLevel string
+
+const (
+	RuleWarning DoctorRuleLevel = "warning"
+	RuleError   DoctorRuleLevel = "error"
+)
+
+type DoctorRuleTarget string
+
+const (
+	RulePosting     DoctorRuleTarget = "posting"
+	RuleTransaction DoctorRuleTarget = "transaction"
+)
+
+type BudgetPeriod string
+
+const (
+	Monthly   BudgetPeriod = "monthly"
+	Quarterly BudgetPeriod = "quarterly"
+	Yearly    BudgetPeriod = "yearly"
+)
+
 type BoolType string
 
 const (
@@ -57,11 +111,12 @@ type Price struct {
 }
 
 type Commodity struct {
-	Name        string          `json:"name" yaml:"name"`
-	Type        CommodityType   `json:"type" yaml:"type"`
-	Price       Price           `json:"price" yaml:"price"`
-	Harvest     int             `json:"harvest" yaml:"harvest"`
-	TaxCategory TaxCategoryType `json:"tax_category" yaml:"tax_category"`
+	Name         string           `json:"name" yaml:"name"`
+	Type         CommodityType    `json:"type" yaml:"type"`
+	Price        Price            `json:"price" yaml:"price"`
+	Harvest      int              `json:"harvest" yaml:"harvest"`
+	TaxCategory  TaxCategoryType  `json:"tax_category" yaml:"tax_category"`
+	LotSelection LotSelectionType `json:"lot_selection" yaml:"lot_selection"`
 }
 
 type Account struct {
@@ -87,6 +142,12 @@ type RetirementGoal struct {
 	Savings        []string `json:"savings" yaml:"savings"`
 	YearlyExpenses float64  `json:"yearly_expenses" yaml:"yearly_expenses"`
 	Priority       int      `json:"priority" yaml:"priority"`
+
+	Inflation          float64 `json:"inflation" yaml:"inflation"`
+	YearlyContribution float64 `json:"yearly_contribution" yaml:"yearly_contribution"`
+	WithdrawalRate     float64 `json:"withdrawal_rate" yaml:"withdrawal_rate"`
+	RetirementDate     string  `json:"retirement_date" yaml:"retirement_date"`
+	Years              int     `json:"years" yaml:"years"`
 }
 
 type SavingsGoal struct {
@@ -106,7 +167,21 @@ type ScheduleAL struct {
 }
 
 type Budget struct {
-	Rollover BoolType `json:"rollover" yaml:"rollover"`
+	Rollover  BoolType         `json:"rollover" yaml:"rollover"`
+	Envelopes []BudgetEnvelope `json:"envelopes" yaml:"envelopes"`
+}
+
+type BudgetEnvelope struct {
+	Name     string          `json:"name" yaml:"name"`
+	Accounts []BudgetAccount `json:"accounts" yaml:"accounts"`
+}
+
+type BudgetAccount struct {
+	Account    string       `json:"account" yaml:"account"`
+	Period     BudgetPeriod `json:"period" yaml:"period"`
+	Amount     float64      `json:"amount" yaml:"amount"`
+	Percentage float64      `json:"percentage" yaml:"percentage"`
+	Cap        float64      `json:"cap" yaml:"cap"`
 }
 
 type AllocationTarget struct {
@@ -123,6 +198,25 @@ type CreditCard struct {
 	Network         string `json:"network" yaml:"network"`
 	Number          string `json:"number" yaml:"number"`
 	ExpirationDate  string `json:"expiration_date" yaml:"expiration_date"`
+
+	MinimumDuePercent    float64 `json:"minimum_due_percent" yaml:"minimum_due_percent"`
+	UtilizationThreshold float64 `json:"utilization_threshold" yaml:"utilization_threshold"`
+}
+
+type LoanRateChange struct {
+	Date string  `json:"date" yaml:"date"`
+	Rate float64 `json:"rate" yaml:"rate"`
+	EMI  float64 `json:"emi" yaml:"emi"`
+}
+
+type Loan struct {
+	Account     string           `json:"account" yaml:"account"`
+	Principal   float64          `json:"principal" yaml:"principal"`
+	Rate        float64          `json:"rate" yaml:"rate"`
+	StartDate   string           `json:"start_date" yaml:"start_date"`
+	Tenure      int              `json:"tenure" yaml:"tenure"`
+	EMI         float64          `json:"emi" yaml:"emi"`
+	RateChanges []LoanRateChange `json:"rate_changes" yaml:"rate_changes"`
 }
 
 type CustomValuation struct {
@@ -132,9 +226,38 @@ type CustomValuation struct {
 	Formula      string `json:"formula" yaml:"formula"`
 }
 
+type DoctorRule struct {
+	Summary     string           `json:"summary" yaml:"summary"`
+	Description string           `json:"description" yaml:"description"`
+	Level       DoctorRuleLevel  `json:"level" yaml:"level"`
+	On          DoctorRuleTarget `json:"on" yaml:"on"`
+	When        string           `json:"when" yaml:"when"`
+	Assert      string           `json:"assert" yaml:"assert"`
+}
+
+type TaxRule struct {
+	Category          TaxCategoryType `json:"category" yaml:"category"`
+	EffectiveFrom     string          `json:"effective_from" yaml:"effective_from"`
+	PurchasedFrom     string          `json:"purchased_from" yaml:"purchased_from"`
+	PurchasedBefore   string          `json:"purchased_before" yaml:"purchased_before"`
+	LongTermAfter     int             `json:"long_term_after" yaml:"long_term_after"`
+	ShortTermRate     TaxRate         `json:"short_term_rate" yaml:"short_term_rate"`
+	LongTermRate      TaxRate         `json:"long_term_rate" yaml:"long_term_rate"`
+	LongTermExemption float64         `json:"long_term_exemption" yaml:"long_term_exemption"`
+	Indexation        BoolType        `json:"indexation" yaml:"indexation"`
+	GrandfatherDate   string          `json:"grandfather_date" yaml:"grandfather_date"`
+	Exempt            BoolType        `json:"exempt" yaml:"exempt"`
+}
+
+type TaxJurisdiction struct {
+	Name  string    `json:"name" yaml:"name"`
+	Rules []TaxRule `json:"rules" yaml:"rules"`
+}
+
 type Config struct {
 	JournalPath                string       `json:"journal_path" yaml:"journal_path"`
 	DBPath                     string       `json:"db_path" yaml:"db_path"`
+	DBDSN                      string       `json:"db_dsn" yaml:"db_dsn"`
 	SheetsDirectory            string       `json:"sheets_directory" yaml:"sheets_directory"`
 	Readonly                   bool         `json:"readonly" yaml:"readonly"`
 	LedgerCli                  string       `json:"ledger_cli" yaml:"ledger_cli"`
@@ -165,7 +288,15 @@ type Config struct {
 
 	CreditCards []CreditCard `json:"credit_cards" yaml:"credit_cards"`
 
+	Loans []Loan `json:"loans" yaml:"loans"`
+
 	CustomValuations []CustomValuation `json:"custom_valuations" yaml:"custom_valuations"`
+
+	DoctorRules []DoctorRule `json:"doctor_rules" yaml:"doctor_rules"`
+
+	TaxJurisdiction string `json:"tax_jurisdiction" yaml:"tax_jurisdiction"`
+
+	TaxJurisdictions []TaxJurisdiction `json:"tax_jurisdictions" yaml:"tax_jurisdictions"`
 }
 
 var config Config
@@ -192,7 +323,11 @@ var defaultConfig = Config{
 	Goals:                      Goals{Retirement: []RetirementGoal{}, Savings: []SavingsGoal{}},
 	UserAccounts:               []UserAccount{},
 	CreditCards:                []CreditCard{},
+	Loans:                      []Loan{},
 	CustomValuations:           []CustomValuation{},
+	DoctorRules:                []DoctorRule{},
+	TaxJurisdiction:            "india",
+	TaxJurisdictions:           []TaxJurisdiction{},
 }
 
 var itemsUniquePropertiesMeta = jsonschema.MustCompileString("itemsUniqueProperties.json", `{
@@ -344,6 +479,33 @@ func LoadConfig(content []byte, cp string) error {
 		}
 	}
 
+	return validateTaxJurisdictions(config.TaxJurisdictions)
+}
+
+// validateTaxJurisdictions checks the dates and rates of the tax rules,
+// so the capital gains calculation doesn't have to deal with them
+func validateTaxJurisdictions(jurisdictions []TaxJurisdiction) error {
+	for _, jurisdiction := range jurisdictions {
+		for _, rule := range jurisdiction.Rules {
+			for _, date := range []string{rule.EffectiveFrom, rule.PurchasedFrom, rule.PurchasedBefore, rule.GrandfatherDate} {
+				if date == "" {
+					continue
+				}
+				if _, err := time.Parse("2006-01-02", date); err != nil {
+					return fmt.Errorf("Invalid tax rule in %s: Invalid date %s", jurisdiction.Name, date)
+				}
+			}
+
+			for _, rate := range []TaxRate{rule.ShortTermRate, rule.LongTermRate} {
+				if rate == "" || rate == Slab {
+					continue
+				}
+				if _, err := strconv.ParseFloat(string(rate), 64); err != nil {
+					return fmt.Errorf("Invalid tax rule in %s: Invalid tax rate %s", jurisdiction.Name, rate)
+				}
+			}
+		}
+	}
 	return nil
 }
 
@@ -385,6 +547,12 @@ func GetDBPath() string {
 	return config.DBPath
 }
 
+// GetDBDSN returns the PostgreSQL connection string, empty if the
+// SQLite database at db_path should be used
+func GetDBDSN() string {
+	return config.DBDSN
+}
+
 func GetConfigDir() string {
 	return filepath.Dir(configPath)
 }
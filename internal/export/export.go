@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CSV     = "csv"
+	XLSX    = "xlsx"
+	Parquet = "parquet"
+)
+
+var Formats = []string{CSV, XLSX, Parquet}
+
+var contentTypes = map[string]string{
+	CSV:     "text/csv; charset=utf-8",
+	XLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	Parquet: "application/vnd.apache.parquet",
+}
+
+// Table is the flat view of a report, the cells are either string,
+// int, time.Time or decimal.Decimal. All the cells of a column should
+// be of the same type, nil is used for the missing values.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
+
+func (t *Table) Add(cells ...any) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Records returns the rows as column name to cell maps
+func (t Table) Records() []map[string]any {
+	return lo.Map(t.Rows, func(row []any, _ int) map[string]any {
+		record := make(map[string]any)
+		for i, column := range t.Columns {
+			record[column] = row[i]
+		}
+		return record
+	})
+}
+
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// MultiTable tells whether the format could hold more than one table
+func MultiTable(format string) bool {
+	return format == XLSX
+}
+
+// Write encodes the tables in the format. CSV and Parquet can hold only
+// one table, so only the first one is written.
+func Write(w io.Writer, format string, tables []Table) error {
+	if len(tables) == 0 {
+		return fmt.Errorf("Nothing to export")
+	}
+
+	switch format {
+	case CSV:
+		return WriteCSV(w, tables[0])
+	case XLSX:
+		return WriteXLSX(w, tables)
+	case Parquet:
+		return WriteParquet(w, tables[0])
+	default:
+		return fmt.Errorf("Invalid format %s, expected one of csv, xlsx or parquet", format)
+	}
+}
+
+// FormatCell formats the cell as text, the decimals are formatted with
+// the given precision or as is if the precision is negative
+func FormatCell(cell any, precision int32) string {
+	switch value := cell.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return value.Format("2006-01-02")
+	case decimal.Decimal:
+		if precision < 0 {
+			return value.String()
+		}
+		return value.StringFixed(precision)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func WriteCSV(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		if err := writer.Write(lo.Map(row, func(cell any, _ int) string { return FormatCell(cell, -1) })); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
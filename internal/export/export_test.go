@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func testTables() []Table {
+	sales := Table{Name: "sales", Columns: []string{"account", "sell_date", "units", "gain"}}
+	sales.Add("Assets:Equity:ABC", time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC), 10, decimal.RequireFromString("1250.50"))
+	sales.Add("Assets:Equity:XYZ", nil, nil, decimal.RequireFromString("-20"))
+
+	exemptions := Table{Name: "exemptions", Columns: []string{"financial_year", "limit"}}
+	exemptions.Add("2023 - 24", decimal.NewFromInt(100000))
+	return []Table{sales, exemptions}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, CSV, testTables()))
+	assert.Equal(t, "account,sell_date,units,gain\nAssets:Equity:ABC,2023-05-10,10,1250.5\nAssets:Equity:XYZ,,,-20\n", buffer.String())
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, XLSX, testTables()))
+
+	f, err := excelize.OpenReader(&buffer)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"sales", "exemptions"}, f.GetSheetList())
+
+	rows, err := f.GetRows("sales")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"account", "sell_date", "units", "gain"}, rows[0])
+	assert.Equal(t, "Assets:Equity:ABC", rows[1][0])
+	assert.Equal(t, "1250.5", rows[1][3])
+
+	value, err := f.GetCellValue("exemptions", "B2")
+	require.NoError(t, err)
+	assert.Equal(t, "100000", value)
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, Parquet, testTables()))
+
+	type sale struct {
+		Account  *string  `parquet:"account,optional"`
+		SellDate *int32   `parquet:"sell_date,optional"`
+		Units    *int64   `parquet:"units,optional"`
+		Gain     *float64 `parquet:"gain,optional"`
+	}
+	rows, err := parquet.Read[sale](bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "Assets:Equity:ABC", *rows[0].Account)
+	assert.Equal(t, "2023-05-10", time.Unix(int64(*rows[0].SellDate)*86400, 0).UTC().Format("2006-01-02"))
+	assert.Equal(t, int64(10), *rows[0].Units)
+	assert.Equal(t, 1250.5, *rows[0].Gain)
+
+	assert.Nil(t, rows[1].SellDate)
+	assert.Nil(t, rows[1].Units)
+	assert.Equal(t, -20.0, *rows[1].Gain)
+}
+
+func TestWriteInvalidFormat(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Error(t, Write(&buffer, "pdf", testTables()))
+	assert.Error(t, Write(&buffer, CSV, nil))
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/shopspring/decimal"
+)
+
+// columnNode maps the go type of the column to the parquet type, the
+// decimals are stored as double and the dates as date. All the columns
+// are optional to support the missing values.
+func columnNode(table Table, column int) parquet.Node {
+	for _, row := range table.Rows {
+		switch row[column].(type) {
+		case nil:
+			continue
+		case int:
+			return parquet.Optional(parquet.Int(64))
+		case decimal.Decimal:
+			return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+		case time.Time:
+			return parquet.Optional(parquet.Date())
+		default:
+			return parquet.Optional(parquet.String())
+		}
+	}
+	return parquet.Optional(parquet.String())
+}
+
+func parquetValue(cell any) parquet.Value {
+	switch value := cell.(type) {
+	case nil:
+		return parquet.NullValue()
+	case int:
+		return parquet.Int64Value(int64(value))
+	case decimal.Decimal:
+		return parquet.DoubleValue(value.InexactFloat64())
+	case time.Time:
+		days := time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		return parquet.Int32Value(int32(days))
+	default:
+		return parquet.ByteArrayValue([]byte(FormatCell(value, -1)))
+	}
+}
+
+func WriteParquet(w io.Writer, table Table) error {
+	group := parquet.Group{}
+	for i, column := range table.Columns {
+		group[column] = columnNode(table, i)
+	}
+	schema := parquet.NewSchema(table.Name, group)
+
+	// the columns of the group are ordered by the name in the schema
+	indexes := make(map[string]int)
+	for i, path := range schema.Columns() {
+		indexes[path[0]] = i
+	}
+
+	rows := make([]parquet.Row, len(table.Rows))
+	for r, row := range table.Rows {
+		values := make(parquet.Row, len(table.Columns))
+		for i, column := range table.Columns {
+			index := indexes[column]
+			if row[i] == nil {
+				values[index] = parquet.NullValue().Level(0, 0, index)
+			} else {
+				values[index] = parquetValue(row[i]).Level(0, 1, index)
+			}
+		}
+		rows[r] = values
+	}
+
+	writer := parquet.NewWriter(w, schema)
+	if _, err := writer.WriteRows(rows); err != nil {
+		return err
+	}
+	return writer.Close()
+}
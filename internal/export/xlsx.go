@@ -0,0 +1,64 @@
+package export
+
+import (
+	"io"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// WriteXLSX writes each table to its own sheet, in the same order
+func WriteXLSX(w io.Writer, tables []Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	header, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	for i, table := range tables {
+		sheet := table.Name
+		if i == 0 {
+			err = f.SetSheetName("Sheet1", sheet)
+		} else {
+			_, err = f.NewSheet(sheet)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := f.SetSheetRow(sheet, "A1", &table.Columns); err != nil {
+			return err
+		}
+		last, err := excelize.CoordinatesToCellName(len(table.Columns), 1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, "A1", last, header); err != nil {
+			return err
+		}
+		if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+			return err
+		}
+
+		for r, row := range table.Rows {
+			cells := lo.Map(row, func(cell any, _ int) any {
+				if value, ok := cell.(decimal.Decimal); ok {
+					return value.InexactFloat64()
+				}
+				return cell
+			})
+			start, err := excelize.CoordinatesToCellName(1, r+2)
+			if err != nil {
+				return err
+			}
+			if err := f.SetSheetRow(sheet, start, &cells); err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.Write(w)
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The templates use dayjs date formats like DD/MM/YYYY. They are
+// converted to the go layout, the parsing is strict like the dayjs
+// custom parse format plugin, the parsed date has to format back to the
+// exact input.
+
+var dayjsTokens = regexp.MustCompile(`\[[^\]]*]|YYYY|YY|MMMM|MMM|MM|M|Do|DD|D|dddd|ddd|HH|H|hh|h|mm|m|ss|s|SSS|A|a|Q|ww?|zz?|ZZ?|[0-9]+`)
+
+var dayjsLayouts = map[string]string{
+	"YYYY": "2006",
+	"YY":   "06",
+	"MMMM": "January",
+	"MMM":  "Jan",
+	"MM":   "01",
+	"M":    "1",
+	"DD":   "02",
+	"D":    "2",
+	"dddd": "Monday",
+	"ddd":  "Mon",
+	"HH":   "15",
+	"hh":   "03",
+	"h":    "3",
+	"mm":   "04",
+	"m":    "4",
+	"ss":   "05",
+	"s":    "5",
+	"SSS":  "000",
+	"A":    "PM",
+	"a":    "pm",
+}
+
+func layout(format string) (string, error) {
+	var err error
+	result := dayjsTokens.ReplaceAllStringFunc(format, func(token string) string {
+		if strings.HasPrefix(token, "[") {
+			return token[1 : len(token)-1]
+		}
+		if l, ok := dayjsLayouts[token]; ok {
+			return l
+		}
+		err = fmt.Errorf("Unsupported date format %s", format)
+		return token
+	})
+	return result, err
+}
+
+func parseDate(input string, format string) (time.Time, error) {
+	if format == "" {
+		return time.Time{}, fmt.Errorf("Invalid Date")
+	}
+
+	l, err := layout(format)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := time.ParseInLocation(l, input, time.UTC)
+	if err != nil || date.Format(l) != input {
+		return time.Time{}, fmt.Errorf("Invalid Date")
+	}
+	return date, nil
+}
+
+func formatDate(date time.Time, format string) string {
+	l, _ := layout(format)
+	return date.Format(l)
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Port of format from src/lib/journal.ts, which aligns the amounts of
+// the postings to the amount alignment column.
+
+var (
+	transactionHeader = regexp.MustCompile(`^\d{4}[/-]\d{2}[/-]\d{2}`)
+	periodicHeader    = regexp.MustCompile(`^[~=]`)
+	notIndented       = regexp.MustCompile(`^[^ \t]`)
+
+	// an account can have single spaces, two spaces end it
+	accountPattern = `(?:[*!]\s+)?[^; \t\n](?:[^;\s]|[ \r\f\v][^;\s])+`
+	fullPosting    = regexp.MustCompile(`^[ \t]+(` + accountPattern + `)[ \t]+([^;]*?)([+-]?[.,0-9]+)(.*)$`)
+	partialPosting = regexp.MustCompile(`^[ \t]+(` + accountPattern + `[ \r\f\v]?)$`)
+)
+
+func Format(text string, amountAlignmentColumn int) string {
+	inTransaction := false
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if transactionHeader.MatchString(line) || periodicHeader.MatchString(line) {
+			inTransaction = true
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" || notIndented.MatchString(line) {
+			inTransaction = false
+		}
+
+		if !inTransaction {
+			continue
+		}
+
+		lines[i] = formatPosting(line, amountAlignmentColumn)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatPosting(line string, amountAlignmentColumn int) string {
+	if m := fullPosting.FindStringSubmatch(line); m != nil {
+		account, prefix, amount, suffix := m[1], m[2], m[3], m[4]
+		width := utf8.RuneCountInString(account) + utf8.RuneCountInString(prefix) + utf8.RuneCountInString(amount)
+		if width <= amountAlignmentColumn-6 {
+			return strings.Repeat(" ", 4) + account + strings.Repeat(" ", amountAlignmentColumn-4-width) + prefix + amount + suffix
+		}
+	}
+
+	if m := partialPosting.FindStringSubmatch(line); m != nil {
+		return strings.Repeat(" ", 4) + m[1]
+	}
+	return line
+}
@@ -0,0 +1,230 @@
+package importer
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mailgun/raymond/v2"
+	"github.com/mailgun/raymond/v2/ast"
+	"github.com/mailgun/raymond/v2/parser"
+	"github.com/samber/lo"
+)
+
+// The import templates are rendered in the browser by Handlebars and
+// here by raymond. The helpers of the import page take any number of
+// arguments and read the hash in the order it's written, raymond calls
+// a helper only with its exact number of arguments and passes the hash
+// as a map. So each call of a helper is renamed to a helper registered
+// just for that call.
+
+// raymond checks the text left after stripping the previous standalone
+// line, so the indentation of the consecutive block lines is kept. The
+// standalone block lines are dedented before parsing, Handlebars removes
+// the whole line anyway.
+var standaloneBlock = regexp.MustCompile(`(?m)^[ \t]+(\{\{~?(?:[#^/!]|else\b)[^{}\n]*\}\}[ \t]*(?:\r?\n|$))`)
+
+// builtinHelpers are the helpers of Handlebars, raymond renders the
+// calls of any other unknown helper as empty instead of failing.
+var builtinHelpers = []string{"if", "unless", "with", "each", "log", "lookup"}
+
+type options struct {
+	hash     map[string]any
+	hashKeys []string
+	data     *raymond.DataFrame
+}
+
+func (o *options) root() map[string]any {
+	root, _ := o.data.Get("root").(map[string]any)
+	return root
+}
+
+func (o *options) row() map[string]any {
+	row, _ := o.root()["ROW"].(map[string]any)
+	return row
+}
+
+type helper func(args []any, o *options) any
+
+// Template is a parsed import template.
+type Template struct {
+	template *raymond.Template
+}
+
+func Compile(source string, helpers map[string]helper) (*Template, error) {
+	source = standaloneBlock.ReplaceAllString(source, "$1")
+	program, err := parser.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	finder := &callFinder{helpers: helpers}
+	program.Accept(finder)
+	if finder.missing != "" {
+		return nil, fmt.Errorf("Missing helper: %q", finder.missing)
+	}
+	sort.Slice(finder.calls, func(i, j int) bool { return finder.calls[i].pos > finder.calls[j].pos })
+
+	registered := make(map[string]any)
+	for i, c := range finder.calls {
+		name := fmt.Sprintf("%s_%d", c.name, len(finder.calls)-i)
+		source = source[:c.pos] + name + source[c.pos+len(c.name):]
+		registered[name] = c.bind(helpers[c.name])
+	}
+
+	template, err := raymond.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	template.RegisterHelpers(registered)
+	return &Template{template: template}, nil
+}
+
+// Render executes the template with the given context.
+func (t *Template) Render(context map[string]any) (string, error) {
+	data := raymond.NewDataFrame()
+	data.Set("root", context)
+	return t.template.ExecWith(context, data)
+}
+
+type call struct {
+	name     string
+	pos      int
+	arity    int
+	hashKeys []string
+}
+
+var (
+	anyType     = reflect.TypeOf((*any)(nil)).Elem()
+	optionsType = reflect.TypeOf(&raymond.Options{})
+)
+
+// bind wraps the helper in a function that takes the exact number of
+// arguments of the call, followed by the options.
+func (c call) bind(h helper) any {
+	in := make([]reflect.Type, c.arity+1)
+	for i := 0; i < c.arity; i++ {
+		in[i] = anyType
+	}
+	in[c.arity] = optionsType
+
+	return reflect.MakeFunc(reflect.FuncOf(in, []reflect.Type{anyType}, false), func(values []reflect.Value) []reflect.Value {
+		args := make([]any, c.arity)
+		for i := range args {
+			args[i] = values[i].Interface()
+		}
+		o := values[c.arity].Interface().(*raymond.Options)
+		result := h(args, &options{hash: o.Hash(), hashKeys: c.hashKeys, data: o.DataFrame()})
+		return []reflect.Value{reflect.ValueOf(&result).Elem()}
+	}).Interface()
+}
+
+// callFinder walks the template and collects the calls of the helpers.
+type callFinder struct {
+	helpers map[string]helper
+	calls   []call
+	missing string
+}
+
+func (f *callFinder) accept(node ast.Node) {
+	if node != nil && !reflect.ValueOf(node).IsNil() {
+		node.Accept(f)
+	}
+}
+
+func (f *callFinder) VisitProgram(node *ast.Program) interface{} {
+	for _, n := range node.Body {
+		f.accept(n)
+	}
+	return nil
+}
+
+func (f *callFinder) VisitMustache(node *ast.MustacheStatement) interface{} {
+	f.accept(node.Expression)
+	return nil
+}
+
+func (f *callFinder) VisitBlock(node *ast.BlockStatement) interface{} {
+	f.accept(node.Expression)
+	f.accept(node.Program)
+	f.accept(node.Inverse)
+	return nil
+}
+
+func (f *callFinder) VisitPartial(node *ast.PartialStatement) interface{} {
+	for _, n := range node.Params {
+		f.accept(n)
+	}
+	f.accept(node.Hash)
+	return nil
+}
+
+func (f *callFinder) VisitExpression(node *ast.Expression) interface{} {
+	if name := node.HelperName(); name != "" && f.helpers[name] != nil {
+		c := call{name: name, pos: node.Path.Location().Pos, arity: len(node.Params)}
+		if node.Hash != nil {
+			for _, pair := range node.Hash.Pairs {
+				c.hashKeys = append(c.hashKeys, pair.Key)
+			}
+		}
+		f.calls = append(f.calls, c)
+	} else if name != "" && (len(node.Params) > 0 || node.Hash != nil) && !lo.Contains(builtinHelpers, name) && f.missing == "" {
+		f.missing = name
+	}
+
+	for _, n := range node.Params {
+		f.accept(n)
+	}
+	f.accept(node.Hash)
+	return nil
+}
+
+func (f *callFinder) VisitSubExpression(node *ast.SubExpression) interface{} {
+	f.accept(node.Expression)
+	return nil
+}
+
+func (f *callFinder) VisitHash(node *ast.Hash) interface{} {
+	for _, pair := range node.Pairs {
+		f.accept(pair)
+	}
+	return nil
+}
+
+func (f *callFinder) VisitHashPair(node *ast.HashPair) interface{} {
+	f.accept(node.Val)
+	return nil
+}
+
+func (f *callFinder) VisitContent(node *ast.ContentStatement) interface{} { return nil }
+func (f *callFinder) VisitComment(node *ast.CommentStatement) interface{} { return nil }
+func (f *callFinder) VisitPath(node *ast.PathExpression) interface{}      { return nil }
+func (f *callFinder) VisitString(node *ast.StringLiteral) interface{}     { return nil }
+func (f *callFinder) VisitBoolean(node *ast.BooleanLiteral) interface{}   { return nil }
+func (f *callFinder) VisitNumber(node *ast.NumberLiteral) interface{}     { return nil }
+
+// toString converts the value to string like the javascript String
+// function.
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "undefined"
+	case []any:
+		return strings.Join(stringValues(v), ",")
+	case map[string]any:
+		return "[object Object]"
+	}
+	return raymond.Str(value)
+}
+
+func stringValues(values []any) []string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			strs[i] = toString(value)
+		}
+	}
+	return strs
+}
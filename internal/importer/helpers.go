@@ -0,0 +1,458 @@
+package importer
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+// Go port of src/lib/template_helpers.ts. The helpers in the browser
+// swallow the errors and return undefined, the same is done here by
+// returning nil.
+
+var stopWords = []string{"", "fof", "growth", "direct", "plan", "the"}
+
+// Predictor returns the accounts matching the query, best match first.
+type Predictor func(query string) []string
+
+var regexpCache sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+var (
+	parenthesisAmount = regexp.MustCompile(`\((.+)\)`)
+	nonAmount         = regexp.MustCompile(`[^0-9.-]`)
+	validAmount       = regexp.MustCompile(`^-?(\d+\.?\d*|\.\d+)$`)
+	nonLetter         = regexp.MustCompile(`[^a-zA-Z ]`)
+)
+
+func scrubAmount(value any) (string, bool) {
+	amount := strings.TrimSpace(trimString(value))
+	if m := parenthesisAmount.FindStringSubmatchIndex(amount); m != nil {
+		amount = amount[:m[0]] + "-" + amount[m[2]:m[3]] + amount[m[1]:]
+	}
+	amount = nonAmount.ReplaceAllString(amount, "")
+	if validAmount.MatchString(amount) {
+		return amount, true
+	}
+	return "", false
+}
+
+// parseAmount returns NaN for invalid amounts, which makes all the
+// comparisons false like undefined in javascript.
+func parseAmount(value any) float64 {
+	if f, ok := value.(float64); ok {
+		return f
+	}
+	amount, ok := scrubAmount(value)
+	if !ok {
+		return math.NaN()
+	}
+	f, _ := strconv.ParseFloat(amount, 64)
+	return f
+}
+
+func trimString(value any) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(toString(value))
+}
+
+func arg(args []any, i int) any {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+func integer(value any) int {
+	f := parseAmount(value)
+	if math.IsNaN(f) {
+		return 0
+	}
+	return int(f)
+}
+
+// round rounds half up like lodash, shifting the decimal string instead
+// of multiplying the float.
+func round(f float64, precision int) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	d, err := decimal.NewFromString(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return math.NaN()
+	}
+	shifted := d.Shift(int32(precision)).Add(decimal.NewFromFloat(0.5)).Floor()
+	result, _ := shifted.Shift(-int32(precision)).Float64()
+	return result
+}
+
+// truthy follows the javascript rules.
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case int:
+		return v != 0
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+func identical(a, b any) bool {
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !ra.IsValid() || !rb.IsValid() {
+		return !ra.IsValid() && !rb.IsValid()
+	}
+	if ra.Kind() != rb.Kind() {
+		return false
+	}
+	switch ra.Kind() {
+	case reflect.Map, reflect.Slice:
+		return ra.Pointer() == rb.Pointer() && ra.Len() == rb.Len()
+	}
+	return ra.Type().Comparable() && a == b
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case float64:
+		b, ok := b.(float64)
+		return ok && a == b
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	}
+	return identical(a, b)
+}
+
+func matchRegexp(value any, pattern string, group any) any {
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		return nil
+	}
+	str := toString(value)
+	m := re.FindStringSubmatchIndex(str)
+	if m == nil {
+		return nil
+	}
+	i := integer(group)
+	if i < 0 || 2*i+1 >= len(m) || m[2*i] < 0 {
+		return nil
+	}
+	return str[m[2*i]:m[2*i+1]]
+}
+
+func nextColumn(key string) string {
+	if key == "Z" {
+		return "AA"
+	}
+	last := key[len(key)-1:]
+	butlast := key[:len(key)-1]
+	if last == "Z" {
+		return nextColumn(butlast) + "A"
+	}
+	return butlast + string(rune(last[0]+1))
+}
+
+// rowValues returns the cells in the column order, followed by the
+// index, the same order javascript iterates the row object.
+func rowValues(row map[string]any) []any {
+	keys := lo.Keys(row)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "index" || keys[j] == "index" {
+			return keys[j] == "index" && keys[i] != "index"
+		}
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return lo.Map(keys, func(key string, _ int) any { return row[key] })
+}
+
+func join(values []any, separator string) string {
+	return strings.Join(lo.Map(values, func(value any, _ int) string {
+		if value == nil {
+			return ""
+		}
+		return toString(value)
+	}), separator)
+}
+
+func find(o *options, column any, direction int) any {
+	pattern := ".+"
+	if truthy(o.hash["regexp"]) {
+		pattern = toString(o.hash["regexp"])
+	}
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		return nil
+	}
+
+	sheet, _ := o.root()["SHEET"].([]any)
+	index := integer(o.row()["index"])
+	for i := index + direction; i >= 0 && i < len(sheet); i += direction {
+		row, _ := sheet[i].(map[string]any)
+		cell := ""
+		if truthy(row[toString(column)]) {
+			cell = toString(row[toString(column)])
+		}
+		m := re.FindStringSubmatchIndex(cell)
+		if m == nil {
+			continue
+		}
+		if truthy(o.hash["group"]) {
+			group := integer(o.hash["group"])
+			if 2*group+1 >= len(m) || m[2*group] < 0 {
+				return nil
+			}
+			return cell[m[2*group]:m[2*group+1]]
+		}
+		return cell
+	}
+	return nil
+}
+
+func templateHelpers(predict Predictor) map[string]helper {
+	return map[string]helper{
+		"eq": func(args []any, o *options) any {
+			return equal(arg(args, 0), arg(args, 1))
+		},
+		"ne": func(args []any, o *options) any {
+			return !equal(arg(args, 0), arg(args, 1))
+		},
+		"not": func(args []any, o *options) any {
+			return !truthy(arg(args, 0))
+		},
+		"gte": func(args []any, o *options) any {
+			return parseAmount(arg(args, 0)) >= parseAmount(arg(args, 1))
+		},
+		"gt": func(args []any, o *options) any {
+			return parseAmount(arg(args, 0)) > parseAmount(arg(args, 1))
+		},
+		"lte": func(args []any, o *options) any {
+			return parseAmount(arg(args, 0)) <= parseAmount(arg(args, 1))
+		},
+		"lt": func(args []any, o *options) any {
+			return parseAmount(arg(args, 0)) < parseAmount(arg(args, 1))
+		},
+		"negate": func(args []any, o *options) any {
+			return parseAmount(arg(args, 0)) * -1
+		},
+		"round": func(args []any, o *options) any {
+			return round(parseAmount(arg(args, 0)), integer(o.hash["precision"]))
+		},
+		"and": func(args []any, o *options) any {
+			return lo.EveryBy(args, truthy)
+		},
+		"or": func(args []any, o *options) any {
+			for _, a := range args {
+				if truthy(a) {
+					return a
+				}
+			}
+			return nil
+		},
+		"isDate": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return false
+			}
+			format, _ := arg(args, 1).(string)
+			_, err := parseDate(strings.TrimSpace(str), format)
+			return err == nil
+		},
+		"predictAccount": func(args []any, o *options) any {
+			var query string
+			if len(args) == 0 {
+				query = join(rowValues(o.row()), " ")
+			} else {
+				query = join(lo.FlatMap(args, func(a any, _ int) []any {
+					switch a := a.(type) {
+					case map[string]any:
+						return rowValues(a)
+					case []any:
+						return a
+					}
+					return []any{a}
+				}), " ")
+			}
+
+			prefix := ""
+			if truthy(o.hash["prefix"]) {
+				prefix = toString(o.hash["prefix"])
+			}
+
+			if predict != nil {
+				for _, account := range predict(query) {
+					if strings.HasPrefix(account, prefix) {
+						return account
+					}
+				}
+			}
+
+			if strings.HasSuffix(prefix, ":") {
+				return prefix + "Unknown"
+			}
+			return prefix + ":Unknown"
+		},
+		"isBlank": func(args []any, o *options) any {
+			str := arg(args, 0)
+			switch v := str.(type) {
+			case nil, float64, bool:
+				return true
+			case string:
+				return strings.TrimSpace(v) == ""
+			}
+			return false
+		},
+		"amount": func(args []any, o *options) any {
+			if amount, ok := scrubAmount(arg(args, 0)); ok {
+				return amount
+			}
+			if truthy(o.hash["default"]) {
+				return o.hash["default"]
+			}
+			return ""
+		},
+		"date": func(args []any, o *options) any {
+			format, _ := arg(args, 1).(string)
+			date, err := parseDate(trimString(arg(args, 0)), format)
+			if err != nil {
+				return "Invalid Date"
+			}
+			return formatDate(date, "YYYY/MM/DD")
+		},
+		"trim": func(args []any, o *options) any {
+			return trimString(arg(args, 0))
+		},
+		"replace": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return nil
+			}
+			return strings.ReplaceAll(str, toString(arg(args, 1)), toString(arg(args, 2)))
+		},
+		"textRange": func(args []any, o *options) any {
+			from, to := toString(arg(args, 0)), toString(arg(args, 1))
+			row := o.row()
+			var cells []any
+			current := from
+			for i := 0; i < 1000 && current != ""; i++ {
+				cells = append(cells, row[current])
+				if current == to {
+					break
+				}
+				current = nextColumn(current)
+			}
+			separator := " "
+			if truthy(o.hash["separator"]) {
+				separator = toString(o.hash["separator"])
+			}
+			return join(cells, separator)
+		},
+		"regexpTest": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return nil
+			}
+			re, err := compileRegexp(toString(arg(args, 1)))
+			if err != nil {
+				return nil
+			}
+			return re.MatchString(str)
+		},
+		"regexpMatch": func(args []any, o *options) any {
+			if _, ok := arg(args, 0).(string); !ok {
+				return nil
+			}
+			return matchRegexp(arg(args, 0), toString(arg(args, 1)), o.hash["group"])
+		},
+		"match": func(args []any, o *options) any {
+			for _, key := range o.hashKeys {
+				re, err := compileRegexp(toString(o.hash[key]))
+				if err != nil {
+					return nil
+				}
+				if re.MatchString(toString(arg(args, 0))) {
+					return key
+				}
+			}
+			return nil
+		},
+		"findAbove": func(args []any, o *options) any {
+			return find(o, arg(args, 0), -1)
+		},
+		"findBelow": func(args []any, o *options) any {
+			return find(o, arg(args, 0), 1)
+		},
+		"acronym": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return nil
+			}
+			words := lo.Filter(strings.Split(nonLetter.ReplaceAllString(str, ""), " "), func(word string, _ int) bool {
+				return !lo.Contains(stopWords, strings.ToLower(word))
+			})
+			return strings.Join(lo.Map(words, func(word string, _ int) string {
+				return strings.ToUpper(word[:1])
+			}), "")
+		},
+		"toLowerCase": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return nil
+			}
+			return strings.ToLower(str)
+		},
+		"toUpperCase": func(args []any, o *options) any {
+			str, ok := arg(args, 0).(string)
+			if !ok {
+				return nil
+			}
+			return strings.ToUpper(str)
+		},
+		"capitalize": func(args []any, o *options) any {
+			var str []rune
+			if arg(args, 0) != nil {
+				str = []rune(strings.ToLower(toString(arg(args, 0))))
+			}
+			if len(str) == 0 {
+				return ""
+			}
+			str[0] = unicode.ToUpper(str[0])
+			return string(str)
+		},
+	}
+}
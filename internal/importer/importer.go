@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/template"
+	"github.com/ananthakumaran/paisa/internal/prediction"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type Transaction struct {
+	Date      time.Time `json:"date"`
+	Text      string    `json:"text"`
+	Duplicate bool      `json:"duplicate"`
+}
+
+type Result struct {
+	Transactions []Transaction `json:"transactions"`
+	Duplicates   int           `json:"duplicates"`
+}
+
+// Ledger returns the transactions formatted and ready to be appended
+// to the journal.
+func (r Result) Ledger(includeDuplicates bool) string {
+	texts := lo.FilterMap(r.Transactions, func(t Transaction, _ int) (string, bool) {
+		return t.Text, includeDuplicates || !t.Duplicate
+	})
+	return Format(strings.Join(texts, "\n\n"), config.GetConfig().AmountAlignmentColumn)
+}
+
+// Render applies the template to each row of the sheet, same as the
+// import page, and returns the non empty results.
+func Render(sheet Sheet, content string, predict Predictor) ([]string, error) {
+	tmpl, err := Compile(content, templateHelpers(predict))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := sheet.Rows()
+	var output []string
+	for _, row := range rows {
+		context := map[string]any{"ROW": row, "SHEET": rows}
+		// javascript range excludes the end, so Z is not available
+		for c := 'A'; c < 'Z'; c++ {
+			context[string(c)] = string(c)
+		}
+
+		rendered, err := tmpl.Render(context)
+		if err != nil {
+			return nil, err
+		}
+		rendered = strings.TrimSpace(rendered)
+		if rendered != "" {
+			output = append(output, rendered)
+		}
+	}
+	return output, nil
+}
+
+// Import converts the statement to ledger transactions using the named
+// template. The accounts are predicted from the existing postings and
+// the transactions already in the journal are marked as duplicate.
+func Import(db *gorm.DB, templateName string, fileName string, data []byte, reverse bool) (Result, error) {
+	tmpl, found := lo.Find(template.All(), func(t template.Template) bool { return t.Name == templateName })
+	if !found {
+		return Result{}, fmt.Errorf("Template %s not found", templateName)
+	}
+
+	sheet, err := ParseFile(fileName, data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	rendered, err := Render(sheet, tmpl.Content, func(query string) []string {
+		return prediction.FindMatch(db, query)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if reverse {
+		rendered = lo.Reverse(rendered)
+	}
+
+	var transactions []Transaction
+	for _, text := range rendered {
+		for _, t := range transactionSeparator.Split(text, -1) {
+			transactions = append(transactions, Transaction{Text: t})
+		}
+	}
+
+	return markDuplicates(db, transactions), nil
+}
+
+var (
+	transactionSeparator = regexp.MustCompile(`\n[ \t]*\n\s*`)
+	headerDate           = regexp.MustCompile(`^(\d{4})[/-](\d{2})[/-](\d{2})`)
+	postingLine          = regexp.MustCompile(`^[ \t]+(?:[*!]\s+)?([^;\s](?:[^;\t]*?[^;\s])??)(?:(?:\t|  )[ \t]*([^;]*?))?\s*(?:;.*)?$`)
+	amountPattern        = regexp.MustCompile(`^("[^"]+"|[^\d\s+\-.,;@"]+)?\s*([+-]?[\d,]*\.?\d+)\s*("[^"]+"|[^\d\s+\-.,;@"]+)?$`)
+)
+
+type entry struct {
+	account   string
+	commodity string
+	quantity  decimal.Decimal
+}
+
+func (e entry) key(date time.Time) string {
+	return strings.Join([]string{date.Format("2006-01-02"), e.account, e.commodity, e.quantity.Round(4).String()}, "|")
+}
+
+// markDuplicates compares the postings with known amount against the
+// postings in the journal on the same date. Each journal posting can
+// match only one imported transaction.
+func markDuplicates(db *gorm.DB, transactions []Transaction) Result {
+	parsed := make([][]entry, len(transactions))
+	var from, to time.Time
+	for i := range transactions {
+		date, entries, ok := parseTransaction(transactions[i].Text)
+		if !ok {
+			continue
+		}
+		transactions[i].Date = date
+		parsed[i] = entries
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if to.IsZero() || date.After(to) {
+			to = date
+		}
+	}
+
+	result := Result{Transactions: transactions}
+	if from.IsZero() {
+		return result
+	}
+
+	existing := make(map[string]int)
+	for _, p := range query.Init(db).Where("date >= ? AND date < ?", from, to.AddDate(0, 0, 1)).All() {
+		existing[postingKey(p)]++
+	}
+
+	for i, entries := range parsed {
+		for _, e := range entries {
+			key := e.key(transactions[i].Date)
+			if existing[key] > 0 {
+				existing[key]--
+				result.Transactions[i].Duplicate = true
+				result.Duplicates++
+				break
+			}
+		}
+	}
+	return result
+}
+
+func postingKey(p posting.Posting) string {
+	return entry{account: p.Account, commodity: p.Commodity, quantity: p.Quantity}.key(p.Date.In(config.TimeZone()))
+}
+
+// parseTransaction extracts the date and the postings of a generated
+// transaction. The amount of a posting without one is inferred when
+// the rest of the postings are in a single commodity.
+func parseTransaction(text string) (time.Time, []entry, bool) {
+	lines := strings.Split(text, "\n")
+	m := headerDate.FindStringSubmatch(lines[0])
+	if m == nil {
+		return time.Time{}, nil, false
+	}
+	date, err := time.ParseInLocation("2006-01-02", strings.Join(m[1:], "-"), config.TimeZone())
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+
+	var entries []entry
+	elided, missing := -1, 0
+	balance := make(map[string]decimal.Decimal)
+	for _, line := range lines[1:] {
+		pm := postingLine.FindStringSubmatch(line)
+		if pm == nil {
+			continue
+		}
+
+		account, amount := pm[1], strings.TrimSpace(pm[2])
+		if amount == "" {
+			missing++
+			elided = len(entries)
+			entries = append(entries, entry{account: account})
+			continue
+		}
+
+		quantity, commodity, ok := parseQuantity(amount)
+		if !ok {
+			continue
+		}
+		entries = append(entries, entry{account: account, commodity: commodity, quantity: quantity})
+
+		costCommodity, cost := commodity, quantity
+		if _, price, found := strings.Cut(amount, "@"); found {
+			if total, isTotal := strings.CutPrefix(price, "@"); isTotal {
+				if c, pc, ok := parseQuantity(total); ok {
+					costCommodity, cost = pc, c.Abs().Mul(decimal.NewFromInt(int64(quantity.Sign())))
+				}
+			} else if c, pc, ok := parseQuantity(price); ok {
+				costCommodity, cost = pc, c.Mul(quantity)
+			}
+		}
+		balance[costCommodity] = balance[costCommodity].Add(cost)
+	}
+
+	if missing == 1 && len(balance) == 1 {
+		for commodity, total := range balance {
+			entries[elided] = entry{account: entries[elided].account, commodity: commodity, quantity: total.Neg()}
+		}
+	}
+	return date, lo.Filter(entries, func(e entry, _ int) bool { return e.commodity != "" }), true
+}
+
+func parseQuantity(amount string) (decimal.Decimal, string, bool) {
+	amount, _, _ = strings.Cut(amount, "@")
+	m := amountPattern.FindStringSubmatch(strings.TrimSpace(amount))
+	if m == nil {
+		return decimal.Zero, "", false
+	}
+	quantity, err := decimal.NewFromString(strings.ReplaceAll(m[2], ",", ""))
+	if err != nil {
+		return decimal.Zero, "", false
+	}
+
+	commodity := m[1]
+	if commodity == "" {
+		commodity = m[3]
+	}
+	commodity = strings.Trim(commodity, `"`)
+	if commodity == "" {
+		commodity = config.DefaultCurrency()
+	}
+	return quantity, commodity, true
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/model/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	dirs, err := os.ReadDir("../../fixture/import")
+	require.NoError(t, err)
+
+	for _, dir := range dirs {
+		files, err := os.ReadDir(filepath.Join("../../fixture/import", dir.Name()))
+		require.NoError(t, err)
+
+		for _, file := range files {
+			name, extension, _ := strings.Cut(file.Name(), ".")
+			if extension != "ledger" {
+				continue
+			}
+
+			for _, input := range files {
+				if input.Name() == file.Name() || !strings.HasPrefix(input.Name(), name) {
+					continue
+				}
+
+				t.Run(dir.Name()+"/"+input.Name(), func(t *testing.T) {
+					data, err := os.ReadFile(filepath.Join("../../fixture/import", dir.Name(), input.Name()))
+					require.NoError(t, err)
+					sheet, err := ParseFile(input.Name(), data)
+					if err != nil {
+						t.Skip(err.Error())
+					}
+
+					content, err := template.BuiltinTemplates.ReadFile("templates/" + dir.Name() + ".handlebars")
+					require.NoError(t, err)
+					expected, err := os.ReadFile(filepath.Join("../../fixture/import", dir.Name(), file.Name()))
+					require.NoError(t, err)
+
+					rendered, err := Render(sheet, string(content), nil)
+					require.NoError(t, err)
+					assert.Equal(t, strings.TrimSpace(string(expected)), Format(strings.Join(rendered, "\n\n"), 52))
+				})
+			}
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	rows := Sheet{
+		{"Date", "Description", "Amount"},
+		{"28/03/2023", "AMAZON & CO", "(1,249.50)"},
+		{"29/03/2023", "SALARY", "50000"},
+	}
+
+	content := `{{#if (isDate ROW.A "DD/MM/YYYY")}}
+  {{date ROW.A "DD/MM/YYYY"}} {{ROW.B}} {{!-- ignored --}}
+  {{#if (lt (amount ROW.C) 0)}}
+    {{predictAccount prefix="Expenses"}}  {{negate (amount ROW.C)}} INR
+  {{else}}
+    {{predictAccount prefix="Income:"}}   {{round ROW.C precision=2}} INR ; {{acronym "the first bank"}} {{findAbove B}}
+  {{/if}}
+    Assets:Checking
+{{/if}}`
+
+	predict := func(query string) []string {
+		if strings.Contains(query, "AMAZON") {
+			return []string{"Assets:Checking", "Expenses:Shopping"}
+		}
+		return nil
+	}
+
+	rendered, err := Render(rows, content, predict)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"2023/03/28 AMAZON &amp; CO \n    Expenses:Shopping  1249.5 INR\n    Assets:Checking",
+		"2023/03/29 SALARY \n    Income:Unknown   50000 INR ; FB AMAZON &amp; CO\n    Assets:Checking",
+	}, rendered)
+
+	_, err = Render(rows, "{{#if ROW.A}}", nil)
+	assert.ErrorContains(t, err, "Parse error on line 1")
+
+	_, err = Render(rows, "{{missing ROW.A}}", nil)
+	assert.EqualError(t, err, `Missing helper: "missing"`)
+}
+
+func TestParseDate(t *testing.T) {
+	valid := map[string]string{
+		"12 Nov 2022": "D MMM YYYY",
+		"05/11/22":    "DD/MM/YY",
+		"1/05/2023":   "M/DD/YYYY",
+		"2023-01-31":  "YYYY-MM-DD",
+		"09-Jan-2023": "DD-MMM-YYYY",
+	}
+	for input, format := range valid {
+		_, err := parseDate(input, format)
+		assert.NoError(t, err, input)
+	}
+
+	date, _ := parseDate("05/11/22", "DD/MM/YY")
+	assert.Equal(t, "2022/11/05", formatDate(date, "YYYY/MM/DD"))
+
+	invalid := map[string]string{
+		"5/11/22":     "DD/MM/YY",
+		"31/02/2023":  "DD/MM/YYYY",
+		"12 NOV 2022": "D MMM YYYY",
+		"Date":        "DD/MM/YYYY",
+	}
+	for input, format := range invalid {
+		_, err := parseDate(input, format)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseTransaction(t *testing.T) {
+	date, entries, ok := parseTransaction(`2022/06/17 Purchased 2 Shares of FCL
+    Assets:Stocks:FCL                              2 "FCL" @ 163.1 INR
+    Assets:Broker:Zerodha`)
+	require.True(t, ok)
+	assert.Equal(t, "2022-06-17", date.Format("2006-01-02"))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2022-06-17|Assets:Stocks:FCL|FCL|2", entries[0].key(date))
+	assert.Equal(t, "2022-06-17|Assets:Broker:Zerodha|INR|-326.2", entries[1].key(date))
+}
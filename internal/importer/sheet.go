@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Sheet is the parsed file, a list of rows with the cells in column
+// order. A missing cell is nil.
+type Sheet [][]any
+
+var delimitersToGuess = []rune{',', '\t', '|', ';', '\x1e', '\x1f', '^'}
+
+// ParseFile parses the file based on the extension, like the import
+// page. PDF statements have to be converted to text first, for example
+// with pdftotext, and imported as txt.
+func ParseFile(name string, data []byte) (Sheet, error) {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch extension {
+	case "csv", "txt":
+		return ParseCSV(data)
+	case "xlsx":
+		return ParseXLSX(data)
+	}
+	return nil, fmt.Errorf("Unsupported file type %s", extension)
+}
+
+func ParseCSV(data []byte) (Sheet, error) {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	if !strings.Contains(text, "\n") {
+		text = strings.ReplaceAll(text, "\r", "\n")
+	}
+
+	records, err := readCSV(text, guessDelimiter(text), -1)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := make(Sheet, len(records))
+	for i, record := range records {
+		sheet[i] = make([]any, len(record))
+		for j, cell := range record {
+			sheet[i][j] = cell
+		}
+	}
+	return sheet, nil
+}
+
+func readCSV(text string, delimiter rune, limit int) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	var records [][]string
+	for limit < 0 || len(records) < limit {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if limit >= 0 {
+				break
+			}
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// guessDelimiter picks the delimiter that splits the first few rows
+// into the most fields with the least variation, same as papaparse.
+func guessDelimiter(text string) rune {
+	var best rune
+	bestDelta, maxFieldCount := -1, 0.0
+	for _, delimiter := range delimitersToGuess {
+		records, _ := readCSV(text, delimiter, 10)
+		delta, total := 0, 0
+		previous := -1
+		for _, record := range records {
+			total += len(record)
+			if previous < 0 {
+				previous = len(record)
+				continue
+			}
+			if len(record) > 0 {
+				delta += abs(len(record) - previous)
+				previous = len(record)
+			}
+		}
+
+		if len(records) == 0 {
+			continue
+		}
+		average := float64(total) / float64(len(records))
+		if (bestDelta < 0 || delta <= bestDelta) && (best == 0 || average > maxFieldCount) && average > 1.99 {
+			best = delimiter
+			bestDelta = delta
+			maxFieldCount = average
+		}
+	}
+
+	if best == 0 {
+		return ','
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Rows converts the sheet to the template rows, where the cells are
+// keyed by the column letter along with the row index.
+func (s Sheet) Rows() []any {
+	rows := make([]any, len(s))
+	for i, cells := range s {
+		row := make(map[string]any, len(cells)+1)
+		for j, cell := range cells {
+			row[columnName(j)] = cell
+		}
+		row["index"] = float64(i)
+		rows[i] = row
+	}
+	return rows
+}
+
+// columnName follows the import page, which names the columns by char
+// code, so the column after Z is [.
+func columnName(i int) string {
+	return string(rune('A' + i))
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ParseXLSX reads the first sheet of the workbook. The cells are
+// formatted with the number format of the cell, like the formatted
+// text shown by the spreadsheet application.
+func ParseXLSX(data []byte) (Sheet, error) {
+	if bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}) {
+		return nil, fmt.Errorf("Unable to parse password protected or legacy XLS file")
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid XLSX file: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("Invalid XLSX file: no sheets found")
+	}
+
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid XLSX file: %w", err)
+	}
+
+	// the columns start from the first column of the sheet dimension,
+	// same as the import page
+	firstColumn := -1
+	if dimension, err := f.GetSheetDimension(sheets[0]); err == nil {
+		start, _, _ := strings.Cut(dimension, ":")
+		if column, _, err := excelize.CellNameToCoordinates(start); err == nil {
+			firstColumn = column - 1
+		}
+	}
+	for _, row := range rows {
+		for column, cell := range row {
+			if cell != "" && (firstColumn < 0 || column < firstColumn) {
+				firstColumn = column
+			}
+		}
+	}
+
+	var sheet Sheet
+	for _, row := range rows {
+		var cells []any
+		for column := max(firstColumn, 0); column < len(row); column++ {
+			var cell any
+			if row[column] != "" {
+				cell = row[column]
+			}
+			cells = append(cells, cell)
+		}
+		for len(cells) > 0 && cells[len(cells)-1] == nil {
+			cells = cells[:len(cells)-1]
+		}
+		if len(cells) > 0 {
+			sheet = append(sheet, cells)
+		}
+	}
+	return sheet, nil
+}
@@ -0,0 +1,59 @@
+package ledger
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var includeRegex = regexp.MustCompile(`^!?include\s+(.+?)\s*$`)
+
+// Files returns the journal file and every file it includes,
+// recursively. It understands the include directive of ledger, hledger
+// and beancount.
+func Files(journalPath string) []string {
+	visited := make(map[string]bool)
+	var files []string
+
+	var walk func(path string)
+	walk = func(path string) {
+		path, err := filepath.Abs(path)
+		if err != nil || visited[path] {
+			return
+		}
+		visited[path] = true
+		files = append(files, path)
+
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			match := includeRegex.FindStringSubmatch(scanner.Text())
+			if len(match) == 0 {
+				continue
+			}
+
+			pattern := utils.UnQuote(strings.TrimSpace(stripComment(match[1])))
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+
+			matches, _ := doublestar.FilepathGlob(pattern)
+			for _, match := range matches {
+				walk(match)
+			}
+		}
+	}
+
+	walk(journalPath)
+	return files
+}
@@ -52,6 +52,10 @@ func Cli() Ledger {
 		return Beancount{}
 	}
 
+	if config.GetConfig().LedgerCli == "native" {
+		return Native{}
+	}
+
 	return LedgerCLI{}
 }
 
@@ -0,0 +1,536 @@
+package ledger
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/gofrs/uuid"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+// Native parses the journal in process without shelling out to an
+// external binary. It understands the ledger-cli flavour of the
+// journal format.
+type Native struct{}
+
+func (Native) ValidateFile(journalPath string) ([]LedgerFileError, string, error) {
+	errors := []LedgerFileError{}
+	journal := loadNativeJournal(journalPath)
+	if len(journal.Errors) == 0 {
+		return errors, journal.balanceReport(), nil
+	}
+
+	for _, e := range journal.Errors {
+		errors = append(errors, LedgerFileError{LineFrom: e.LineFrom, LineTo: e.LineTo, Message: e.String()})
+	}
+	return errors, "", fmt.Errorf("Found %d error(s) in journal", len(journal.Errors))
+}
+
+func (Native) Parse(journalPath string, prices []price.Price) ([]*posting.Posting, error) {
+	journal := loadNativeJournal(journalPath)
+	if len(journal.Errors) > 0 {
+		return nil, fmt.Errorf("%s", journal.Errors[0].String())
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(journalPath))
+	if err != nil {
+		return nil, err
+	}
+
+	index := buildNativePriceIndex(journal.Prices, prices)
+	namespace := uuid.Must(uuid.FromString("45964a1b-b24c-4a73-835a-9335a7aa7de5"))
+
+	var postings []*posting.Posting
+	for _, t := range journal.Transactions {
+		fileName, err := filepath.Rel(dir, t.FileName)
+		if err != nil {
+			return nil, err
+		}
+
+		transactionID := uuid.NewV5(namespace, fileName+":"+fmt.Sprint(t.Sequence)).String()
+		postings = append(postings, buildNativePostings(t, t.Date, transactionID, fileName, false, index)...)
+	}
+
+	from := utils.Now()
+	if len(journal.Transactions) > 0 {
+		from = lo.MinBy(journal.Transactions, func(a, b *nativeTransaction) bool { return a.Date.Before(b.Date) }).Date
+	}
+	until := time.Date(utils.Now().Year()+3, 1, 1, 0, 0, 0, 0, config.TimeZone())
+
+	for _, periodic := range journal.Periodics {
+		for _, date := range periodic.Period.Occurrences(from, until) {
+			transactionID := uuid.NewV5(namespace, date.Format("2006/01/02")+":"+periodic.Transaction.Payee).String()
+			postings = append(postings, buildNativePostings(periodic.Transaction, date, transactionID, "", true, index)...)
+		}
+	}
+
+	return postings, nil
+}
+
+func (Native) Prices(journalPath string) ([]price.Price, error) {
+	journal := loadNativeJournal(journalPath)
+	if len(journal.Errors) > 0 {
+		return nil, fmt.Errorf("%s", journal.Errors[0].String())
+	}
+
+	defaultCurrency := config.DefaultCurrency()
+	type key struct {
+		commodity string
+		date      time.Time
+	}
+
+	var prices []price.Price
+	seen := make(map[key]int)
+	for _, p := range journal.Prices {
+		commodity := p.Commodity
+		value := p.Target.Quantity
+		if p.Target.Commodity != defaultCurrency {
+			if commodity != defaultCurrency || value.IsZero() {
+				continue
+			}
+			commodity = p.Target.Commodity
+			value = decimal.NewFromInt(1).Div(value)
+		}
+
+		pc := price.Price{Date: p.Date, CommodityName: commodity, CommodityID: commodity, CommodityType: config.Unknown, Value: value}
+		if i, ok := seen[key{commodity, p.Date}]; ok {
+			prices[i] = pc
+			continue
+		}
+		seen[key{commodity, p.Date}] = len(prices)
+		prices = append(prices, pc)
+	}
+
+	return prices, nil
+}
+
+func (e nativeError) String() string {
+	return fmt.Sprintf("While parsing file \"%s\", line %d:\nError: %s", e.FileName, e.LineFrom, e.Message)
+}
+
+func loadNativeJournal(journalPath string) *nativeJournal {
+	journal := parseNativeJournal(journalPath)
+	journal.finalize(config.GetConfig().Strict == config.Yes)
+	return journal
+}
+
+func buildNativePostings(t *nativeTransaction, date time.Time, transactionID string, fileName string, forecast bool, index nativePriceIndex) []*posting.Posting {
+	defaultCurrency := config.DefaultCurrency()
+	var postings []*posting.Posting
+	for _, p := range t.Postings {
+		var tagRecurring, tagPeriod string
+		if value, ok := nativeTag(t, p, "Recurring"); ok {
+			tagRecurring = value
+		}
+		if value, ok := nativeTag(t, p, "Period"); ok {
+			tagPeriod = value
+		}
+
+		postings = append(postings, &posting.Posting{
+			Date:                 date,
+			Payee:                t.Payee,
+			Account:              p.Account,
+			Commodity:            p.Amount.Commodity,
+			Quantity:             p.Amount.Quantity,
+			Amount:               index.amount(p, date, defaultCurrency),
+			TransactionID:        transactionID,
+			Status:               p.Status,
+			TagRecurring:         tagRecurring,
+			TagPeriod:            tagPeriod,
+			TransactionBeginLine: t.BeginLine,
+			TransactionEndLine:   t.EndLine,
+			Forecast:             forecast,
+			FileName:             fileName,
+			Note:                 p.Note,
+			TransactionNote:      t.Note})
+	}
+	return postings
+}
+
+func (j *nativeJournal) finalize(strict bool) {
+	balances := make(map[string]map[string]decimal.Decimal)
+	for _, t := range j.Transactions {
+		j.assignBalances(t, balances)
+		if !j.balanceTransaction(t, true) {
+			continue
+		}
+		j.applyAutomated(t)
+		if !j.checkBalanced(t) {
+			continue
+		}
+		j.recordImpliedPrices(t)
+		j.checkAssertions(t, balances)
+		if strict {
+			j.checkDeclared(t)
+		}
+	}
+
+	for _, periodic := range j.Periodics {
+		if j.balanceTransaction(periodic.Transaction, false) {
+			j.checkBalanced(periodic.Transaction)
+		}
+	}
+
+	j.balances = balances
+	sort.SliceStable(j.Prices, func(a, b int) bool {
+		if j.Prices[a].Date.Equal(j.Prices[b].Date) {
+			return j.Prices[a].order < j.Prices[b].order
+		}
+		return j.Prices[a].Date.Before(j.Prices[b].Date)
+	})
+}
+
+func (j *nativeJournal) assignBalances(t *nativeTransaction, balances map[string]map[string]decimal.Decimal) {
+	postings := t.Postings[:0]
+	for _, p := range t.Postings {
+		if p.Amount == nil && p.Assertion != nil {
+			current := balances[p.Account][p.Assertion.Commodity]
+			p.Amount = &nativeAmount{Commodity: p.Assertion.Commodity, Quantity: p.Assertion.Quantity.Sub(current)}
+			p.assigned = true
+			if p.Amount.Quantity.IsZero() {
+				continue
+			}
+		}
+		postings = append(postings, p)
+	}
+	t.Postings = postings
+}
+
+// balanceTransaction fills in the elided amount of a transaction, one
+// posting per commodity, and infers the conversion rate when exactly
+// two commodities are exchanged without an explicit cost.
+func (j *nativeJournal) balanceTransaction(t *nativeTransaction, useBucket bool) bool {
+	for _, p := range t.Postings {
+		if p.Virtual && p.Amount == nil {
+			j.errorf(t, p.Line, p.Line, "Virtual posting has no amount")
+			return false
+		}
+	}
+
+	for _, balanced := range []bool{false, true} {
+		group := lo.Filter(t.Postings, func(p *nativePosting, _ int) bool { return !p.Virtual && p.Balanced == balanced })
+		elided := lo.Filter(group, func(p *nativePosting, _ int) bool { return p.Amount == nil })
+		if len(elided) > 1 {
+			j.errorf(t, t.BeginLine, t.EndLine, "Only one posting with null amount allowed per transaction")
+			return false
+		}
+
+		commodities, residual := nativeResidual(group)
+		if len(elided) == 1 {
+			inferred := []*nativePosting{}
+			for _, commodity := range commodities {
+				if residual[commodity].IsZero() {
+					continue
+				}
+				p := *elided[0]
+				p.Amount = &nativeAmount{Commodity: commodity, Quantity: residual[commodity].Neg()}
+				inferred = append(inferred, &p)
+			}
+			t.Postings = lo.FlatMap(t.Postings, func(p *nativePosting, _ int) []*nativePosting {
+				if p == elided[0] {
+					return inferred
+				}
+				return []*nativePosting{p}
+			})
+			continue
+		}
+
+		unbalanced := lo.Filter(commodities, func(c string, _ int) bool { return !j.withinTolerance(c, residual[c]) })
+		if !balanced && useBucket && t.bucket != "" && len(unbalanced) > 0 {
+			for _, commodity := range unbalanced {
+				t.Postings = append(t.Postings, &nativePosting{Account: t.bucket, Status: t.Status, Line: t.EndLine, Tags: map[string]string{},
+					Amount: &nativeAmount{Commodity: commodity, Quantity: residual[commodity].Neg()}})
+			}
+			continue
+		}
+
+		if len(unbalanced) == 2 && lo.NoneBy(group, func(p *nativePosting) bool { return p.Cost != nil || p.Lot != nil }) {
+			from, to := unbalanced[0], unbalanced[1]
+			if from == config.DefaultCurrency() {
+				from, to = to, from
+			}
+			rate := residual[to].Div(residual[from]).Neg()
+			for _, p := range group {
+				if p.Amount.Commodity == from {
+					p.Cost = &nativeAmount{Commodity: to, Quantity: p.Amount.Quantity.Mul(rate)}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func nativeResidual(postings []*nativePosting) ([]string, map[string]decimal.Decimal) {
+	var commodities []string
+	residual := make(map[string]decimal.Decimal)
+	for _, p := range postings {
+		if p.Amount == nil {
+			continue
+		}
+		value := *p.Amount
+		if p.Lot != nil {
+			value = *p.Lot
+		} else if p.Cost != nil {
+			value = *p.Cost
+		}
+		if _, ok := residual[value.Commodity]; !ok {
+			commodities = append(commodities, value.Commodity)
+		}
+		residual[value.Commodity] = residual[value.Commodity].Add(value.Quantity)
+	}
+	return commodities, residual
+}
+
+func (j *nativeJournal) withinTolerance(commodity string, quantity decimal.Decimal) bool {
+	precision, ok := j.precision[commodity]
+	if !ok {
+		precision = 2
+	}
+	return quantity.Abs().LessThanOrEqual(decimal.New(5, -precision-1))
+}
+
+func (j *nativeJournal) applyAutomated(t *nativeTransaction) {
+	var generated []*nativePosting
+	original := t.Postings
+	for _, automated := range t.auto {
+		for _, matched := range original {
+			if !automated.Predicate(t, matched) {
+				continue
+			}
+
+			for k, v := range automated.Tags {
+				matched.Tags[k] = v
+			}
+
+			for _, ap := range automated.Postings {
+				if ap.Amount == nil {
+					j.fileErrorf(automated.FileName, ap.Line, ap.Line, "Automated transaction posting has no amount")
+					continue
+				}
+
+				amount := *ap.Amount
+				if amount.Commodity == "" {
+					amount = nativeAmount{Commodity: matched.Amount.Commodity, Quantity: matched.Amount.Quantity.Mul(ap.Amount.Quantity)}
+				}
+
+				account := ap.Account
+				if account == "$account" {
+					account = matched.Account
+				}
+
+				tags := make(map[string]string)
+				for k, v := range automated.Tags {
+					tags[k] = v
+				}
+				for k, v := range ap.Tags {
+					tags[k] = v
+				}
+
+				generated = append(generated, &nativePosting{
+					Account:  account,
+					Virtual:  ap.Virtual,
+					Balanced: ap.Balanced,
+					Status:   matched.Status,
+					Amount:   &amount,
+					Note:     ap.Note,
+					Tags:     tags,
+					Line:     matched.Line,
+				})
+			}
+		}
+	}
+	t.Postings = append(t.Postings, generated...)
+}
+
+func (j *nativeJournal) checkBalanced(t *nativeTransaction) bool {
+	for _, balanced := range []bool{false, true} {
+		group := lo.Filter(t.Postings, func(p *nativePosting, _ int) bool { return !p.Virtual && p.Balanced == balanced })
+		commodities, residual := nativeResidual(group)
+		unbalanced := lo.Filter(commodities, func(c string, _ int) bool { return !j.withinTolerance(c, residual[c]) })
+		if len(unbalanced) > 0 {
+			remainder := lo.Map(unbalanced, func(c string, _ int) string {
+				return formatNativeAmount(nativeAmount{Commodity: c, Quantity: residual[c]})
+			})
+			j.errorf(t, t.BeginLine, t.EndLine, "Transaction does not balance\nUnbalanced remainder is:\n%s", strings.Join(remainder, "\n"))
+			return false
+		}
+	}
+	return true
+}
+
+func (j *nativeJournal) recordImpliedPrices(t *nativeTransaction) {
+	for _, p := range t.Postings {
+		if p.Cost == nil || p.Amount.Quantity.IsZero() || p.Cost.Commodity == p.Amount.Commodity {
+			continue
+		}
+		j.Prices = append(j.Prices, nativePrice{
+			Date:      t.Date,
+			Commodity: p.Amount.Commodity,
+			Target:    nativeAmount{Commodity: p.Cost.Commodity, Quantity: p.Cost.Quantity.Div(p.Amount.Quantity).Abs()},
+			order:     t.order,
+		})
+	}
+}
+
+func (j *nativeJournal) checkAssertions(t *nativeTransaction, balances map[string]map[string]decimal.Decimal) {
+	for _, p := range t.Postings {
+		if balances[p.Account] == nil {
+			balances[p.Account] = make(map[string]decimal.Decimal)
+		}
+		balances[p.Account][p.Amount.Commodity] = balances[p.Account][p.Amount.Commodity].Add(p.Amount.Quantity)
+	}
+
+	for _, p := range t.Postings {
+		if p.Assertion == nil || p.assigned {
+			continue
+		}
+
+		total := make(map[string]decimal.Decimal)
+		for account, balance := range balances {
+			if account == p.Account || (p.AssertionInclusive && strings.HasPrefix(account, p.Account+":")) {
+				for commodity, quantity := range balance {
+					total[commodity] = total[commodity].Add(quantity)
+				}
+			}
+		}
+
+		if p.Assertion.Commodity == "" && p.Assertion.Quantity.IsZero() {
+			for commodity, quantity := range total {
+				if !quantity.IsZero() {
+					j.errorf(t, p.Line, p.Line, "Balance assertion off by %s (expected to see 0)", formatNativeAmount(nativeAmount{Commodity: commodity, Quantity: quantity}))
+				}
+			}
+			continue
+		}
+
+		actual := total[p.Assertion.Commodity]
+		if !actual.Equal(p.Assertion.Quantity) {
+			difference := nativeAmount{Commodity: p.Assertion.Commodity, Quantity: actual.Sub(p.Assertion.Quantity)}
+			j.errorf(t, p.Line, p.Line, "Balance assertion off by %s (expected to see %s)", formatNativeAmount(difference), formatNativeAmount(*p.Assertion))
+		}
+	}
+}
+
+func (j *nativeJournal) checkDeclared(t *nativeTransaction) {
+	for _, p := range t.Postings {
+		if !j.accounts[p.Account] {
+			j.errorf(t, p.Line, p.Line, "Unknown account '%s'", p.Account)
+		}
+		if p.Amount.Commodity != "" && !j.commodities[p.Amount.Commodity] {
+			j.errorf(t, p.Line, p.Line, "Unknown commodity '%s'", p.Amount.Commodity)
+		}
+	}
+}
+
+func (j *nativeJournal) errorf(t *nativeTransaction, lineFrom uint64, lineTo uint64, format string, args ...any) {
+	j.fileErrorf(t.FileName, lineFrom, lineTo, format, args...)
+}
+
+func (j *nativeJournal) fileErrorf(fileName string, lineFrom uint64, lineTo uint64, format string, args ...any) {
+	j.Errors = append(j.Errors, nativeError{FileName: fileName, LineFrom: lineFrom, LineTo: lineTo, Message: fmt.Sprintf(format, args...)})
+}
+
+func (j *nativeJournal) balanceReport() string {
+	var report strings.Builder
+	accounts := lo.Keys(j.balances)
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		commodities := lo.Keys(j.balances[account])
+		sort.Strings(commodities)
+		for _, commodity := range commodities {
+			quantity := j.balances[account][commodity]
+			if quantity.IsZero() {
+				continue
+			}
+			fmt.Fprintf(&report, "%20s  %s\n", formatNativeAmount(nativeAmount{Commodity: commodity, Quantity: quantity}), account)
+		}
+	}
+	return report.String()
+}
+
+func formatNativeAmount(amount nativeAmount) string {
+	if amount.Commodity == "" {
+		return amount.Quantity.String()
+	}
+	return amount.Quantity.String() + " " + amount.Commodity
+}
+
+type nativePriceIndex map[string][]nativePrice
+
+func buildNativePriceIndex(journalPrices []nativePrice, prices []price.Price) nativePriceIndex {
+	index := make(nativePriceIndex)
+	for _, p := range prices {
+		index[p.CommodityName] = append(index[p.CommodityName], nativePrice{
+			Date:      p.Date,
+			Commodity: p.CommodityName,
+			Target:    nativeAmount{Commodity: config.DefaultCurrency(), Quantity: p.Value},
+			order:     -1,
+		})
+	}
+
+	for _, p := range journalPrices {
+		index[p.Commodity] = append(index[p.Commodity], p)
+	}
+
+	for _, prices := range index {
+		sort.SliceStable(prices, func(a, b int) bool {
+			if prices[a].Date.Equal(prices[b].Date) {
+				return prices[a].order < prices[b].order
+			}
+			return prices[a].Date.Before(prices[b].Date)
+		})
+	}
+	return index
+}
+
+func (index nativePriceIndex) lookup(commodity string, date time.Time, target string) (nativePrice, bool) {
+	prices := index[commodity]
+	i := sort.Search(len(prices), func(i int) bool { return prices[i].Date.After(date) })
+	for i--; i >= 0; i-- {
+		if target == "" || prices[i].Target.Commodity == target {
+			return prices[i], true
+		}
+	}
+	return nativePrice{}, false
+}
+
+func (index nativePriceIndex) convert(amount nativeAmount, date time.Time, target string, depth int) (decimal.Decimal, bool) {
+	if amount.Commodity == target {
+		return amount.Quantity, true
+	}
+
+	if p, ok := index.lookup(amount.Commodity, date, target); ok {
+		return amount.Quantity.Mul(p.Target.Quantity), true
+	}
+
+	if p, ok := index.lookup(target, date, amount.Commodity); ok && !p.Target.Quantity.IsZero() {
+		return amount.Quantity.Div(p.Target.Quantity), true
+	}
+
+	if depth > 0 {
+		if p, ok := index.lookup(amount.Commodity, date, ""); ok {
+			return index.convert(nativeAmount{Commodity: p.Target.Commodity, Quantity: amount.Quantity.Mul(p.Target.Quantity)}, date, target, depth-1)
+		}
+	}
+
+	return decimal.Zero, false
+}
+
+func (index nativePriceIndex) amount(p *nativePosting, date time.Time, defaultCurrency string) decimal.Decimal {
+	if p.Lot != nil && p.Lot.Commodity == defaultCurrency {
+		return p.Lot.Quantity
+	}
+
+	if value, ok := index.convert(*p.Amount, date, defaultCurrency, 1); ok {
+		return value
+	}
+	return p.Amount.Quantity
+}
@@ -0,0 +1,837 @@
+package ledger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/shopspring/decimal"
+)
+
+type nativeAmount struct {
+	Commodity string
+	Quantity  decimal.Decimal
+}
+
+func (a nativeAmount) Neg() nativeAmount {
+	return nativeAmount{Commodity: a.Commodity, Quantity: a.Quantity.Neg()}
+}
+
+type nativePosting struct {
+	Account            string
+	Virtual            bool
+	Balanced           bool
+	Status             string
+	Amount             *nativeAmount
+	Cost               *nativeAmount
+	Lot                *nativeAmount
+	Assertion          *nativeAmount
+	AssertionInclusive bool
+	Note               string
+	Tags               map[string]string
+	Line               uint64
+	assigned           bool
+}
+
+type nativeTransaction struct {
+	Date      time.Time
+	Status    string
+	Payee     string
+	Note      string
+	Tags      map[string]string
+	Postings  []*nativePosting
+	FileName  string
+	BeginLine uint64
+	EndLine   uint64
+	Sequence  int
+
+	order  int
+	bucket string
+	auto   []*nativeAutomated
+}
+
+type nativeAutomated struct {
+	Predicate nativePredicate
+	Tags      map[string]string
+	Postings  []*nativePosting
+	FileName  string
+	Line      uint64
+}
+
+type nativePeriodic struct {
+	Period      nativePeriod
+	Transaction *nativeTransaction
+}
+
+type nativePrice struct {
+	Date      time.Time
+	Commodity string
+	Target    nativeAmount
+	order     int
+}
+
+type nativeError struct {
+	FileName string
+	LineFrom uint64
+	LineTo   uint64
+	Message  string
+}
+
+type nativeJournal struct {
+	Transactions []*nativeTransaction
+	Periodics    []*nativePeriodic
+	Prices       []nativePrice
+	Errors       []nativeError
+
+	accounts    map[string]bool
+	commodities map[string]bool
+	precision   map[string]int32
+	balances    map[string]map[string]decimal.Decimal
+}
+
+type nativeParser struct {
+	journal      *nativeJournal
+	decimalComma map[string]bool
+	aliases      map[string]string
+	applyAccount []string
+	bucket       string
+	year         int
+	order        int
+	automated    []*nativeAutomated
+	visited      map[string]bool
+	sequence     map[string]int
+}
+
+var (
+	nativeDateRegex        = regexp.MustCompile(`^(\d{4}[/.-])?(\d{1,2})[/.-](\d{1,2})(?:=\S+)?$`)
+	nativeSplitRegex       = regexp.MustCompile(`\s{2,}|\t`)
+	nativeTagRegex         = regexp.MustCompile(`^\s*([^\s:]+):(?::)?\s+(.*?)\s*$`)
+	nativeSimpleTagsRegex  = regexp.MustCompile(`:((?:[^\s:]+:)+)`)
+	nativeAmountRegex      = regexp.MustCompile(`^(-)?\s*("[^"]+"|[^\s\d.,;:?!\-+*/^&|=<>{}\[\]()@"]+)?\s*(-?(?:[0-9][0-9.,]*|[.,][0-9]+))\s*("[^"]+"|[^\s\d.,;:?!\-+*/^&|=<>{}\[\]()@"]+)?$`)
+	nativeCommodityRegex   = regexp.MustCompile(`^("[^"]+"|[^\s\d.,;:?!\-+*/^&|=<>{}\[\]()@"]+)$`)
+	nativeTopCommentPrefix = ";#%|*"
+)
+
+func parseNativeJournal(journalPath string) *nativeJournal {
+	p := &nativeParser{
+		journal: &nativeJournal{
+			accounts:    make(map[string]bool),
+			commodities: make(map[string]bool),
+			precision:   make(map[string]int32),
+		},
+		decimalComma: make(map[string]bool),
+		aliases:      make(map[string]string),
+		visited:      make(map[string]bool),
+		sequence:     make(map[string]int),
+		year:         utils.Now().Year(),
+	}
+
+	absolutePath, err := filepath.Abs(journalPath)
+	if err != nil {
+		absolutePath = journalPath
+	}
+
+	p.parseFile(absolutePath, "", 0)
+	return p.journal
+}
+
+func (p *nativeParser) errorf(fileName string, lineFrom uint64, lineTo uint64, format string, args ...any) {
+	p.journal.Errors = append(p.journal.Errors, nativeError{FileName: fileName, LineFrom: lineFrom, LineTo: lineTo, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *nativeParser) parseFile(path string, parentFile string, parentLine uint64) {
+	if p.visited[path] {
+		p.errorf(parentFile, parentLine, parentLine, "File %s is included more than once", path)
+		return
+	}
+	p.visited[path] = true
+	defer delete(p.visited, path)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		p.errorf(parentFile, parentLine, parentLine, "Failed to read file %s: %s", path, err.Error())
+		return
+	}
+
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	scanner := bufio.NewScanner(strings.NewReader(utils.Dos2Unix(string(content))))
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+
+	i := 0
+	for i < len(lines) {
+		line := lines[i]
+		lineNumber := uint64(i + 1)
+
+		if strings.TrimSpace(line) == "" {
+			i++
+			continue
+		}
+
+		first := line[0]
+		switch {
+		case strings.ContainsRune(nativeTopCommentPrefix, rune(first)):
+			i++
+		case first == ' ' || first == '\t':
+			trimmed := strings.TrimSpace(line)
+			if !strings.HasPrefix(trimmed, ";") && !strings.HasPrefix(trimmed, "#") {
+				p.errorf(path, lineNumber, lineNumber, "Unexpected whitespace at beginning of line")
+			}
+			i++
+		case first >= '0' && first <= '9':
+			end := blockEnd(lines, i)
+			p.parseTransaction(path, lines[i:end], lineNumber)
+			i = end
+		case first == '=':
+			end := blockEnd(lines, i)
+			p.parseAutomated(path, lines[i:end], lineNumber)
+			i = end
+		case first == '~':
+			end := blockEnd(lines, i)
+			p.parsePeriodic(path, lines[i:end], lineNumber)
+			i = end
+		case first == 'P':
+			p.parsePrice(path, line, lineNumber)
+			i++
+		default:
+			i = p.parseDirective(path, lines, i)
+		}
+	}
+}
+
+func blockEnd(lines []string, start int) int {
+	end := start + 1
+	for end < len(lines) && lines[end] != "" && (lines[end][0] == ' ' || lines[end][0] == '\t') {
+		end++
+	}
+	return end
+}
+
+func (p *nativeParser) parseDirective(path string, lines []string, i int) int {
+	line := lines[i]
+	lineNumber := uint64(i + 1)
+	fields := strings.Fields(line)
+	directive := strings.TrimPrefix(fields[0], "!")
+	argument := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "!"), directive))
+	end := blockEnd(lines, i)
+
+	switch directive {
+	case "include":
+		p.parseInclude(path, argument, lineNumber)
+	case "comment", "test":
+		end = i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "end "+directive) {
+			end++
+		}
+		return end + 1
+	case "account":
+		p.journal.accounts[p.accountName(stripComment(argument))] = true
+	case "commodity":
+		p.parseCommodityDirective(stripComment(argument), lines[i+1:end])
+	case "D":
+		p.parseCommodityDirective(stripComment(argument), nil)
+	case "decimal-mark":
+		if strings.TrimSpace(argument) == "," {
+			p.decimalComma[""] = true
+		}
+	case "alias":
+		parts := strings.SplitN(argument, "=", 2)
+		if len(parts) != 2 {
+			p.errorf(path, lineNumber, lineNumber, "Invalid alias directive")
+		} else {
+			p.aliases[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	case "year", "Y":
+		p.parseYear(path, argument, lineNumber)
+	case "apply":
+		args := strings.Fields(argument)
+		if len(args) >= 2 && args[0] == "account" {
+			p.applyAccount = append(p.applyAccount, strings.TrimSpace(strings.TrimPrefix(argument, "account")))
+		} else if len(args) >= 2 && args[0] == "year" {
+			p.parseYear(path, strings.TrimSpace(strings.TrimPrefix(argument, "year")), lineNumber)
+		}
+	case "end":
+		args := strings.Fields(argument)
+		if len(args) == 0 || (args[0] == "apply" && (len(args) == 1 || args[1] == "account")) {
+			if len(p.applyAccount) > 0 {
+				p.applyAccount = p.applyAccount[:len(p.applyAccount)-1]
+			}
+		}
+	case "bucket", "A":
+		p.bucket = p.accountName(stripComment(argument))
+	case "payee", "tag", "define", "N", "C", "assert", "check", "expr", "value", "python", "eval", "import", "option":
+	default:
+		if !strings.HasPrefix(directive, "--") {
+			p.errorf(path, lineNumber, lineNumber, "Unknown directive: %s", directive)
+		}
+	}
+
+	return end
+}
+
+func (p *nativeParser) parseYear(path string, argument string, lineNumber uint64) {
+	var year int
+	_, err := fmt.Sscanf(strings.TrimSpace(argument), "%d", &year)
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber, "Invalid year: %s", argument)
+		return
+	}
+	p.year = year
+}
+
+func (p *nativeParser) parseInclude(path string, argument string, lineNumber uint64) {
+	pattern := utils.UnQuote(stripComment(argument))
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(path), pattern)
+	}
+
+	matches, err := doublestar.FilepathGlob(pattern)
+	if err != nil || len(matches) == 0 {
+		p.errorf(path, lineNumber, lineNumber, "File to include was not found: %s", argument)
+		return
+	}
+
+	for _, match := range matches {
+		p.parseFile(match, path, lineNumber)
+	}
+}
+
+func (p *nativeParser) parseCommodityDirective(argument string, subDirectives []string) {
+	formats := []string{argument}
+	for _, sub := range subDirectives {
+		fields := strings.Fields(sub)
+		if len(fields) > 1 && fields[0] == "format" {
+			formats = append(formats, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(sub), "format")))
+		}
+	}
+
+	for _, format := range formats {
+		match := nativeAmountRegex.FindStringSubmatch(format)
+		if len(match) == 0 {
+			commodity := utils.UnQuote(strings.TrimSpace(format))
+			if commodity != "" {
+				p.journal.commodities[commodity] = true
+			}
+			continue
+		}
+
+		commodity := utils.UnQuote(match[2] + match[4])
+		p.journal.commodities[commodity] = true
+		number := match[3]
+		lastComma := strings.LastIndex(number, ",")
+		lastDot := strings.LastIndex(number, ".")
+		if lastComma > lastDot && (lastDot != -1 || len(number)-lastComma-1 != 3) {
+			p.decimalComma[commodity] = true
+		}
+	}
+}
+
+func (p *nativeParser) parsePrice(path string, line string, lineNumber uint64) {
+	fields := strings.Fields(stripComment(line))
+	if len(fields) < 4 {
+		p.errorf(path, lineNumber, lineNumber, "Invalid price directive")
+		return
+	}
+
+	date, err := p.parseDate(fields[1])
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber, "%s", err.Error())
+		return
+	}
+
+	rest := fields[2:]
+	if regexp.MustCompile(`^\d{1,2}:\d{2}(:\d{2})?$`).MatchString(rest[0]) {
+		rest = rest[1:]
+	}
+
+	if len(rest) < 2 {
+		p.errorf(path, lineNumber, lineNumber, "Invalid price directive")
+		return
+	}
+
+	commodity := rest[0]
+	remaining := strings.Join(rest[1:], " ")
+	if strings.HasPrefix(commodity, "\"") && !strings.HasSuffix(commodity, "\"") {
+		index := strings.Index(remaining, "\"")
+		if index == -1 {
+			p.errorf(path, lineNumber, lineNumber, "Invalid price directive")
+			return
+		}
+		commodity = commodity + " " + remaining[:index+1]
+		remaining = strings.TrimSpace(remaining[index+1:])
+	}
+
+	target, err := p.parseAmount(remaining)
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber, "%s", err.Error())
+		return
+	}
+
+	p.order++
+	p.journal.Prices = append(p.journal.Prices, nativePrice{Date: date, Commodity: utils.UnQuote(commodity), Target: target, order: p.order})
+}
+
+func (p *nativeParser) parseDate(str string) (time.Time, error) {
+	match := nativeDateRegex.FindStringSubmatch(str)
+	if len(match) == 0 {
+		return time.Time{}, fmt.Errorf("Invalid date: %s", str)
+	}
+
+	year := p.year
+	if match[1] != "" {
+		fmt.Sscanf(match[1][:4], "%d", &year)
+	}
+
+	var month, day int
+	fmt.Sscanf(match[2], "%d", &month)
+	fmt.Sscanf(match[3], "%d", &day)
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, config.TimeZone())
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("Invalid date: %s", str)
+	}
+	return date, nil
+}
+
+func (p *nativeParser) parseTransaction(path string, lines []string, lineNumber uint64) {
+	header, note := splitComment(lines[0])
+	dateString, rest := header, ""
+	if index := strings.IndexAny(header, " \t"); index != -1 {
+		dateString, rest = header[:index], strings.TrimSpace(header[index:])
+	}
+
+	date, err := p.parseDate(dateString)
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber+uint64(len(lines)-1), "%s", err.Error())
+		return
+	}
+
+	status := "unmarked"
+	if strings.HasPrefix(rest, "*") {
+		status = "cleared"
+		rest = strings.TrimSpace(rest[1:])
+	} else if strings.HasPrefix(rest, "!") {
+		status = "pending"
+		rest = strings.TrimSpace(rest[1:])
+	}
+
+	if strings.HasPrefix(rest, "(") {
+		if index := strings.Index(rest, ")"); index != -1 {
+			rest = strings.TrimSpace(rest[index+1:])
+		}
+	}
+
+	// ledger numbers every transaction and posting in a file from a
+	// single counter, the transaction id is derived from it.
+	p.sequence[path]++
+	p.order++
+	t := &nativeTransaction{
+		Date:      date,
+		Status:    status,
+		Payee:     rest,
+		FileName:  path,
+		BeginLine: lineNumber,
+		EndLine:   lineNumber + uint64(len(lines)-1),
+		Sequence:  p.sequence[path],
+		Tags:      make(map[string]string),
+		order:     p.order,
+		bucket:    p.bucket,
+		auto:      p.automated,
+	}
+
+	var notes []string
+	if note != nil {
+		notes = append(notes, *note)
+	}
+
+	ok := p.parsePostings(path, lines[1:], lineNumber, &notes, &t.Postings, status)
+	p.sequence[path] += len(t.Postings)
+	t.Note = strings.Join(notes, "\n")
+	parseTags(t.Note, t.Tags)
+	if !ok {
+		return
+	}
+
+	if len(t.Postings) == 0 {
+		p.errorf(path, t.BeginLine, t.EndLine, "Transaction has no postings")
+		return
+	}
+
+	p.journal.Transactions = append(p.journal.Transactions, t)
+}
+
+func (p *nativeParser) parsePostings(path string, lines []string, lineNumber uint64, notes *[]string, postings *[]*nativePosting, defaultStatus string) bool {
+	var current *nativePosting
+	ok := true
+
+	for i, line := range lines {
+		number := lineNumber + uint64(i) + 1
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
+			comment := strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(trimmed, ";"), "#"), " \t")
+			if current == nil {
+				*notes = append(*notes, comment)
+			} else {
+				if current.Note != "" {
+					current.Note += "\n"
+				}
+				current.Note += comment
+				parseTags(comment, current.Tags)
+			}
+			continue
+		}
+
+		posting, err := p.parsePosting(trimmed, number)
+		if err != nil {
+			p.errorf(path, number, number, "%s", err.Error())
+			ok = false
+			continue
+		}
+
+		if posting.Status == "" {
+			posting.Status = defaultStatus
+		}
+		current = posting
+		*postings = append(*postings, posting)
+	}
+
+	return ok
+}
+
+func (p *nativeParser) parsePosting(line string, lineNumber uint64) (*nativePosting, error) {
+	body, note := splitComment(line)
+	posting := &nativePosting{Line: lineNumber, Tags: make(map[string]string)}
+	if note != nil {
+		posting.Note = *note
+		parseTags(posting.Note, posting.Tags)
+	}
+
+	if strings.HasPrefix(body, "*") {
+		posting.Status = "cleared"
+		body = strings.TrimSpace(body[1:])
+	} else if strings.HasPrefix(body, "!") {
+		posting.Status = "pending"
+		body = strings.TrimSpace(body[1:])
+	}
+
+	parts := nativeSplitRegex.Split(body, 2)
+	account := strings.TrimSpace(parts[0])
+	rest := ""
+	if len(parts) > 1 {
+		rest = strings.TrimSpace(parts[1])
+	}
+
+	if strings.HasPrefix(account, "(") && strings.HasSuffix(account, ")") {
+		posting.Virtual = true
+		account = account[1 : len(account)-1]
+	} else if strings.HasPrefix(account, "[") && strings.HasSuffix(account, "]") {
+		posting.Balanced = true
+		account = account[1 : len(account)-1]
+	}
+
+	if account == "" {
+		return nil, fmt.Errorf("Posting has no account")
+	}
+	posting.Account = p.accountName(account)
+
+	if rest == "" {
+		return posting, nil
+	}
+
+	amountPart, assertionPart, inclusive := splitAssertion(rest)
+	if assertionPart != "" {
+		assertion, err := p.parseAmount(assertionPart)
+		if err != nil {
+			return nil, err
+		}
+		posting.Assertion = &assertion
+		posting.AssertionInclusive = inclusive
+	}
+
+	amountPart, costPart, totalCost := splitCost(amountPart)
+	amountPart, lotPart, totalLot := splitLot(amountPart)
+
+	if amountPart == "" {
+		if costPart != "" || lotPart != "" {
+			return nil, fmt.Errorf("Cost specified without an amount")
+		}
+		return posting, nil
+	}
+
+	amount, err := p.parseAmount(amountPart)
+	if err != nil {
+		return nil, err
+	}
+	posting.Amount = &amount
+
+	if lotPart != "" {
+		lot, err := p.parseAmount(lotPart)
+		if err != nil {
+			return nil, err
+		}
+		if !totalLot {
+			lot.Quantity = lot.Quantity.Mul(amount.Quantity)
+		} else if amount.Quantity.IsNegative() {
+			lot.Quantity = lot.Quantity.Abs().Neg()
+		}
+		posting.Lot = &lot
+	}
+
+	if costPart != "" {
+		cost, err := p.parseAmount(costPart)
+		if err != nil {
+			return nil, err
+		}
+		if !totalCost {
+			cost.Quantity = cost.Quantity.Mul(amount.Quantity)
+		} else if amount.Quantity.IsNegative() {
+			cost.Quantity = cost.Quantity.Abs().Neg()
+		}
+		posting.Cost = &cost
+	}
+
+	return posting, nil
+}
+
+func (p *nativeParser) parseAutomated(path string, lines []string, lineNumber uint64) {
+	header, _ := splitComment(lines[0])
+	predicate, err := parseNativePredicate(strings.TrimSpace(strings.TrimPrefix(header, "=")))
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber, "%s", err.Error())
+		return
+	}
+
+	automated := &nativeAutomated{Predicate: predicate, Tags: make(map[string]string), FileName: path, Line: lineNumber}
+	var notes []string
+	ok := p.parsePostings(path, lines[1:], lineNumber, &notes, &automated.Postings, "")
+	p.sequence[path] += len(automated.Postings)
+	if !ok {
+		return
+	}
+	for _, note := range notes {
+		parseTags(note, automated.Tags)
+	}
+
+	p.automated = append(append([]*nativeAutomated{}, p.automated...), automated)
+}
+
+func (p *nativeParser) parsePeriodic(path string, lines []string, lineNumber uint64) {
+	header, note := splitComment(lines[0])
+	period, err := parseNativePeriod(strings.TrimSpace(strings.TrimPrefix(header, "~")))
+	if err != nil {
+		p.errorf(path, lineNumber, lineNumber, "%s", err.Error())
+		return
+	}
+
+	p.order++
+	t := &nativeTransaction{
+		Status:    "unmarked",
+		Payee:     "Budget transaction",
+		FileName:  path,
+		BeginLine: lineNumber,
+		EndLine:   lineNumber + uint64(len(lines)-1),
+		Tags:      make(map[string]string),
+		order:     p.order,
+	}
+
+	var notes []string
+	if note != nil {
+		notes = append(notes, *note)
+	}
+
+	ok := p.parsePostings(path, lines[1:], lineNumber, &notes, &t.Postings, "unmarked")
+	p.sequence[path] += len(t.Postings)
+	if !ok {
+		return
+	}
+	t.Note = strings.Join(notes, "\n")
+	parseTags(t.Note, t.Tags)
+
+	p.journal.Periodics = append(p.journal.Periodics, &nativePeriodic{Period: period, Transaction: t})
+}
+
+func (p *nativeParser) accountName(account string) string {
+	account = strings.TrimSpace(account)
+	for alias, target := range p.aliases {
+		if account == alias {
+			account = target
+			break
+		}
+		if strings.HasPrefix(account, alias+":") {
+			account = target + account[len(alias):]
+			break
+		}
+	}
+
+	for i := len(p.applyAccount) - 1; i >= 0; i-- {
+		account = p.applyAccount[i] + ":" + account
+	}
+	return account
+}
+
+func (p *nativeParser) parseAmount(str string) (nativeAmount, error) {
+	str = strings.TrimSpace(str)
+	match := nativeAmountRegex.FindStringSubmatch(str)
+	if len(match) == 0 {
+		return nativeAmount{}, fmt.Errorf("Could not parse amount: <%s>", str)
+	}
+
+	if match[2] != "" && match[4] != "" {
+		return nativeAmount{}, fmt.Errorf("Could not parse amount: <%s>", str)
+	}
+
+	commodity := utils.UnQuote(match[2] + match[4])
+	number := match[3]
+	negative := match[1] == "-"
+	if strings.HasPrefix(number, "-") {
+		negative = !negative
+		number = number[1:]
+	}
+
+	number = p.normalizeNumber(commodity, number)
+	quantity, err := decimal.NewFromString(number)
+	if err != nil {
+		return nativeAmount{}, fmt.Errorf("Could not parse amount: <%s>", str)
+	}
+
+	if negative {
+		quantity = quantity.Neg()
+	}
+
+	if commodity != "" {
+		p.journal.precision[commodity] = max(p.journal.precision[commodity], -quantity.Exponent())
+	}
+
+	return nativeAmount{Commodity: commodity, Quantity: quantity}, nil
+}
+
+func (p *nativeParser) normalizeNumber(commodity string, number string) string {
+	comma, known := p.decimalComma[commodity]
+	if !known {
+		comma = p.decimalComma[""]
+		lastComma := strings.LastIndex(number, ",")
+		lastDot := strings.LastIndex(number, ".")
+		if lastComma != -1 && lastDot != -1 {
+			comma = lastComma > lastDot
+		}
+	}
+
+	if comma {
+		return strings.ReplaceAll(strings.ReplaceAll(number, ".", ""), ",", ".")
+	}
+	return strings.ReplaceAll(number, ",", "")
+}
+
+func splitComment(line string) (string, *string) {
+	inQuote := false
+	for i, c := range line {
+		if c == '"' {
+			inQuote = !inQuote
+		}
+		if c == ';' && !inQuote && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			note := strings.TrimRight(line[i+1:], " \t")
+			return strings.TrimSpace(line[:i]), &note
+		}
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func stripComment(line string) string {
+	body, _ := splitComment(line)
+	return body
+}
+
+func splitAssertion(rest string) (string, string, bool) {
+	inQuote := false
+	depth := 0
+	for i, c := range rest {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '{' && !inQuote:
+			depth++
+		case c == '}' && !inQuote:
+			depth--
+		case c == '=' && !inQuote && depth == 0:
+			assertion := strings.TrimLeft(rest[i:], "=")
+			inclusive := strings.HasPrefix(assertion, "*")
+			return strings.TrimSpace(rest[:i]), strings.TrimSpace(strings.TrimPrefix(assertion, "*")), inclusive
+		}
+	}
+	return rest, "", false
+}
+
+func splitCost(rest string) (string, string, bool) {
+	inQuote := false
+	depth := 0
+	for i, c := range rest {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '{' && !inQuote:
+			depth++
+		case c == '}' && !inQuote:
+			depth--
+		case c == '@' && !inQuote && depth == 0:
+			amount := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest[:i]), "("))
+			cost := rest[i+1:]
+			total := strings.HasPrefix(cost, "@")
+			cost = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(cost, "@"), ")"))
+			return amount, cost, total
+		}
+	}
+	return rest, "", false
+}
+
+func splitLot(rest string) (string, string, bool) {
+	lot := ""
+	total := false
+
+	if start := strings.Index(rest, "{"); start != -1 {
+		end := strings.LastIndex(rest, "}")
+		if end > start {
+			inner := rest[start+1 : end]
+			if strings.HasPrefix(inner, "{") && strings.HasSuffix(inner, "}") {
+				total = true
+				inner = inner[1 : len(inner)-1]
+			}
+			lot = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(inner), "="))
+			rest = rest[:start] + rest[end+1:]
+		}
+	}
+
+	annotations := regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+	if stripped := strings.TrimSpace(annotations.ReplaceAllString(rest, "")); stripped != "" {
+		rest = stripped
+	}
+	return strings.TrimSpace(rest), lot, total
+}
+
+func parseTags(note string, tags map[string]string) {
+	for _, line := range strings.Split(note, "\n") {
+		if match := nativeTagRegex.FindStringSubmatch(line); len(match) > 0 && !strings.HasPrefix(strings.TrimSpace(line), ":") {
+			tags[match[1]] = match[2]
+			continue
+		}
+
+		for _, match := range nativeSimpleTagsRegex.FindAllStringSubmatch(line, -1) {
+			for _, tag := range strings.Split(strings.Trim(match[1], ":"), ":") {
+				if _, ok := tags[tag]; !ok {
+					tags[tag] = ""
+				}
+			}
+		}
+	}
+}
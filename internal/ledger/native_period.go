@@ -0,0 +1,222 @@
+package ledger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+)
+
+type nativeInterval int
+
+const (
+	nativeDay nativeInterval = iota
+	nativeWeek
+	nativeMonth
+	nativeQuarter
+	nativeYear
+)
+
+type nativePeriod struct {
+	Interval nativeInterval
+	Count    int
+	Begin    *time.Time
+	End      *time.Time
+}
+
+var nativePeriodDateRegex = regexp.MustCompile(`^(\d{4})(?:[/.-](\d{1,2}))?(?:[/.-](\d{1,2}))?$`)
+
+var nativeIntervalNames = map[string]nativeInterval{
+	"day":      nativeDay,
+	"days":     nativeDay,
+	"week":     nativeWeek,
+	"weeks":    nativeWeek,
+	"month":    nativeMonth,
+	"months":   nativeMonth,
+	"quarter":  nativeQuarter,
+	"quarters": nativeQuarter,
+	"year":     nativeYear,
+	"years":    nativeYear,
+}
+
+var nativeIntervalAdjectives = map[string]nativePeriod{
+	"daily":       {Interval: nativeDay, Count: 1},
+	"weekly":      {Interval: nativeWeek, Count: 1},
+	"biweekly":    {Interval: nativeWeek, Count: 2},
+	"fortnightly": {Interval: nativeWeek, Count: 2},
+	"monthly":     {Interval: nativeMonth, Count: 1},
+	"bimonthly":   {Interval: nativeMonth, Count: 2},
+	"quarterly":   {Interval: nativeQuarter, Count: 1},
+	"yearly":      {Interval: nativeYear, Count: 1},
+	"annually":    {Interval: nativeYear, Count: 1},
+}
+
+func parseNativePeriod(str string) (nativePeriod, error) {
+	var period nativePeriod
+	tokens := strings.Fields(strings.ToLower(str))
+	if len(tokens) == 0 {
+		return period, fmt.Errorf("Invalid period expression: %s", str)
+	}
+
+	invalid := func() (nativePeriod, error) {
+		return nativePeriod{}, fmt.Errorf("Invalid period expression: %s", str)
+	}
+
+	var granularity *nativeInterval
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if adjective, ok := nativeIntervalAdjectives[token]; ok {
+			period.Interval = adjective.Interval
+			period.Count = adjective.Count
+			continue
+		}
+
+		switch token {
+		case "every":
+			if i+1 >= len(tokens) {
+				return invalid()
+			}
+			count := 1
+			if n, err := strconv.Atoi(tokens[i+1]); err == nil {
+				count = n
+				i++
+			}
+			if i+1 >= len(tokens) || count <= 0 {
+				return invalid()
+			}
+			interval, ok := nativeIntervalNames[tokens[i+1]]
+			if !ok {
+				return invalid()
+			}
+			period.Interval = interval
+			period.Count = count
+			i++
+		case "from", "since", "to", "until", "in":
+			if i+1 >= len(tokens) {
+				return invalid()
+			}
+			begin, end, interval, err := parseNativePeriodDate(tokens[i+1])
+			if err != nil {
+				return invalid()
+			}
+			switch token {
+			case "from", "since":
+				period.Begin = &begin
+			case "to", "until":
+				period.End = &begin
+			default:
+				period.Begin = &begin
+				period.End = &end
+				granularity = &interval
+			}
+			i++
+		default:
+			begin, end, interval, err := parseNativePeriodDate(token)
+			if err != nil {
+				return invalid()
+			}
+			period.Begin = &begin
+			period.End = &end
+			granularity = &interval
+		}
+	}
+
+	if period.Count == 0 {
+		if granularity == nil {
+			return invalid()
+		}
+		period.Interval = *granularity
+		period.Count = 1
+	}
+
+	return period, nil
+}
+
+func parseNativePeriodDate(str string) (time.Time, time.Time, nativeInterval, error) {
+	match := nativePeriodDateRegex.FindStringSubmatch(str)
+	if len(match) == 0 {
+		return time.Time{}, time.Time{}, nativeDay, fmt.Errorf("Invalid date: %s", str)
+	}
+
+	year, _ := strconv.Atoi(match[1])
+	if match[2] == "" {
+		begin := time.Date(year, 1, 1, 0, 0, 0, 0, config.TimeZone())
+		return begin, begin.AddDate(1, 0, 0), nativeYear, nil
+	}
+
+	month, _ := strconv.Atoi(match[2])
+	if month < 1 || month > 12 {
+		return time.Time{}, time.Time{}, nativeDay, fmt.Errorf("Invalid date: %s", str)
+	}
+	if match[3] == "" {
+		begin := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, config.TimeZone())
+		return begin, begin.AddDate(0, 1, 0), nativeMonth, nil
+	}
+
+	day, _ := strconv.Atoi(match[3])
+	begin := time.Date(year, time.Month(month), day, 0, 0, 0, 0, config.TimeZone())
+	if begin.Day() != day {
+		return time.Time{}, time.Time{}, nativeDay, fmt.Errorf("Invalid date: %s", str)
+	}
+	return begin, begin.AddDate(0, 0, 1), nativeDay, nil
+}
+
+func (period nativePeriod) align(date time.Time) time.Time {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, config.TimeZone())
+	switch period.Interval {
+	case nativeWeek:
+		return date.AddDate(0, 0, -int(date.Weekday()))
+	case nativeMonth:
+		return date.AddDate(0, 0, 1-date.Day())
+	case nativeQuarter:
+		return time.Date(date.Year(), date.Month()-(date.Month()-1)%3, 1, 0, 0, 0, 0, config.TimeZone())
+	case nativeYear:
+		return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, config.TimeZone())
+	default:
+		return date
+	}
+}
+
+func (period nativePeriod) step(start time.Time, n int) time.Time {
+	n = n * period.Count
+	switch period.Interval {
+	case nativeWeek:
+		return start.AddDate(0, 0, 7*n)
+	case nativeMonth:
+		return start.AddDate(0, n, 0)
+	case nativeQuarter:
+		return start.AddDate(0, 3*n, 0)
+	case nativeYear:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// Occurrences returns the dates on which the periodic transaction
+// fires, starting at the period begin (or the interval containing
+// from) and stopping before the period end or until.
+func (period nativePeriod) Occurrences(from time.Time, until time.Time) []time.Time {
+	start := period.align(from)
+	if period.Begin != nil {
+		start = *period.Begin
+	}
+
+	end := until
+	if period.End != nil && period.End.Before(end) {
+		end = *period.End
+	}
+
+	var dates []time.Time
+	for i := 0; ; i++ {
+		date := period.step(start, i)
+		if !date.Before(end) {
+			break
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
@@ -0,0 +1,573 @@
+package ledger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+type nativePredicate func(t *nativeTransaction, p *nativePosting) bool
+
+type nativeExpr func(t *nativeTransaction, p *nativePosting) any
+
+func parseNativePredicate(str string) (nativePredicate, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, fmt.Errorf("Invalid automated transaction predicate")
+	}
+
+	if rest, ok := strings.CutPrefix(str, "expr "); ok {
+		return parseNativeExprPredicate(unquoteNative(strings.TrimSpace(rest)))
+	}
+
+	if regexp.MustCompile(`=~|!~|==|!=|\b(account|payee|note|commodity|amount|quantity)\s*[<>]`).MatchString(str) {
+		return parseNativeExprPredicate(str)
+	}
+
+	return parseNativeQueryPredicate(str)
+}
+
+func parseNativeExprPredicate(str string) (nativePredicate, error) {
+	tokens, err := tokenizeNativeExpr(str)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &nativeExprParser{tokens: tokens}
+	expr, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("Unexpected token %s in predicate: %s", parser.tokens[parser.position], str)
+	}
+
+	return func(t *nativeTransaction, p *nativePosting) bool {
+		return nativeTruthy(expr(t, p), p)
+	}, nil
+}
+
+func unquoteNative(str string) string {
+	if len(str) >= 2 && (str[0] == '\'' || str[0] == '"') && str[len(str)-1] == str[0] {
+		return str[1 : len(str)-1]
+	}
+	return str
+}
+
+func tokenizeNativeExpr(str string) ([]string, error) {
+	var tokens []string
+	runes := []rune(str)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' || c == '\'' || c == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != c {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("Unterminated %c in predicate: %s", c, str)
+			}
+			tokens = append(tokens, string(runes[i:end+1]))
+			i = end + 1
+		case strings.ContainsRune("()!=<>&|,", c):
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				switch pair {
+				case "=~", "!~", "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, pair)
+					i += 2
+					continue
+				}
+			}
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()!=<>&|,'\"", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type nativeExprParser struct {
+	tokens   []string
+	position int
+}
+
+func (parser *nativeExprParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position]
+	}
+	return ""
+}
+
+func (parser *nativeExprParser) next() string {
+	token := parser.peek()
+	parser.position++
+	return token
+}
+
+func (parser *nativeExprParser) or() (nativeExpr, error) {
+	left, err := parser.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for token := parser.peek(); token == "or" || token == "|" || token == "||"; token = parser.peek() {
+		parser.next()
+		right, err := parser.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(t *nativeTransaction, p *nativePosting) any {
+			return nativeTruthy(l(t, p), p) || nativeTruthy(right(t, p), p)
+		}
+	}
+	return left, nil
+}
+
+func (parser *nativeExprParser) and() (nativeExpr, error) {
+	left, err := parser.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for token := parser.peek(); token == "and" || token == "&" || token == "&&"; token = parser.peek() {
+		parser.next()
+		right, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(t *nativeTransaction, p *nativePosting) any {
+			return nativeTruthy(l(t, p), p) && nativeTruthy(right(t, p), p)
+		}
+	}
+	return left, nil
+}
+
+func (parser *nativeExprParser) not() (nativeExpr, error) {
+	if token := parser.peek(); token == "not" || token == "!" {
+		parser.next()
+		expr, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *nativeTransaction, p *nativePosting) any {
+			return !nativeTruthy(expr(t, p), p)
+		}, nil
+	}
+	return parser.comparison()
+}
+
+func (parser *nativeExprParser) comparison() (nativeExpr, error) {
+	left, err := parser.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := parser.peek()
+	switch operator {
+	case "=~", "!~", "==", "!=", "<", "<=", ">", ">=":
+		parser.next()
+	default:
+		return left, nil
+	}
+
+	right, err := parser.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(t *nativeTransaction, p *nativePosting) any {
+		return nativeCompare(operator, left(t, p), right(t, p))
+	}, nil
+}
+
+func (parser *nativeExprParser) primary() (nativeExpr, error) {
+	token := parser.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("Unexpected end of predicate")
+	case token == "(":
+		expr, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+		if parser.next() != ")" {
+			return nil, fmt.Errorf("Missing ) in predicate")
+		}
+		return expr, nil
+	case strings.HasPrefix(token, "/"):
+		re, err := regexp.Compile("(?i)" + token[1:len(token)-1])
+		if err != nil {
+			return nil, err
+		}
+		return func(*nativeTransaction, *nativePosting) any { return re }, nil
+	case strings.HasPrefix(token, "'") || strings.HasPrefix(token, "\""):
+		value := token[1 : len(token)-1]
+		return func(*nativeTransaction, *nativePosting) any { return value }, nil
+	}
+
+	if number, err := decimal.NewFromString(token); err == nil {
+		return func(*nativeTransaction, *nativePosting) any { return number }, nil
+	}
+
+	switch token {
+	case "account":
+		return func(_ *nativeTransaction, p *nativePosting) any { return p.Account }, nil
+	case "payee":
+		return func(t *nativeTransaction, _ *nativePosting) any { return t.Payee }, nil
+	case "note":
+		return func(t *nativeTransaction, p *nativePosting) any { return strings.TrimSpace(t.Note + "\n" + p.Note) }, nil
+	case "commodity":
+		return func(_ *nativeTransaction, p *nativePosting) any {
+			if p.Amount == nil {
+				return ""
+			}
+			return p.Amount.Commodity
+		}, nil
+	case "amount", "quantity":
+		return func(_ *nativeTransaction, p *nativePosting) any {
+			if p.Amount == nil {
+				return decimal.Zero
+			}
+			return p.Amount.Quantity
+		}, nil
+	case "tag", "has_tag":
+		if parser.next() != "(" {
+			return nil, fmt.Errorf("Expected ( after %s", token)
+		}
+		name := unquoteNative(parser.next())
+		if parser.next() != ")" {
+			return nil, fmt.Errorf("Missing ) in predicate")
+		}
+		if token == "has_tag" {
+			return func(t *nativeTransaction, p *nativePosting) any {
+				_, ok := nativeTag(t, p, name)
+				return ok
+			}, nil
+		}
+		return func(t *nativeTransaction, p *nativePosting) any {
+			value, _ := nativeTag(t, p, name)
+			return value
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown identifier %s in predicate", token)
+}
+
+func nativeTag(t *nativeTransaction, p *nativePosting, name string) (string, bool) {
+	if value, ok := p.Tags[name]; ok {
+		return value, true
+	}
+	value, ok := t.Tags[name]
+	return value, ok
+}
+
+func nativeTruthy(value any, p *nativePosting) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case *regexp.Regexp:
+		return v.MatchString(p.Account)
+	case string:
+		return v != ""
+	case decimal.Decimal:
+		return !v.IsZero()
+	}
+	return false
+}
+
+func nativeCompare(operator string, left any, right any) bool {
+	switch operator {
+	case "=~", "!~":
+		var matched bool
+		if re, ok := right.(*regexp.Regexp); ok {
+			matched = re.MatchString(fmt.Sprint(left))
+		} else {
+			matched = fmt.Sprint(left) == fmt.Sprint(right)
+		}
+		return matched == (operator == "=~")
+	}
+
+	l, lok := left.(decimal.Decimal)
+	r, rok := right.(decimal.Decimal)
+	if lok && rok {
+		switch operator {
+		case "==":
+			return l.Equal(r)
+		case "!=":
+			return !l.Equal(r)
+		case "<":
+			return l.LessThan(r)
+		case "<=":
+			return l.LessThanOrEqual(r)
+		case ">":
+			return l.GreaterThan(r)
+		case ">=":
+			return l.GreaterThanOrEqual(r)
+		}
+	}
+
+	ls, rs := fmt.Sprint(left), fmt.Sprint(right)
+	switch operator {
+	case "==":
+		return ls == rs
+	case "!=":
+		return ls != rs
+	case "<":
+		return ls < rs
+	case "<=":
+		return ls <= rs
+	case ">":
+		return ls > rs
+	case ">=":
+		return ls >= rs
+	}
+	return false
+}
+
+func parseNativeQueryPredicate(str string) (nativePredicate, error) {
+	tokens, err := tokenizeNativeQuery(str)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &nativeQueryParser{tokens: tokens}
+	predicate, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("Unexpected token %s in predicate: %s", parser.tokens[parser.position], str)
+	}
+	return predicate, nil
+}
+
+func tokenizeNativeQuery(str string) ([]string, error) {
+	var tokens []string
+	runes := []rune(str)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == '&' || c == '|' || c == '!':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != c {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("Unterminated %c in predicate: %s", c, str)
+			}
+			tokens = append(tokens, string(runes[i+1:end]))
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()&|", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type nativeQueryParser struct {
+	tokens   []string
+	position int
+}
+
+func (parser *nativeQueryParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position]
+	}
+	return ""
+}
+
+func (parser *nativeQueryParser) or() (nativePredicate, error) {
+	left, err := parser.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := parser.peek()
+		if token == "" || token == ")" || token == "and" || token == "&" {
+			return left, nil
+		}
+		if token == "or" || token == "|" {
+			parser.position++
+		}
+		right, err := parser.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(t *nativeTransaction, p *nativePosting) bool { return l(t, p) || right(t, p) }
+	}
+}
+
+func (parser *nativeQueryParser) and() (nativePredicate, error) {
+	left, err := parser.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for token := parser.peek(); token == "and" || token == "&"; token = parser.peek() {
+		parser.position++
+		right, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(t *nativeTransaction, p *nativePosting) bool { return l(t, p) && right(t, p) }
+	}
+	return left, nil
+}
+
+func (parser *nativeQueryParser) not() (nativePredicate, error) {
+	if token := parser.peek(); token == "not" || token == "!" {
+		parser.position++
+		predicate, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *nativeTransaction, p *nativePosting) bool { return !predicate(t, p) }, nil
+	}
+	return parser.term()
+}
+
+func (parser *nativeQueryParser) term() (nativePredicate, error) {
+	token := parser.peek()
+	parser.position++
+
+	argument := func() (string, error) {
+		value := parser.peek()
+		if value == "" {
+			return "", fmt.Errorf("Missing argument for %s in predicate", token)
+		}
+		parser.position++
+		return value, nil
+	}
+
+	switch token {
+	case "":
+		return nil, fmt.Errorf("Unexpected end of predicate")
+	case "(":
+		predicate, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek() != ")" {
+			return nil, fmt.Errorf("Missing ) in predicate")
+		}
+		parser.position++
+		return predicate, nil
+	case "payee", "desc":
+		value, err := argument()
+		if err != nil {
+			return nil, err
+		}
+		return nativePayeePredicate(value)
+	case "tag", "meta":
+		value, err := argument()
+		if err != nil {
+			return nil, err
+		}
+		return nativeTagPredicate(value)
+	case "note":
+		value, err := argument()
+		if err != nil {
+			return nil, err
+		}
+		return nativeNotePredicate(value)
+	case "expr":
+		value, err := argument()
+		if err != nil {
+			return nil, err
+		}
+		return parseNativeExprPredicate(value)
+	}
+
+	switch {
+	case strings.HasPrefix(token, "@"):
+		return nativePayeePredicate(token[1:])
+	case strings.HasPrefix(token, "%"):
+		return nativeTagPredicate(token[1:])
+	case strings.HasPrefix(token, "="):
+		return nativeNotePredicate(token[1:])
+	}
+
+	re, err := compileNativeRegex(token)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ *nativeTransaction, p *nativePosting) bool { return re.MatchString(p.Account) }, nil
+}
+
+func compileNativeRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		pattern = pattern[1 : len(pattern)-1]
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func nativePayeePredicate(pattern string) (nativePredicate, error) {
+	re, err := compileNativeRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(t *nativeTransaction, _ *nativePosting) bool { return re.MatchString(t.Payee) }, nil
+}
+
+func nativeNotePredicate(pattern string) (nativePredicate, error) {
+	re, err := compileNativeRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(t *nativeTransaction, p *nativePosting) bool {
+		return re.MatchString(t.Note) || re.MatchString(p.Note)
+	}, nil
+}
+
+func nativeTagPredicate(pattern string) (nativePredicate, error) {
+	name, value, hasValue := strings.Cut(pattern, "=")
+	nameRegex, err := compileNativeRegex(name)
+	if err != nil {
+		return nil, err
+	}
+	valueRegex, err := compileNativeRegex(value)
+	if err != nil {
+		return nil, err
+	}
+
+	match := func(tags map[string]string) bool {
+		for k, v := range tags {
+			if nameRegex.MatchString(k) && (!hasValue || valueRegex.MatchString(v)) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(t *nativeTransaction, p *nativePosting) bool { return match(p.Tags) || match(t.Tags) }, nil
+}
@@ -0,0 +1,260 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadNativeFixture(t *testing.T, name string) string {
+	dir, err := filepath.Abs(filepath.Join("..", "..", "tests", "fixture", name))
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "paisa.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, config.LoadConfig(content, filepath.Join(dir, "paisa.yaml")))
+	return filepath.Join(dir, "main.ledger")
+}
+
+func writeNativeJournal(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	require.NoError(t, config.LoadConfig([]byte("journal_path: main.ledger\ndb_path: paisa.db\nledger_cli: native\n"), filepath.Join(dir, "paisa.yaml")))
+	return filepath.Join(dir, "main.ledger")
+}
+
+func findPosting(postings []*posting.Posting, payee string, account string) *posting.Posting {
+	p, _ := lo.Find(postings, func(p *posting.Posting) bool { return p.Payee == payee && p.Account == account })
+	return p
+}
+
+func TestNativeParseFixture(t *testing.T) {
+	journalPath := loadNativeFixture(t, "inr")
+
+	errors, _, err := Native{}.ValidateFile(journalPath)
+	assert.NoError(t, err)
+	assert.Empty(t, errors)
+
+	prices, err := Native{}.Prices(journalPath)
+	require.NoError(t, err)
+
+	postings, err := Native{}.Parse(journalPath, prices)
+	require.NoError(t, err)
+	assert.Len(t, postings, 21)
+
+	rent := findPosting(postings, "Rent", "Assets:Checking")
+	assert.Equal(t, "67f44c92-c138-550d-9ae2-84034aca39d3", rent.TransactionID)
+	assert.Equal(t, "main.ledger", rent.FileName)
+	assert.Equal(t, uint64(10), rent.TransactionBeginLine)
+	assert.Equal(t, uint64(12), rent.TransactionEndLine)
+	assert.Equal(t, 20000.0, findPosting(postings, "Rent", "Expenses:Rent").Amount.InexactFloat64())
+
+	abnb := findPosting(postings, "Buy ABNB", "Assets:Equity:ABNB")
+	assert.Equal(t, "8025.317", abnb.Amount.StringFixed(3))
+
+	sell := findPosting(postings, "Sell", "Assets:Equity:AAPL")
+	assert.Equal(t, -10000.0, sell.Amount.InexactFloat64())
+
+	multi := lo.Filter(postings, func(p *posting.Posting, _ int) bool {
+		return p.Payee == "Multi Currency Debit" && p.Account == "Assets:Checking"
+	})
+	assert.Equal(t, []string{"INR", "USD"}, lo.Map(multi, func(p *posting.Posting, _ int) string { return p.Commodity }))
+	assert.Equal(t, "-804.42048", multi[1].Amount.String())
+
+	interest := findPosting(postings, "Interest", "Income:Interest:Checking")
+	assert.Equal(t, " Posting note", interest.Note)
+	assert.Equal(t, " Global note\n :interest:", interest.TransactionNote)
+	assert.Equal(t, uint64(42), interest.TransactionBeginLine)
+	assert.Equal(t, uint64(46), interest.TransactionEndLine)
+
+	assert.Nil(t, findPosting(postings, "Asset balance", "Assets:Equity:NIFTY"))
+}
+
+func TestNativePrices(t *testing.T) {
+	journalPath := loadNativeFixture(t, "inr")
+	prices, err := Native{}.Prices(journalPath)
+	require.NoError(t, err)
+	assert.Len(t, prices, 5)
+	assertPriceEqual(t, prices[0], "2022/01/07", "NIFTY", 100)
+	assertPriceEqual(t, prices[1], "2022/01/08", "USD", 80.442048)
+	assertPriceEqual(t, prices[2], "2022/01/09", "AAPL", 100)
+	assertPriceEqual(t, prices[3], "2022/01/10", "AAPL", 100.273)
+	assertPriceEqual(t, prices[4], "2022/02/07", "NIFTY", 100.273)
+
+	journalPath = loadNativeFixture(t, "eur")
+	prices, err = Native{}.Prices(journalPath)
+	require.NoError(t, err)
+	assertPriceEqual(t, prices[0], "2022/01/05", "AAPL", 10005.05)
+	assertPriceEqual(t, prices[1], "2022/01/15", "AAPL", 10025.05)
+}
+
+func TestNativeAutomatedAndBudget(t *testing.T) {
+	utils.SetNow("2022-06-01")
+	journalPath := writeNativeJournal(t, map[string]string{
+		"main.ledger": `include accounts.ledger
+
+= /^Expenses:Food/
+    (Budget:Food)                      -1
+    ; :auto:
+
+~ Monthly from 2024/10/01
+    Expenses:Food                   5,000 INR
+    Assets:Checking
+
+2022/01/01 * Opening
+    Assets:Checking                 10000 INR
+    Equity:Opening
+
+2022/01/05 ! Groceries
+    ; Recurring: Groceries
+    Expenses:Food:Groceries          1200 INR ; Period: monthly
+    Assets:Checking                       = 8800 INR
+`,
+		"accounts.ledger": `2021/12/31 Included
+    Assets:Wallet                     500 INR
+    Equity:Opening
+`,
+	})
+
+	errors, _, err := Native{}.ValidateFile(journalPath)
+	require.NoError(t, err)
+	assert.Empty(t, errors)
+
+	postings, err := Native{}.Parse(journalPath, nil)
+	require.NoError(t, err)
+
+	wallet := findPosting(postings, "Included", "Assets:Wallet")
+	assert.Equal(t, "accounts.ledger", wallet.FileName)
+	assert.Equal(t, uint64(1), wallet.TransactionBeginLine)
+
+	groceries := findPosting(postings, "Groceries", "Expenses:Food:Groceries")
+	assert.Equal(t, "pending", groceries.Status)
+	assert.Equal(t, "Groceries", groceries.TagRecurring)
+	assert.Equal(t, "monthly", groceries.TagPeriod)
+
+	checking := findPosting(postings, "Groceries", "Assets:Checking")
+	assert.Equal(t, -1200.0, checking.Amount.InexactFloat64())
+
+	auto := findPosting(postings, "Groceries", "Budget:Food")
+	require.NotNil(t, auto)
+	assert.Equal(t, -1200.0, auto.Amount.InexactFloat64())
+
+	budget := lo.Filter(postings, func(p *posting.Posting, _ int) bool { return p.Forecast })
+	assert.Len(t, budget, 6)
+	assert.Equal(t, "Budget transaction", budget[0].Payee)
+	assert.Equal(t, "2024/10/01", budget[0].Date.Format("2006/01/02"))
+	assert.Equal(t, 5000.0, budget[0].Amount.InexactFloat64())
+	assert.Equal(t, -5000.0, budget[1].Amount.InexactFloat64())
+	assert.Equal(t, "2024/12/01", budget[5].Date.Format("2006/01/02"))
+	assert.Equal(t, "", budget[0].FileName)
+}
+
+func TestNativeErrors(t *testing.T) {
+	journalPath := writeNativeJournal(t, map[string]string{
+		"main.ledger": `2022/01/01 Salary
+    Income:Salary                   -1000 INR
+    Assets:Checking                   900 INR
+
+2022/01/02 Check
+    Assets:Checking                    10 INR = 50 INR
+    Income:Salary
+
+2022/13/01 Bad date
+    Assets:Checking                    10 INR
+    Income:Salary
+`,
+	})
+
+	errors, _, err := Native{}.ValidateFile(journalPath)
+	assert.Error(t, err)
+	require.Len(t, errors, 3)
+
+	assert.Equal(t, uint64(9), errors[0].LineFrom)
+	assert.Equal(t, uint64(11), errors[0].LineTo)
+	assert.Contains(t, errors[0].Message, "Invalid date")
+
+	assert.Equal(t, uint64(1), errors[1].LineFrom)
+	assert.Equal(t, uint64(3), errors[1].LineTo)
+	assert.Contains(t, errors[1].Message, "Transaction does not balance")
+
+	assert.Equal(t, uint64(6), errors[2].LineFrom)
+	assert.Contains(t, errors[2].Message, "Balance assertion off by -40 INR (expected to see 50 INR)")
+
+	_, err = Native{}.Parse(journalPath, nil)
+	assert.Error(t, err)
+}
+
+func TestNativeStrict(t *testing.T) {
+	journalPath := writeNativeJournal(t, map[string]string{
+		"main.ledger": `account Assets:Checking
+commodity INR
+
+2022/01/01 Salary
+    Income:Salary                   -1000 INR
+    Assets:Checking                  1000 INR
+`,
+	})
+	config.LoadConfig([]byte("journal_path: main.ledger\ndb_path: paisa.db\nstrict: \"yes\"\n"), journalPath)
+
+	errors, _, err := Native{}.ValidateFile(journalPath)
+	assert.Error(t, err)
+	require.Len(t, errors, 1)
+	assert.Equal(t, uint64(5), errors[0].LineFrom)
+	assert.Contains(t, errors[0].Message, "Unknown account 'Income:Salary'")
+}
+
+func TestParseNativePeriod(t *testing.T) {
+	period, err := parseNativePeriod("every 2 weeks from 2023/01/01 to 2023/02/01")
+	require.NoError(t, err)
+	assert.Equal(t, nativeWeek, period.Interval)
+	assert.Equal(t, 2, period.Count)
+	dates := period.Occurrences(period.Begin.AddDate(-1, 0, 0), period.Begin.AddDate(1, 0, 0))
+	assert.Equal(t, []string{"2023/01/01", "2023/01/15", "2023/01/29"}, lo.Map(dates, func(d time.Time, _ int) string { return d.Format("2006/01/02") }))
+
+	period, err = parseNativePeriod("Quarterly in 2023")
+	require.NoError(t, err)
+	assert.Len(t, period.Occurrences(period.Begin.AddDate(-5, 0, 0), period.Begin.AddDate(5, 0, 0)), 4)
+
+	_, err = parseNativePeriod("every fortnight")
+	assert.Error(t, err)
+}
+
+func TestParseNativePredicate(t *testing.T) {
+	tx := &nativeTransaction{Payee: "Swiggy", Tags: map[string]string{"trip": "goa"}}
+	food := &nativePosting{Account: "Expenses:Food", Amount: &nativeAmount{Commodity: "INR", Quantity: decimal.NewFromInt(600)}, Tags: map[string]string{}}
+	rent := &nativePosting{Account: "Expenses:Rent", Amount: &nativeAmount{Commodity: "INR", Quantity: decimal.NewFromInt(100)}, Tags: map[string]string{}}
+
+	cases := []struct {
+		predicate string
+		food      bool
+		rent      bool
+	}{
+		{"Expenses:Food", true, false},
+		{"food or rent", true, true},
+		{"expenses and not rent", true, false},
+		{"@swiggy and %trip=goa", true, true},
+		{"expr 'account =~ /Food/ && amount > 500'", true, false},
+		{"account =~ /^Expenses/ and commodity == 'INR' and amount <= 100", false, true},
+		{"tag('trip') == 'goa' and !(payee =~ /zomato/)", true, true},
+	}
+
+	for _, c := range cases {
+		predicate, err := parseNativePredicate(c.predicate)
+		require.NoError(t, err, c.predicate)
+		assert.Equal(t, c.food, predicate(tx, food), c.predicate)
+		assert.Equal(t, c.rent, predicate(tx, rent), c.predicate)
+	}
+
+	_, err := parseNativePredicate("account =~")
+	assert.Error(t, err)
+}
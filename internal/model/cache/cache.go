@@ -13,7 +13,7 @@ type Cache struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	ExpiresAt time.Time `json:"expires_at"`
 	HashKey   string    `json:"hash_key"`
-	Value     []byte    `gorm:"type:BLOB" json:"item"`
+	Value     []byte    `json:"item"`
 }
 
 func DeleteExpired(db *gorm.DB) error {
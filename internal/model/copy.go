@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ananthakumaran/paisa/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const copyBatchSize = 500
+
+// CopyDatabase replaces all the rows in the target database with the
+// rows of the source database. The ids are copied as is, so the id
+// sequences of the PostgreSQL tables are moved past the copied ids.
+func CopyDatabase(from *gorm.DB, to *gorm.DB) error {
+	err := to.AutoMigrate(Models...)
+	if err != nil {
+		return err
+	}
+
+	return to.Transaction(func(tx *gorm.DB) error {
+		for _, model := range Models {
+			err := copyTable(from, tx, model)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func copyTable(from *gorm.DB, to *gorm.DB, model any) error {
+	statement := &gorm.Statement{DB: to}
+	err := statement.Parse(model)
+	if err != nil {
+		return err
+	}
+	table := statement.Schema.Table
+
+	err = to.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error
+	if err != nil {
+		return err
+	}
+
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem())).Interface()
+	var count int64
+	err = from.FindInBatches(rows, copyBatchSize, func(batch *gorm.DB, _ int) error {
+		count += batch.RowsAffected
+		return to.Create(rows).Error
+	}).Error
+	if err != nil {
+		return fmt.Errorf("Failed to copy %s: %w", table, err)
+	}
+
+	if to.Dialector.Name() == utils.Postgres {
+		err = to.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)", statement.Quote(table)), table).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Copied %d rows of %s", count, table)
+	return nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/model/cache"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openCopyDB(t *testing.T, name string) *gorm.DB {
+	db, err := utils.OpenSQLite(filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	AutoMigrate(db)
+	return db
+}
+
+func TestCopyDatabase(t *testing.T) {
+	from := openCopyDB(t, "from.db")
+	to := openCopyDB(t, "to.db")
+
+	date := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < copyBatchSize+10; i++ {
+		amount := decimal.NewFromFloat(10.25).Mul(decimal.NewFromInt(int64(i)))
+		require.NoError(t, from.Create(&posting.Posting{TransactionID: "1", Date: date, Account: "Expenses:Food", Commodity: "INR", Quantity: amount, Amount: amount, Forecast: i%2 == 0}).Error)
+	}
+	require.NoError(t, from.Create(&price.Price{Date: date, CommodityName: "NIFTY", Value: decimal.RequireFromString("123.4567")}).Error)
+	require.NoError(t, from.Create(&cache.Cache{ExpiresAt: date, HashKey: "1", Value: []byte{0, 1, 2}}).Error)
+
+	require.NoError(t, to.Create(&posting.Posting{TransactionID: "stale", Date: date, Account: "Assets:Checking"}).Error)
+
+	require.NoError(t, CopyDatabase(from, to))
+
+	var expected, actual []posting.Posting
+	require.NoError(t, from.Order("id").Find(&expected).Error)
+	require.NoError(t, to.Order("id").Find(&actual).Error)
+	require.Len(t, actual, copyBatchSize+10)
+	for i := range expected {
+		assert.Equal(t, expected[i].ID, actual[i].ID)
+		assert.True(t, expected[i].Amount.Equal(actual[i].Amount))
+		assert.Equal(t, expected[i].Forecast, actual[i].Forecast)
+		assert.True(t, expected[i].Date.Equal(actual[i].Date))
+	}
+
+	var prices []price.Price
+	require.NoError(t, to.Find(&prices).Error)
+	require.Len(t, prices, 1)
+	assert.Equal(t, "123.4567", prices[0].Value.String())
+
+	var caches []cache.Cache
+	require.NoError(t, to.Find(&caches).Error)
+	require.Len(t, caches, 1)
+	assert.Equal(t, []byte{0, 1, 2}, caches[0].Value)
+
+	// copying again replaces the rows instead of appending
+	require.NoError(t, CopyDatabase(from, to))
+	var count int64
+	require.NoError(t, to.Model(&posting.Posting{}).Count(&count).Error)
+	assert.Equal(t, int64(copyBatchSize+10), count)
+}
@@ -22,18 +22,31 @@ import (
 	"gorm.io/gorm"
 )
 
+// Models are all the tables stored in the database
+var Models = []any{
+	&npsModel.Scheme{},
+	&mutualfundModel.Scheme{},
+	&posting.Posting{},
+	&price.Price{},
+	&portfolio.Portfolio{},
+	&cii.CII{},
+	&cache.Cache{},
+}
+
 func AutoMigrate(db *gorm.DB) {
-	db.AutoMigrate(&npsModel.Scheme{})
-	db.AutoMigrate(&mutualfundModel.Scheme{})
-	db.AutoMigrate(&posting.Posting{})
-	db.AutoMigrate(&price.Price{})
-	db.AutoMigrate(&portfolio.Portfolio{})
-	db.AutoMigrate(&price.Price{})
-	db.AutoMigrate(&cii.CII{})
-	db.AutoMigrate(&cache.Cache{})
+	err := db.AutoMigrate(Models...)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func SyncJournal(db *gorm.DB) (string, error) {
+type JournalSyncResult struct {
+	Postings      posting.SyncResult `json:"postings"`
+	PricesChanged bool               `json:"prices_changed"`
+}
+
+func SyncJournal(db *gorm.DB) (JournalSyncResult, string, error) {
+	var result JournalSyncResult
 	AutoMigrate(db)
 	log.Info("Syncing transactions from journal")
 
@@ -41,30 +54,34 @@ func SyncJournal(db *gorm.DB) (string, error) {
 	if err != nil {
 
 		if len(errors) == 0 {
-			return err.Error(), err
+			return result, err.Error(), err
 		}
 
 		var message string
 		for _, error := range errors {
 			message += error.Message + "\n\n"
 		}
-		return strings.TrimRight(message, "\n"), err
+		return result, strings.TrimRight(message, "\n"), err
 	}
 
 	prices, err := ledger.Cli().Prices(config.GetJournalPath())
 	if err != nil {
-		return err.Error(), err
+		return result, err.Error(), err
 	}
 
-	price.UpsertAllByType(db, config.Unknown, prices)
+	if !price.IsUpToDate(db, config.Unknown, prices) {
+		price.UpsertAllByType(db, config.Unknown, prices)
+		result.PricesChanged = true
+	}
 
 	postings, err := ledger.Cli().Parse(config.GetJournalPath(), prices)
 	if err != nil {
-		return err.Error(), err
+		return result, err.Error(), err
 	}
-	posting.UpsertAll(db, postings)
+	result.Postings = posting.SyncAll(db, postings)
+	log.Infof("Synced postings: %d inserted, %d updated, %d deleted", result.Postings.Inserted, result.Postings.Updated, result.Postings.Deleted)
 
-	return "", nil
+	return result, "", nil
 }
 
 func SyncCommodities(db *gorm.DB) error {
@@ -78,10 +95,11 @@ func SyncCommodities(db *gorm.DB) error {
 		log.Info("Fetching commodity ", name)
 		code := commodity.Price.Code
 		var prices []*price.Price
-		var err error
 
-		provider := scraper.GetProviderByCode(commodity.Price.Provider)
-		prices, err = provider.GetPrices(code, name)
+		provider, err := scraper.GetProviderByCode(commodity.Price.Provider)
+		if err == nil {
+			prices, err = provider.GetPrices(code, name)
+		}
 
 		if err != nil {
 			log.Error(err)
@@ -89,6 +107,14 @@ func SyncCommodities(db *gorm.DB) error {
 			continue
 		}
 
+		// store the prices under the configured type, so that the next
+		// sync replaces them, whatever type the provider assumed
+		if commodity.Type != config.Unknown {
+			for _, p := range prices {
+				p.CommodityType = commodity.Type
+			}
+		}
+
 		price.UpsertAllByTypeNameAndID(db, commodity.Type, name, code, prices)
 	}
 
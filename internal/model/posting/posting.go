@@ -41,6 +41,7 @@ type Posting struct {
 	Forecast             bool            `json:"forecast"`
 	Note                 string          `json:"note"`
 	TransactionNote      string          `json:"transaction_note"`
+	ContentHash          string          `json:"-"`
 
 	MarketAmount decimal.Decimal `gorm:"-:all" json:"market_amount"`
 	Balance      decimal.Decimal `gorm:"-:all" json:"balance"`
@@ -114,6 +115,12 @@ func (p Posting) HasBehaviour(behaviour string) bool {
 	return false
 }
 
+// Tag returns the value of the `Name: value` tag in the posting note,
+// falling back to the transaction note.
+func (p Posting) Tag(name string) string {
+	return p.Tags()[name]
+}
+
 func UpsertAll(db *gorm.DB, postings []*Posting) {
 	err := db.Transaction(func(tx *gorm.DB) error {
 		err := tx.Exec("DELETE FROM postings").Error
@@ -121,6 +128,7 @@ func UpsertAll(db *gorm.DB, postings []*Posting) {
 			return err
 		}
 		for _, posting := range postings {
+			posting.ContentHash = posting.Hash()
 			err := tx.Create(posting).Error
 			if err != nil {
 				return err
@@ -0,0 +1,254 @@
+package posting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SyncResult summarises the rows touched by SyncAll. Accounts lists
+// the accounts of postings whose content changed, TransactionIDs the
+// transactions whose postings changed in any way.
+type SyncResult struct {
+	Inserted        int      `json:"inserted"`
+	Updated         int      `json:"updated"`
+	Deleted         int      `json:"deleted"`
+	Unchanged       int      `json:"unchanged"`
+	ContentChanged  bool     `json:"-"`
+	AccountsRemoved bool     `json:"-"`
+	Accounts        []string `json:"-"`
+	Commodities     []string `json:"-"`
+	TransactionIDs  []string `json:"-"`
+}
+
+func (r SyncResult) Changed() bool {
+	return r.Inserted+r.Updated+r.Deleted > 0
+}
+
+// Hash covers everything except the row id, the transaction id and
+// the line range. Those change whenever a transaction is inserted
+// above, without the posting itself being any different.
+func (p *Posting) Hash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%s\x00%s\x00%s",
+		p.Date.Format("2006-01-02"), p.Payee, p.Account, p.Commodity, p.Quantity.String(), p.Amount.String(),
+		p.Status, p.TagRecurring, p.TagPeriod, p.Forecast, p.FileName, p.Note, p.TransactionNote)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func syncKey(p *Posting) string {
+	if p.Forecast {
+		return "forecast:" + p.TransactionID
+	}
+	return fmt.Sprintf("%s:%d:%d", p.FileName, p.TransactionBeginLine, p.TransactionEndLine)
+}
+
+type syncGroup struct {
+	key      string
+	hash     string
+	postings []*Posting
+}
+
+func buildSyncGroups(postings []*Posting) []*syncGroup {
+	var groups []*syncGroup
+	byKey := make(map[string]*syncGroup)
+	for _, p := range postings {
+		key := syncKey(p)
+		group, ok := byKey[key]
+		if !ok {
+			group = &syncGroup{key: key}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.postings = append(group.postings, p)
+	}
+
+	for _, group := range groups {
+		group.hash = strings.Join(lo.Map(group.postings, func(p *Posting, _ int) string { return p.ContentHash }), ",")
+	}
+	return groups
+}
+
+// SyncAll brings the postings table in line with the freshly parsed
+// postings. Transactions are matched by file and line range first and
+// then by content, so that a transaction that only moved within the
+// file is updated in place instead of being deleted and inserted.
+func SyncAll(db *gorm.DB, postings []*Posting) SyncResult {
+	var existing []*Posting
+	result := db.Order("id ASC").Find(&existing)
+	if result.Error != nil {
+		log.Fatal(result.Error)
+	}
+
+	for _, p := range postings {
+		p.ContentHash = p.Hash()
+	}
+
+	var inserts, updates, moves, deletes []*Posting
+	var sr SyncResult
+	accounts := make(map[string]bool)
+	commodities := make(map[string]bool)
+	transactionIDs := make(map[string]bool)
+
+	touch := func(p *Posting, content bool) {
+		transactionIDs[p.TransactionID] = true
+		if content {
+			sr.ContentChanged = true
+			accounts[p.Account] = true
+			commodities[p.Commodity] = true
+		}
+	}
+
+	pair := func(old []*Posting, new []*Posting) {
+		for i := 0; i < max(len(old), len(new)); i++ {
+			switch {
+			case i >= len(old):
+				inserts = append(inserts, new[i])
+				touch(new[i], true)
+			case i >= len(new):
+				deletes = append(deletes, old[i])
+				touch(old[i], true)
+				sr.AccountsRemoved = true
+			default:
+				o, n := old[i], new[i]
+				n.ID = o.ID
+				if o.ContentHash != n.ContentHash {
+					updates = append(updates, n)
+					touch(o, true)
+					touch(n, true)
+					if o.Account != n.Account {
+						sr.AccountsRemoved = true
+					}
+				} else if o.TransactionID != n.TransactionID || o.TransactionBeginLine != n.TransactionBeginLine || o.TransactionEndLine != n.TransactionEndLine {
+					moves = append(moves, n)
+					touch(o, false)
+					touch(n, false)
+				} else {
+					sr.Unchanged++
+				}
+			}
+		}
+	}
+
+	oldGroups := buildSyncGroups(existing)
+	oldByKey := lo.KeyBy(oldGroups, func(g *syncGroup) string { return g.key })
+	matched := make(map[*syncGroup]bool)
+
+	var unmatched []*syncGroup
+	for _, group := range buildSyncGroups(postings) {
+		if old, ok := oldByKey[group.key]; ok && old.hash == group.hash {
+			matched[old] = true
+			pair(old.postings, group.postings)
+		} else {
+			unmatched = append(unmatched, group)
+		}
+	}
+
+	oldByHash := make(map[string][]*syncGroup)
+	for _, old := range oldGroups {
+		if !matched[old] {
+			hash := old.postings[0].FileName + ":" + old.hash
+			oldByHash[hash] = append(oldByHash[hash], old)
+		}
+	}
+
+	var remaining []*syncGroup
+	for _, group := range unmatched {
+		hash := group.postings[0].FileName + ":" + group.hash
+		if candidates := oldByHash[hash]; len(candidates) > 0 {
+			matched[candidates[0]] = true
+			oldByHash[hash] = candidates[1:]
+			pair(candidates[0].postings, group.postings)
+		} else {
+			remaining = append(remaining, group)
+		}
+	}
+
+	for _, group := range remaining {
+		if old, ok := oldByKey[group.key]; ok && !matched[old] {
+			matched[old] = true
+			pair(old.postings, group.postings)
+		} else {
+			pair(nil, group.postings)
+		}
+	}
+
+	for _, old := range oldGroups {
+		if !matched[old] {
+			pair(old.postings, nil)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(deletes) > 0 {
+			ids := lo.Map(deletes, func(p *Posting, _ int) uint { return p.ID })
+			for _, chunk := range lo.Chunk(ids, 500) {
+				if err := tx.Delete(&Posting{}, chunk).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, p := range updates {
+			if err := tx.Save(p).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, chunk := range lo.Chunk(moves, 100) {
+			if err := updateLineRanges(tx, chunk); err != nil {
+				return err
+			}
+		}
+
+		if len(inserts) > 0 {
+			if err := tx.CreateInBatches(inserts, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sr.Inserted = len(inserts)
+	sr.Updated = len(updates) + len(moves)
+	sr.Deleted = len(deletes)
+	sr.Accounts = lo.Keys(accounts)
+	sr.Commodities = lo.Keys(commodities)
+	sr.TransactionIDs = lo.Keys(transactionIDs)
+	return sr
+}
+
+// updateLineRanges updates the transaction id and the line range of the
+// postings that only moved within the file, in a single statement
+func updateLineRanges(tx *gorm.DB, postings []*Posting) error {
+	columns := []struct {
+		name  string
+		cast  string
+		value func(p *Posting) any
+	}{
+		{"transaction_id", "TEXT", func(p *Posting) any { return p.TransactionID }},
+		{"transaction_begin_line", "BIGINT", func(p *Posting) any { return p.TransactionBeginLine }},
+		{"transaction_end_line", "BIGINT", func(p *Posting) any { return p.TransactionEndLine }},
+	}
+
+	var sets []string
+	var args []any
+	for _, column := range columns {
+		cases := strings.Repeat(" WHEN ? THEN CAST(? AS "+column.cast+")", len(postings))
+		sets = append(sets, column.name+" = CASE id"+cases+" END")
+		for _, p := range postings {
+			args = append(args, p.ID, column.value(p))
+		}
+	}
+	args = append(args, lo.Map(postings, func(p *Posting, _ int) uint { return p.ID }))
+	return tx.Exec("UPDATE postings SET "+strings.Join(sets, ", ")+" WHERE id IN ?", args...).Error
+}
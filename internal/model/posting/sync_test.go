@@ -0,0 +1,117 @@
+package posting
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openSyncDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Posting{}))
+	return db
+}
+
+func transactionPostings(id string, payee string, line uint64, amount int64) []*Posting {
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*Posting{
+		{TransactionID: id, Date: date, Payee: payee, Account: "Expenses:" + payee, Commodity: "INR", Quantity: decimal.NewFromInt(amount), Amount: decimal.NewFromInt(amount), FileName: "main.ledger", TransactionBeginLine: line, TransactionEndLine: line + 2},
+		{TransactionID: id, Date: date, Payee: payee, Account: "Assets:Checking", Commodity: "INR", Quantity: decimal.NewFromInt(-amount), Amount: decimal.NewFromInt(-amount), FileName: "main.ledger", TransactionBeginLine: line, TransactionEndLine: line + 2},
+	}
+}
+
+func journal(transactions ...[]*Posting) []*Posting {
+	var postings []*Posting
+	for _, t := range transactions {
+		postings = append(postings, t...)
+	}
+	return postings
+}
+
+func TestSyncAll(t *testing.T) {
+	db := openSyncDB(t)
+
+	result := SyncAll(db, journal(transactionPostings("1", "Rent", 1, 100), transactionPostings("4", "Food", 5, 50)))
+	assert.Equal(t, 4, result.Inserted)
+	assert.True(t, result.ContentChanged)
+
+	result = SyncAll(db, journal(transactionPostings("1", "Rent", 1, 100), transactionPostings("4", "Food", 5, 50)))
+	assert.False(t, result.Changed())
+	assert.Equal(t, 4, result.Unchanged)
+
+	var before []Posting
+	db.Order("id").Find(&before)
+
+	result = SyncAll(db, journal(transactionPostings("1", "Rent", 1, 100), transactionPostings("4", "Food", 5, 75)))
+	assert.Equal(t, SyncResult{Updated: 2, Unchanged: 2}, SyncResult{Inserted: result.Inserted, Updated: result.Updated, Deleted: result.Deleted, Unchanged: result.Unchanged})
+	assert.ElementsMatch(t, []string{"Expenses:Food", "Assets:Checking"}, result.Accounts)
+	assert.False(t, result.AccountsRemoved)
+
+	result = SyncAll(db, journal(transactionPostings("1", "Salary", 1, 10), transactionPostings("4", "Rent", 5, 100), transactionPostings("7", "Food", 9, 75)))
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 4, result.Updated)
+	assert.Equal(t, 0, result.Deleted)
+	assert.ElementsMatch(t, []string{"Expenses:Salary", "Assets:Checking"}, result.Accounts)
+
+	var after []Posting
+	db.Order("id").Find(&after)
+	assert.Equal(t, before[0].ID, after[0].ID)
+	assert.Equal(t, uint64(5), after[0].TransactionBeginLine)
+	assert.Equal(t, "4", after[0].TransactionID)
+	assert.Equal(t, uint64(9), after[2].TransactionBeginLine)
+
+	result = SyncAll(db, journal(transactionPostings("4", "Rent", 5, 100)))
+	assert.Equal(t, 4, result.Deleted)
+	assert.True(t, result.AccountsRemoved)
+
+	var count int64
+	db.Model(&Posting{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestSyncAllMovedTransactions(t *testing.T) {
+	db := openSyncDB(t)
+
+	var transactions [][]*Posting
+	for i := 0; i < 80; i++ {
+		transactions = append(transactions, transactionPostings(fmt.Sprint(i), fmt.Sprintf("Payee%d", i), uint64(i*4+1), int64(i+1)))
+	}
+	SyncAll(db, journal(transactions...))
+
+	var before []Posting
+	db.Order("id").Find(&before)
+
+	statements := 0
+	count := func(tx *gorm.DB) { statements++ }
+	require.NoError(t, db.Callback().Raw().After("gorm:raw").Register("test:count_raw", count))
+	require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:count_update", count))
+
+	// a transaction added at the top moves all the others down
+	moved := [][]*Posting{transactionPostings("new", "Salary", 1, 1000)}
+	for i := 0; i < 80; i++ {
+		moved = append(moved, transactionPostings(fmt.Sprint(i+1), fmt.Sprintf("Payee%d", i), uint64(i*4+5), int64(i+1)))
+	}
+	result := SyncAll(db, journal(moved...))
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 160, result.Updated)
+	assert.ElementsMatch(t, []string{"Expenses:Salary", "Assets:Checking"}, result.Accounts)
+	assert.Equal(t, 2, statements)
+
+	var after []Posting
+	db.Order("id").Find(&after)
+	require.Len(t, after, 162)
+	for i, p := range after[:160] {
+		assert.Equal(t, before[i].ID, p.ID)
+		assert.Equal(t, before[i].Payee, p.Payee)
+		assert.Equal(t, fmt.Sprint(i/2+1), p.TransactionID)
+		assert.Equal(t, uint64(i/2*4+5), p.TransactionBeginLine)
+		assert.Equal(t, uint64(i/2*4+7), p.TransactionEndLine)
+	}
+}
@@ -0,0 +1,38 @@
+package posting
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var (
+	noteTags     = regexp.MustCompile(`:(?:[^\s:]+:)+`)
+	noteKeyValue = regexp.MustCompile(`^([^\s:]+):\s*(.*)$`)
+)
+
+// ParseNoteTags extracts the ledger style tags from the note, both
+// :tag1:tag2: and key: value forms are supported.
+func ParseNoteTags(note string, tags map[string]string) map[string]string {
+	for _, line := range strings.Split(note, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "; \t"))
+		if match := noteTags.FindString(line); match != "" {
+			for _, tag := range strings.Split(strings.Trim(match, ":"), ":") {
+				tags[tag] = ""
+			}
+			continue
+		}
+
+		if match := noteKeyValue.FindStringSubmatch(line); match != nil {
+			tags[match[1]] = strings.TrimSpace(match[2])
+		}
+	}
+	return tags
+}
+
+// Tags returns the tags of the posting, the posting tags override the
+// transaction tags
+func (p Posting) Tags() map[string]string {
+	return ParseNoteTags(p.Note, lo.Assign(ParseNoteTags(p.TransactionNote, map[string]string{})))
+}
@@ -0,0 +1,25 @@
+package posting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNoteTags(t *testing.T) {
+	tags := ParseNoteTags(" Recurring: NETFLIX\n Period: 3 * ?\n :travel:work:", map[string]string{})
+	assert.Equal(t, map[string]string{"Recurring": "NETFLIX", "Period": "3 * ?", "travel": "", "work": ""}, tags)
+
+	assert.Empty(t, ParseNoteTags(" paid in cash", map[string]string{}))
+}
+
+func TestPostingTags(t *testing.T) {
+	p := Posting{TransactionNote: " Trip: Goa\n :travel:", Note: " Trip: Goa 2023"}
+	assert.Equal(t, map[string]string{"Trip": "Goa 2023", "travel": ""}, p.Tags())
+	assert.Equal(t, "Goa 2023", p.Tag("Trip"))
+	assert.Equal(t, "", p.Tag("travel"))
+	assert.Equal(t, "", p.Tag("Lot"))
+
+	p = Posting{TransactionNote: " Lot: june", Note: "; Lot: 2022-01-01, june"}
+	assert.Equal(t, "2022-01-01, june", p.Tag("Lot"))
+}
@@ -0,0 +1,77 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"02-01-2006",
+	"02/01/2006",
+	"02-Jan-2006",
+	"02 Jan 2006",
+	"Jan 2, 2006",
+}
+
+// ParseDate parses the date found in a price source. The layout uses
+// the go reference date, the special value unix accepts seconds since
+// epoch. When the layout is empty, a few common layouts are tried.
+func ParseDate(value any, layout string) (time.Time, error) {
+	str := strings.TrimSpace(fmt.Sprint(value))
+	if layout == "unix" {
+		seconds, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Invalid unix timestamp: %s", str)
+		}
+		date := time.Unix(int64(seconds), 0).In(config.TimeZone())
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, config.TimeZone()), nil
+	}
+
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+
+	for _, layout := range layouts {
+		date, err := time.ParseInLocation(layout, str, config.TimeZone())
+		if err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, config.TimeZone()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date: %s", str)
+}
+
+// ParseValue parses the price found in a price source. Numbers can be
+// json numbers or strings with thousand separators.
+func ParseValue(value any) (decimal.Decimal, error) {
+	switch v := value.(type) {
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case int:
+		return decimal.NewFromInt(int64(v)), nil
+	case int64:
+		return decimal.NewFromInt(v), nil
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case decimal.Decimal:
+		return v, nil
+	case string:
+		str := strings.ReplaceAll(strings.TrimSpace(v), ",", "")
+		d, err := decimal.NewFromString(str)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("Invalid price: %s", v)
+		}
+		return d, nil
+	}
+	return decimal.Zero, fmt.Errorf("Invalid price: %v", value)
+}
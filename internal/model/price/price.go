@@ -1,6 +1,7 @@
 package price
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -54,6 +55,36 @@ func UpsertAllByTypeNameAndID(db *gorm.DB, commodityType config.CommodityType, c
 	}
 }
 
+// IsUpToDate reports whether the stored prices of the given type are
+// exactly the given prices, ignoring the order.
+func IsUpToDate(db *gorm.DB, commodityType config.CommodityType, prices []Price) bool {
+	var existing []Price
+	result := db.Where("commodity_type = ?", commodityType).Find(&existing)
+	if result.Error != nil {
+		log.Fatal(result.Error)
+	}
+
+	if len(existing) != len(prices) {
+		return false
+	}
+
+	key := func(p Price) string {
+		return fmt.Sprintf("%s:%s:%d:%s", p.CommodityID, p.CommodityName, p.Date.Unix(), p.Value.String())
+	}
+
+	counts := make(map[string]int)
+	for _, p := range existing {
+		counts[key(p)]++
+	}
+	for _, p := range prices {
+		counts[key(p)]--
+		if counts[key(p)] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func UpsertAllByType(db *gorm.DB, commodityType config.CommodityType, prices []Price) {
 	err := db.Transaction(func(tx *gorm.DB) error {
 		err := tx.Delete(&Price{}, "commodity_type = ?", commodityType).Error
@@ -1,6 +1,11 @@
 package price
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
 
 type AutoCompleteItem struct {
 	Label string `json:"label"`
@@ -12,6 +17,7 @@ type AutoCompleteField struct {
 	ID        string `json:"id"`
 	Help      string `json:"help"`
 	InputType string `json:"inputType"`
+	Optional  bool   `json:"optional"`
 }
 
 type PriceProvider interface {
@@ -23,3 +29,43 @@ type PriceProvider interface {
 	ClearCache(db *gorm.DB)
 	GetPrices(code string, commodityName string) ([]*Price, error)
 }
+
+type registeredProvider struct {
+	provider PriceProvider
+	priority int
+}
+
+var providers []registeredProvider
+
+// RegisterProvider makes the provider available under its code.
+// Providers register themselves from an init function, the priority
+// decides the order in which they are listed, lower first. The first
+// one is the default in the UI, so the order doesn't depend on the
+// order of the imports. Registering the same code twice is a
+// programming error.
+func RegisterProvider(provider PriceProvider, priority int) {
+	for _, existing := range providers {
+		if existing.provider.Code() == provider.Code() {
+			panic(fmt.Sprintf("price provider %s is already registered", provider.Code()))
+		}
+	}
+	providers = append(providers, registeredProvider{provider: provider, priority: priority})
+	sort.SliceStable(providers, func(i, j int) bool { return providers[i].priority < providers[j].priority })
+}
+
+func Providers() []PriceProvider {
+	result := make([]PriceProvider, len(providers))
+	for i, registered := range providers {
+		result[i] = registered.provider
+	}
+	return result
+}
+
+func ProviderByCode(code string) (PriceProvider, error) {
+	for _, registered := range providers {
+		if registered.provider.Code() == code {
+			return registered.provider, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown price provider: %s", code)
+}
@@ -25,7 +25,9 @@ type Transaction struct {
 
 type transactionCache struct {
 	sync.Once
+	sync.Mutex
 	transactions map[string]Transaction
+	stale        map[string]bool
 }
 
 var tcache transactionCache
@@ -33,6 +35,7 @@ var tcache transactionCache
 func loadTransactionCache(db *gorm.DB) {
 	postings := query.Init(db).All()
 	tcache.transactions = make(map[string]Transaction)
+	tcache.stale = make(map[string]bool)
 
 	for _, t := range Build(postings) {
 		tcache.transactions[t.ID] = t
@@ -41,6 +44,17 @@ func loadTransactionCache(db *gorm.DB) {
 
 func GetById(db *gorm.DB, id string) (Transaction, bool) {
 	tcache.Do(func() { loadTransactionCache(db) })
+	tcache.Lock()
+	defer tcache.Unlock()
+
+	if tcache.stale[id] {
+		delete(tcache.stale, id)
+		postings := query.Init(db).Where("transaction_id = ?", id).All()
+		if len(postings) > 0 {
+			tcache.transactions[id] = Build(postings)[0]
+		}
+	}
+
 	t, found := tcache.transactions[id]
 	return t, found
 }
@@ -49,6 +63,22 @@ func ClearCache() {
 	tcache = transactionCache{}
 }
 
+// InvalidateCache drops the given transactions, they are reloaded from
+// the database on next access.
+func InvalidateCache(ids []string) {
+	tcache.Lock()
+	defer tcache.Unlock()
+
+	if tcache.transactions == nil {
+		return
+	}
+
+	for _, id := range ids {
+		delete(tcache.transactions, id)
+		tcache.stale[id] = true
+	}
+}
+
 func Build(postings []posting.Posting) []Transaction {
 	grouped := lo.GroupBy(postings, func(p posting.Posting) string { return p.TransactionID })
 	return lo.Map(lo.Values(grouped), func(ps []posting.Posting, _ int) Transaction {
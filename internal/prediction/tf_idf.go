@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"sync"
 
 	"strings"
@@ -44,6 +45,12 @@ func ClearCache() {
 	cache = tfidfCache{}
 }
 
+func InvalidateCache(contentChanged bool) {
+	if contentChanged {
+		ClearCache()
+	}
+}
+
 func buldIndex(postings []posting.Posting) index {
 	idx := index{
 		Docs:   make(map[string]map[string]int64),
@@ -90,3 +97,54 @@ func GetTfIdf(db *gorm.DB) gin.H {
 	})
 	return gin.H{"tf_idf": cache.vector, "index": cache.index}
 }
+
+// FindMatch returns the accounts ordered by the cosine similarity of
+// their tf-idf vector with the query, best match first. Same as the
+// account prediction of the import page.
+func FindMatch(db *gorm.DB, query string) []string {
+	cache.Do(func() {
+		loadVectorCache(db)
+	})
+
+	tokens := tokenize(query)
+	frequency := lo.CountValues(tokens)
+	queryVector := make(map[string]float64)
+	for token, freq := range frequency {
+		tf := float64(freq) / float64(len(frequency))
+		idf := math.Log(float64(len(cache.index.Docs))/(1+float64(len(cache.index.Tokens[token])))) + 1
+		queryVector[token] = tf * idf
+	}
+
+	type match struct {
+		account string
+		score   float64
+	}
+
+	accounts := lo.Keys(cache.vector)
+	sort.Strings(accounts)
+	var matches []match
+	for _, account := range accounts {
+		score := similarity(queryVector, cache.vector[account])
+		if score > 0 {
+			matches = append(matches, match{account: account, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+	return lo.Reverse(lo.Map(matches, func(m match, _ int) string { return m.account }))
+}
+
+func similarity(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for token, x := range a {
+		dot += x * b[token]
+		normA += x * x
+	}
+	for _, y := range b {
+		normB += y * y
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
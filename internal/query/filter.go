@@ -0,0 +1,384 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// term is a single condition of the filter. The conditions that can be
+// expressed in SQL have the sql set, match is always set and used when
+// the condition has to be combined with the go only conditions.
+type term struct {
+	field   string
+	negated bool
+	sql     string
+	args    []interface{}
+	match   func(p posting.Posting) bool
+}
+
+var amountCondition = regexp.MustCompile(`^(<=|>=|<|>|=)?([+-])?(\d+(?:\.\d+)?)$`)
+
+// Parse builds the query from the hledger style filter expression, for
+// example
+//
+//	acct:Expenses:Food date:2024-01.. payee:/swiggy/ amt:>500 tag:trip=goa status:*
+//
+// The terms of the same field are OR'ed, the terms of different fields
+// and the negated terms are AND'ed. A term without a field prefix
+// matches the account.
+func Parse(db *gorm.DB, input string) (*Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{}
+	terms := map[string][]term{}
+	for _, token := range tokens {
+		t, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := terms[t.field]; !ok {
+			fields = append(fields, t.field)
+		}
+		terms[t.field] = append(terms[t.field], t)
+	}
+
+	q := Init(db)
+	for _, field := range fields {
+		positives := lo.Filter(terms[field], func(t term, _ int) bool { return !t.negated })
+		negatives := lo.Filter(terms[field], func(t term, _ int) bool { return t.negated })
+		if len(positives) > 0 {
+			if lo.EveryBy(positives, func(t term) bool { return t.sql != "" }) {
+				sql := strings.Join(lo.Map(positives, func(t term, _ int) string { return "(" + t.sql + ")" }), " or ")
+				q.Where(sql, lo.FlatMap(positives, func(t term, _ int) []interface{} { return t.args })...)
+			} else {
+				q.Match(func(p posting.Posting) bool {
+					return lo.SomeBy(positives, func(t term) bool { return t.match(p) })
+				})
+			}
+		}
+
+		for _, t := range negatives {
+			if t.sql != "" {
+				q.Where("not ("+t.sql+")", t.args...)
+			} else {
+				match := t.match
+				q.Match(func(p posting.Posting) bool { return !match(p) })
+			}
+		}
+	}
+	return q, nil
+}
+
+// tokenize splits the input on whitespace, the single or double quotes
+// could be used anywhere in the term to include the whitespace
+func tokenize(input string) ([]string, error) {
+	tokens := []string{}
+	var current strings.Builder
+	started := false
+	var quote rune
+	for _, r := range input {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			started = true
+		case unicode.IsSpace(r):
+			if started {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated quote %c", quote)
+	}
+	if started {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func parseTerm(token string) (term, error) {
+	negated := false
+	if strings.HasPrefix(token, "not:") {
+		negated = true
+		token = strings.TrimPrefix(token, "not:")
+	}
+
+	field, value, found := strings.Cut(token, ":")
+	if !found || !lo.Contains([]string{"acct", "payee", "desc", "cur", "date", "amt", "status", "tag"}, field) {
+		field, value = "acct", token
+	}
+
+	var t term
+	var err error
+	switch field {
+	case "acct":
+		t, err = textTerm(value, "account", func(p posting.Posting) string { return p.Account })
+	case "payee", "desc":
+		field = "payee"
+		t, err = textTerm(value, "payee", func(p posting.Posting) string { return p.Payee })
+	case "cur":
+		t, err = commodityTerm(value)
+	case "date":
+		t, err = dateTerm(value)
+	case "amt":
+		t, err = amountTerm(value)
+	case "status":
+		t, err = statusTerm(value)
+	case "tag":
+		t, err = tagTerm(value)
+	}
+	if err != nil {
+		return term{}, fmt.Errorf("%s: %s", token, err.Error())
+	}
+
+	t.field = field
+	t.negated = negated
+	return t, nil
+}
+
+func isRegex(value string) bool {
+	return len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/")
+}
+
+func compileRegex(value string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+	if err != nil {
+		return nil, errors.New("Invalid regular expression")
+	}
+	return re, nil
+}
+
+// textMatcher matches case insensitively, the plain text should be
+// contained in the value and the /regex/ should match the value
+func textMatcher(value string) (func(string) bool, error) {
+	if isRegex(value) {
+		re, err := compileRegex(value)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	value = strings.ToLower(value)
+	return func(s string) bool { return strings.Contains(strings.ToLower(s), value) }, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func textTerm(value string, column string, get func(posting.Posting) string) (term, error) {
+	if value == "" {
+		return term{}, errors.New("Empty value")
+	}
+
+	matcher, err := textMatcher(value)
+	if err != nil {
+		return term{}, err
+	}
+
+	t := term{match: func(p posting.Posting) bool { return matcher(get(p)) }}
+	if !isRegex(value) {
+		t.sql = "lower(" + column + `) like ? escape '\'`
+		t.args = []interface{}{"%" + escapeLike(strings.ToLower(value)) + "%"}
+	}
+	return t, nil
+}
+
+func commodityTerm(value string) (term, error) {
+	if value == "" {
+		return term{}, errors.New("Empty value")
+	}
+
+	if isRegex(value) {
+		re, err := compileRegex(value)
+		if err != nil {
+			return term{}, err
+		}
+		return term{match: func(p posting.Posting) bool { return re.MatchString(p.Commodity) }}, nil
+	}
+
+	return term{
+		sql:   "lower(commodity) = ?",
+		args:  []interface{}{strings.ToLower(value)},
+		match: func(p posting.Posting) bool { return strings.EqualFold(p.Commodity, value) },
+	}, nil
+}
+
+// parsePeriod parses the date with year, month or day precision and
+// returns the start and the exclusive end of the period
+func parsePeriod(value string) (time.Time, time.Time, error) {
+	value = strings.ReplaceAll(value, "/", "-")
+	layouts := []struct {
+		layout              string
+		years, months, days int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	}
+	for _, l := range layouts {
+		if start, err := time.ParseInLocation(l.layout, value, config.TimeZone()); err == nil {
+			return start, start.AddDate(l.years, l.months, l.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("Invalid date %s, expected YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
+
+// dateTerm supports a single period like 2024-01 or a range like
+// 2024-01..2024-03, where the end is exclusive and either side could be
+// left open
+func dateTerm(value string) (term, error) {
+	var start, end time.Time
+	var err error
+
+	if from, to, isRange := strings.Cut(value, ".."); isRange {
+		if from == "" && to == "" {
+			return term{}, errors.New("Empty date range")
+		}
+		if from != "" {
+			if start, _, err = parsePeriod(from); err != nil {
+				return term{}, err
+			}
+		}
+		if to != "" {
+			if end, _, err = parsePeriod(to); err != nil {
+				return term{}, err
+			}
+		}
+	} else {
+		if start, end, err = parsePeriod(value); err != nil {
+			return term{}, err
+		}
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if !start.IsZero() {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, start)
+	}
+	if !end.IsZero() {
+		conditions = append(conditions, "date < ?")
+		args = append(args, end)
+	}
+
+	return term{
+		sql:  strings.Join(conditions, " and "),
+		args: args,
+		match: func(p posting.Posting) bool {
+			return (start.IsZero() || !p.Date.Before(start)) && (end.IsZero() || p.Date.Before(end))
+		},
+	}, nil
+}
+
+// amountTerm compares the amount in the default currency. Without an
+// explicit sign the absolute amount is compared, same as hledger.
+func amountTerm(value string) (term, error) {
+	match := amountCondition.FindStringSubmatch(value)
+	if match == nil {
+		return term{}, errors.New("Invalid amount condition, expected something like >500, <=-100 or 250")
+	}
+
+	operator := lo.Ternary(match[1] == "", "=", match[1])
+	signed := match[2] != ""
+	number, err := decimal.NewFromString(match[2] + match[3])
+	if err != nil {
+		return term{}, err
+	}
+
+	return term{match: func(p posting.Posting) bool {
+		amount := lo.Ternary(signed, p.Amount, p.Amount.Abs())
+		switch operator {
+		case "<":
+			return amount.LessThan(number)
+		case "<=":
+			return amount.LessThanOrEqual(number)
+		case ">":
+			return amount.GreaterThan(number)
+		case ">=":
+			return amount.GreaterThanOrEqual(number)
+		default:
+			return amount.Equal(number)
+		}
+	}}, nil
+}
+
+// statusTerm follows the ledger marks, * is cleared, ! is pending and
+// empty is unmarked
+func statusTerm(value string) (term, error) {
+	var status string
+	switch value {
+	case "*", "cleared":
+		status = "cleared"
+	case "!", "pending":
+		status = "pending"
+	case "", "unmarked":
+		status = "unmarked"
+	default:
+		return term{}, errors.New("Invalid status, expected *, ! or empty")
+	}
+
+	return term{
+		sql:   "status = ?",
+		args:  []interface{}{status},
+		match: func(p posting.Posting) bool { return p.Status == status },
+	}, nil
+}
+
+// tagTerm matches the tag name and the optional value case
+// insensitively, either of them could be a /regex/
+func tagTerm(value string) (term, error) {
+	name, tagValue, hasValue := strings.Cut(value, "=")
+	if name == "" {
+		return term{}, errors.New("Empty tag name")
+	}
+
+	exact := func(value string) (func(string) bool, error) {
+		if isRegex(value) {
+			return textMatcher(value)
+		}
+		return func(s string) bool { return strings.EqualFold(s, value) }, nil
+	}
+
+	nameMatcher, err := exact(name)
+	if err != nil {
+		return term{}, err
+	}
+	valueMatcher, err := exact(tagValue)
+	if err != nil {
+		return term{}, err
+	}
+
+	return term{match: func(p posting.Posting) bool {
+		for tag, v := range p.Tags() {
+			if nameMatcher(tag) && (!hasValue || valueMatcher(v)) {
+				return true
+			}
+		}
+		return false
+	}}, nil
+}
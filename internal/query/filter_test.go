@@ -0,0 +1,110 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openFilterDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&posting.Posting{}))
+
+	postings := []posting.Posting{
+		{TransactionID: "1", Date: date("2023-12-28"), Payee: "Swiggy", Account: "Expenses:Food:Dining", Amount: decimal.NewFromInt(450), Status: "cleared"},
+		{TransactionID: "2", Date: date("2024-01-05"), Payee: "Swiggy Instamart", Account: "Expenses:Food:Groceries", Amount: decimal.NewFromInt(800), Status: "cleared", TransactionNote: " Trip: Goa"},
+		{TransactionID: "3", Date: date("2024-01-20"), Payee: "Big Bazaar", Account: "Expenses:Food:Groceries", Amount: decimal.NewFromInt(1200), Status: "pending"},
+		{TransactionID: "4", Date: date("2024-02-10"), Payee: "Zomato", Account: "Expenses:Food:Dining", Amount: decimal.NewFromInt(600), Status: "unmarked", Note: " :travel:"},
+		{TransactionID: "5", Date: date("2024-02-15"), Payee: "Salary", Account: "Income:Salary", Amount: decimal.NewFromInt(-50000), Status: "cleared"},
+		{TransactionID: "6", Date: date("2024-03-01"), Payee: "Swiggy", Account: "Expenses:Food:Dining", Amount: decimal.NewFromInt(300), Status: "cleared", Commodity: "INR"},
+	}
+	for _, p := range postings {
+		if p.Commodity == "" {
+			p.Commodity = "INR"
+		}
+		p.Quantity = p.Amount
+		require.NoError(t, db.Create(&p).Error)
+	}
+	return db
+}
+
+func date(value string) time.Time {
+	d, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	db := openFilterDB(t)
+
+	cases := []struct {
+		filter   string
+		expected []string
+	}{
+		{"", []string{"1", "2", "3", "4", "5", "6"}},
+		{"acct:Expenses:Food date:2024-01.. payee:/swiggy/ amt:>500 tag:trip=goa status:*", []string{"2"}},
+		{"Expenses:Food:Dining", []string{"1", "4", "6"}},
+		{"acct:dining acct:groceries", []string{"1", "2", "3", "4", "6"}},
+		{"acct:/^income/", []string{"5"}},
+		{"not:acct:Expenses", []string{"5"}},
+		{"date:2024-01", []string{"2", "3"}},
+		{"date:2024", []string{"2", "3", "4", "5", "6"}},
+		{"date:..2024-01", []string{"1"}},
+		{"date:2024-01-20..2024-03-01", []string{"3", "4", "5"}},
+		{"payee:swiggy", []string{"1", "2", "6"}},
+		{"payee:'big bazaar'", []string{"3"}},
+		{"desc:/^swiggy$/", []string{"1", "6"}},
+		{"amt:>=1200", []string{"3", "5"}},
+		{"amt:<-1000", []string{"5"}},
+		{"amt:600", []string{"4"}},
+		{"status:!", []string{"3"}},
+		{"status:", []string{"4"}},
+		{"not:status:*", []string{"3", "4"}},
+		{"tag:trip", []string{"2"}},
+		{"tag:travel", []string{"4"}},
+		{"tag:trip=/^go/ tag:travel", []string{"2", "4"}},
+		{"cur:inr date:2024-03", []string{"6"}},
+		{"payee:100%", []string{}},
+	}
+
+	for _, c := range cases {
+		q, err := Parse(db, c.filter)
+		require.NoError(t, err, c.filter)
+		ids := lo.Map(q.All(), func(p posting.Posting, _ int) string { return p.TransactionID })
+		assert.ElementsMatch(t, c.expected, ids, c.filter)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	db := openFilterDB(t)
+
+	for _, filter := range []string{
+		"date:2024-13",
+		"date:..",
+		"amt:>abc",
+		"status:?",
+		"payee:/[/",
+		"tag:",
+		"acct:",
+		"payee:'big bazaar",
+	} {
+		_, err := Parse(db, filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(` acct:Expenses  payee:"big bazaar" 'not:tag:trip=goa 2023'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acct:Expenses", "payee:big bazaar", "not:tag:trip=goa 2023"}, tokens)
+}
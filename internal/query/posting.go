@@ -15,6 +15,8 @@ type Query struct {
 	context         *gorm.DB
 	order           string
 	includeForecast bool
+	predicates      []func(posting.Posting) bool
+	limit           int
 }
 
 func Init(db *gorm.DB) *Query {
@@ -31,13 +33,18 @@ func (q *Query) Forecast() *Query {
 	return q
 }
 
+// Limit caps the number of postings, it's applied after the Match
+// predicates if there are any
 func (q *Query) Limit(n int) *Query {
-	q.context = q.context.Limit(n)
+	q.limit = n
 	return q
 }
 
 func (q *Query) Clone() *Query {
-	return &Query{context: q.context.Session(&gorm.Session{}), order: q.order, includeForecast: q.includeForecast}
+	// the full slice expression makes the further Match calls on either
+	// of the queries copy the predicates instead of sharing them
+	predicates := q.predicates[:len(q.predicates):len(q.predicates)]
+	return &Query{context: q.context.Session(&gorm.Session{}), order: q.order, includeForecast: q.includeForecast, predicates: predicates, limit: q.limit}
 }
 
 func (q *Query) BeforeNMonths(n int) *Query {
@@ -71,14 +78,17 @@ func (q *Query) Commodities(commodities []config.Commodity) *Query {
 }
 
 func (q *Query) Status(status string) *Query {
-	if status == "cleared" || status == "pending" {
+	if status == "cleared" || status == "pending" || status == "unmarked" {
 		q.context = q.context.Where("status = ?", status)
 	}
 	return q
 }
 
+// Credit filters the postings with positive amount. The amounts are
+// stored as text to keep the precision, PostgreSQL can't compare text
+// with a number, so the amount is cast to numeric first.
 func (q *Query) Credit() *Query {
-	q.context = q.context.Where("amount > 0")
+	q.context = q.context.Where("CAST(amount AS NUMERIC) > 0")
 	return q
 }
 
@@ -126,18 +136,51 @@ func (q *Query) Where(query interface{}, args ...interface{}) *Query {
 	return q
 }
 
+// Match filters the postings in go, for the conditions that can't be
+// expressed in SQL
+func (q *Query) Match(predicate func(posting.Posting) bool) *Query {
+	q.predicates = append(q.predicates, predicate)
+	return q
+}
+
+func (q *Query) matches(p posting.Posting) bool {
+	for _, predicate := range q.predicates {
+		if !predicate(p) {
+			return false
+		}
+	}
+	return true
+}
+
 func (q *Query) All() []posting.Posting {
 	var postings []posting.Posting
 
 	q.context = q.context.Where("forecast = ?", q.includeForecast)
+	if q.limit > 0 && len(q.predicates) == 0 {
+		q.context = q.context.Limit(q.limit)
+	}
 	result := q.context.Order("date " + q.order + ", amount desc, account asc").Find(&postings)
 	if result.Error != nil {
 		log.Fatal(result.Error)
 	}
+	if len(q.predicates) > 0 {
+		postings = lo.Filter(postings, func(p posting.Posting, _ int) bool { return q.matches(p) })
+		if q.limit > 0 && len(postings) > q.limit {
+			postings = postings[:q.limit]
+		}
+	}
 	return postings
 }
 
 func (q *Query) First() *posting.Posting {
+	if len(q.predicates) > 0 {
+		postings := q.All()
+		if len(postings) == 0 {
+			return nil
+		}
+		return &postings[0]
+	}
+
 	var posting posting.Posting
 	q.context = q.context.Where("forecast = ?", q.includeForecast)
 	result := q.context.Order("date " + q.order + ", amount desc, account asc").First(&posting)
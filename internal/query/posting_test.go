@@ -0,0 +1,86 @@
+package query
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCredit(t *testing.T) {
+	assertCredit(t, openFilterDB(t))
+}
+
+func assertCredit(t *testing.T, db *gorm.DB) {
+	for i, amount := range []string{"0", "0.5", "-0.5", "-10", "10", "0.0001", "-1234567.891"} {
+		value := decimal.RequireFromString(amount)
+		require.NoError(t, db.Create(&posting.Posting{TransactionID: "credit", Date: date("2024-04-01"), Account: "Liabilities:Loan", Commodity: "INR", Quantity: value, Amount: value, Payee: amount}).Error, i)
+	}
+
+	postings := Init(db).Like("Liabilities:%").Credit().All()
+	assert.ElementsMatch(t, []string{"0.5", "10", "0.0001"}, lo.Map(postings, func(p posting.Posting, _ int) string { return p.Amount.String() }))
+}
+
+func TestLimitWithMatch(t *testing.T) {
+	db := openFilterDB(t)
+	ids := func(q *Query) []string {
+		return lo.Map(q.All(), func(p posting.Posting, _ int) string { return p.TransactionID })
+	}
+
+	assert.Equal(t, []string{"1", "2"}, ids(Init(db).Limit(2)))
+
+	isDining := func(p posting.Posting) bool { return p.Account == "Expenses:Food:Dining" }
+	assert.Equal(t, []string{"1", "4"}, ids(Init(db).Match(isDining).Limit(2)))
+	assert.Equal(t, []string{"6", "4"}, ids(Init(db).Desc().Limit(2).Match(isDining)))
+	assert.Equal(t, []string{"1", "4", "6"}, ids(Init(db).Match(isDining).Limit(5)))
+}
+
+// TestPostgres runs only if PAISA_TEST_POSTGRES_DSN is set, the paisa
+// tables in the database are dropped before and after the test.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("PAISA_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PAISA_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := utils.OpenPostgres(dsn)
+	require.NoError(t, err)
+	drop := func() {
+		require.NoError(t, db.Migrator().DropTable(model.Models...))
+	}
+	drop()
+	t.Cleanup(drop)
+
+	from := openFilterDB(t)
+	model.AutoMigrate(from)
+	require.NoError(t, model.CopyDatabase(from, db))
+
+	expected := Init(from).All()
+	actual := Init(db).All()
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].TransactionID, actual[i].TransactionID)
+		assert.True(t, expected[i].Amount.Equal(actual[i].Amount))
+		assert.True(t, expected[i].Date.Equal(actual[i].Date))
+	}
+
+	for _, filter := range []string{"amt:>=1200", "amt:<-1000", "date:2024-01", "payee:swiggy", "acct:/^income/", "status:!"} {
+		q, err := Parse(from, filter)
+		require.NoError(t, err, filter)
+		expected := lo.Map(q.All(), func(p posting.Posting, _ int) string { return p.TransactionID })
+
+		q, err = Parse(db, filter)
+		require.NoError(t, err, filter)
+		assert.ElementsMatch(t, expected, lo.Map(q.All(), func(p posting.Posting, _ int) string { return p.TransactionID }), filter)
+	}
+
+	// the postings created after the copy use the moved id sequence
+	assertCredit(t, db)
+}
@@ -0,0 +1,243 @@
+package file
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/commodity"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDateColumn  = "date"
+	defaultValueColumn = "value"
+)
+
+type PriceProvider struct {
+}
+
+func init() {
+	price.RegisterProvider(&PriceProvider{}, 60)
+}
+
+func (p *PriceProvider) Code() string {
+	return "file"
+}
+
+func (p *PriceProvider) Label() string {
+	return "File"
+}
+
+func (p *PriceProvider) Description() string {
+	return "Reads prices from a CSV or JSON file. Useful for unlisted holdings, employer stock, private funds etc."
+}
+
+func (p *PriceProvider) AutoCompleteFields() []price.AutoCompleteField {
+	return []price.AutoCompleteField{
+		{Label: "Date Column", ID: "date", Help: "Name of the column (CSV header or JSON key) that holds the date. Usually <code>date</code>.", InputType: "text", Optional: true},
+		{Label: "Value Column", ID: "value", Help: "Name of the column that holds the price. Usually <code>value</code>.", InputType: "text", Optional: true},
+		{Label: "Date Format", ID: "format", Help: "Date format in <a href='https://pkg.go.dev/time#pkg-constants' target='_blank'>go layout</a>, like <code>2006-01-02</code>. Use <code>unix</code> for timestamps.", InputType: "text", Optional: true},
+		{Label: "File", ID: "path", Help: "CSV or JSON file, relative to the directory of paisa.yaml."},
+	}
+}
+
+func (p *PriceProvider) AutoComplete(db *gorm.DB, field string, filter map[string]string) []price.AutoCompleteItem {
+	if field != "path" {
+		return []price.AutoCompleteItem{}
+	}
+
+	dir := config.GetConfigDir()
+	files, err := doublestar.Glob(os.DirFS(dir), "**/*.{csv,json}")
+	if err != nil {
+		log.Error(err)
+		return []price.AutoCompleteItem{}
+	}
+
+	return lo.Map(files, func(file string, _ int) price.AutoCompleteItem {
+		return price.AutoCompleteItem{
+			Label: file,
+			ID:    EncodeCode(file, filter["date"], filter["value"], filter["format"]),
+		}
+	})
+}
+
+func (p *PriceProvider) ClearCache(db *gorm.DB) {
+}
+
+// EncodeCode builds the commodity price code. Options that match the
+// defaults are left out, so the simple case is just the path.
+func EncodeCode(path, dateColumn, valueColumn, format string) string {
+	query := url.Values{}
+	if dateColumn != "" && dateColumn != defaultDateColumn {
+		query.Set("date", dateColumn)
+	}
+	if valueColumn != "" && valueColumn != defaultValueColumn {
+		query.Set("value", valueColumn)
+	}
+	if format != "" {
+		query.Set("format", format)
+	}
+
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+type options struct {
+	path        string
+	dateColumn  string
+	valueColumn string
+	format      string
+}
+
+func decodeCode(code string) (options, error) {
+	path, rawQuery, _ := strings.Cut(code, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return options{}, fmt.Errorf("Invalid code: %s", code)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(config.GetConfigDir(), path)
+	}
+
+	return options{
+		path:        path,
+		dateColumn:  lo.Ternary(query.Has("date"), query.Get("date"), defaultDateColumn),
+		valueColumn: lo.Ternary(query.Has("value"), query.Get("value"), defaultValueColumn),
+		format:      query.Get("format"),
+	}, nil
+}
+
+func (p *PriceProvider) GetPrices(code string, commodityName string) ([]*price.Price, error) {
+	opts, err := decodeCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Reading prices from %s", opts.path)
+	content, err := os.ReadFile(opts.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]any
+	if strings.EqualFold(filepath.Ext(opts.path), ".json") {
+		rows, err = readJSON(content)
+	} else {
+		rows, err = readCSV(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commodityType := commodity.FindByName(commodityName).Type
+	if commodityType == "" {
+		commodityType = config.Unknown
+	}
+
+	var prices []*price.Price
+	for i, row := range rows {
+		dateValue, ok := lookup(row, opts.dateColumn)
+		if !ok {
+			return nil, fmt.Errorf("Column %s not found in row %d", opts.dateColumn, i+1)
+		}
+		value, ok := lookup(row, opts.valueColumn)
+		if !ok {
+			return nil, fmt.Errorf("Column %s not found in row %d", opts.valueColumn, i+1)
+		}
+
+		date, err := price.ParseDate(dateValue, opts.format)
+		if err != nil {
+			return nil, fmt.Errorf("Row %d: %w", i+1, err)
+		}
+
+		amount, err := price.ParseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("Row %d: %w", i+1, err)
+		}
+
+		prices = append(prices, &price.Price{Date: date, CommodityType: commodityType, CommodityID: code, CommodityName: commodityName, Value: amount})
+	}
+
+	sort.SliceStable(prices, func(i, j int) bool { return prices[i].Date.Before(prices[j].Date) })
+	return prices, nil
+}
+
+// lookup finds the column by name, ignoring case. A number can be used
+// to refer to the column by position, starting from 1.
+func lookup(row map[string]any, column string) (any, bool) {
+	if value, ok := row[column]; ok {
+		return value, true
+	}
+
+	for key, value := range row {
+		if strings.EqualFold(key, column) {
+			return value, true
+		}
+	}
+
+	if index, err := strconv.Atoi(column); err == nil {
+		value, ok := row["#"+strconv.Itoa(index)]
+		return value, ok
+	}
+	return nil, false
+}
+
+func readCSV(content []byte) ([]map[string]any, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	var rows []map[string]any
+	for _, record := range records[1:] {
+		if len(lo.Compact(record)) == 0 {
+			continue
+		}
+
+		row := make(map[string]any)
+		for i, value := range record {
+			if i < len(header) {
+				row[strings.TrimSpace(header[i])] = value
+			}
+			row["#"+strconv.Itoa(i+1)] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSON(content []byte) ([]map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var rows []map[string]any
+	err := decoder.Decode(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("Expected a list of objects: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,72 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestGetPricesCSV(t *testing.T) {
+	path := write(t, "acme.csv", "Date,Open,Close\n02/04/2023,130,\"1,131.25\"\n01/01/2023,118,120.50\n\n")
+	provider := &PriceProvider{}
+
+	prices, err := provider.GetPrices(EncodeCode(path, "Date", "close", "02/01/2006"), "ACME")
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "2023-01-01", prices[0].Date.Format("2006-01-02"))
+	assert.Equal(t, "120.5", prices[0].Value.String())
+	assert.Equal(t, "2023-04-02", prices[1].Date.Format("2006-01-02"))
+	assert.Equal(t, "1131.25", prices[1].Value.String())
+	assert.Equal(t, "ACME", prices[1].CommodityName)
+
+	prices, err = provider.GetPrices(EncodeCode(path, "1", "3", "02/01/2006"), "ACME")
+	require.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	_, err = provider.GetPrices(path, "ACME")
+	assert.ErrorContains(t, err, "Column value not found")
+}
+
+func TestGetPricesJSON(t *testing.T) {
+	path := write(t, "acme.json", `[{"date": "2023-01-01", "value": 120.50}, {"date": "2023-04-01", "value": "131"}]`)
+	provider := &PriceProvider{}
+
+	prices, err := provider.GetPrices(path, "ACME")
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "120.5", prices[0].Value.String())
+	assert.Equal(t, "131", prices[1].Value.String())
+
+	path = write(t, "invalid.json", `{"date": "2023-01-01"}`)
+	_, err = provider.GetPrices(path, "ACME")
+	assert.ErrorContains(t, err, "Expected a list of objects")
+}
+
+func TestEncodeCode(t *testing.T) {
+	assert.Equal(t, "prices/acme.csv", EncodeCode("prices/acme.csv", "date", "value", ""))
+	code := EncodeCode("prices/acme.csv", "Date", "Close", "02/01/2006")
+	assert.Equal(t, "prices/acme.csv?date=Date&format=02%2F01%2F2006&value=Close", code)
+
+	opts, err := decodeCode("/tmp/acme.csv?date=Date&format=02%2F01%2F2006&value=Close")
+	require.NoError(t, err)
+	assert.Equal(t, options{path: "/tmp/acme.csv", dateColumn: "Date", valueColumn: "Close", format: "02/01/2006"}, opts)
+}
+
+func TestRegistry(t *testing.T) {
+	provider, err := price.ProviderByCode("file")
+	require.NoError(t, err)
+	assert.Equal(t, "File", provider.Label())
+
+	_, err = price.ProviderByCode("missing")
+	assert.EqualError(t, err, "Unknown price provider: missing")
+}
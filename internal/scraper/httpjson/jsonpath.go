@@ -0,0 +1,157 @@
+package httpjson
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	wildcardSegment
+)
+
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+	// keys makes the wildcard return the object keys (or array
+	// indices) instead of the values, like the ~ operator of
+	// JSONPath-Plus.
+	keys bool
+}
+
+// jsonPath is a subset of JSONPath: $.a.b, $["a b"], $.a[0], $.a[-1],
+// $.a[*], $.a.* and $.a.*~ for the keys of an object.
+type jsonPath []segment
+
+func parseJSONPath(path string) (jsonPath, error) {
+	str := strings.TrimSpace(path)
+	if !strings.HasPrefix(str, "$") {
+		return nil, fmt.Errorf("Invalid JSONPath %s: should start with $", path)
+	}
+	str = str[1:]
+
+	var segments jsonPath
+	for len(str) > 0 {
+		var s segment
+		switch {
+		case strings.HasPrefix(str, ".*"):
+			s = segment{kind: wildcardSegment}
+			str = str[2:]
+		case strings.HasPrefix(str, "[*]"):
+			s = segment{kind: wildcardSegment}
+			str = str[3:]
+		case strings.HasPrefix(str, "[\"") || strings.HasPrefix(str, "['"):
+			quote := str[1:2]
+			end := strings.Index(str[2:], quote+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("Invalid JSONPath %s: unterminated key", path)
+			}
+			s = segment{kind: keySegment, key: str[2 : 2+end]}
+			str = str[2+end+2:]
+		case strings.HasPrefix(str, "["):
+			end := strings.Index(str, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("Invalid JSONPath %s: unterminated index", path)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(str[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid JSONPath %s: invalid index %s", path, str[1:end])
+			}
+			s = segment{kind: indexSegment, index: index}
+			str = str[end+1:]
+		case strings.HasPrefix(str, "."):
+			end := strings.IndexAny(str[1:], ".[~")
+			if end < 0 {
+				end = len(str) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("Invalid JSONPath %s: empty key", path)
+			}
+			s = segment{kind: keySegment, key: str[1 : end+1]}
+			str = str[end+1:]
+		default:
+			return nil, fmt.Errorf("Invalid JSONPath %s: unexpected %s", path, str)
+		}
+
+		if strings.HasPrefix(str, "~") {
+			if s.kind != wildcardSegment {
+				return nil, fmt.Errorf("Invalid JSONPath %s: ~ is only allowed after a wildcard", path)
+			}
+			s.keys = true
+			str = str[1:]
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// Select returns all the values matched by the path. Object keys are
+// visited in sorted order, so that two paths walking the same object
+// line up. A path that doesn't use a wildcard but ends on an array
+// returns the elements of the array.
+func (path jsonPath) Select(document any) []any {
+	nodes := []any{document}
+	wildcard := false
+	for _, s := range path {
+		var next []any
+		for _, node := range nodes {
+			switch s.kind {
+			case keySegment:
+				if object, ok := node.(map[string]any); ok {
+					if value, ok := object[s.key]; ok {
+						next = append(next, value)
+					}
+				}
+			case indexSegment:
+				if array, ok := node.([]any); ok {
+					index := s.index
+					if index < 0 {
+						index += len(array)
+					}
+					if index >= 0 && index < len(array) {
+						next = append(next, array[index])
+					}
+				}
+			case wildcardSegment:
+				wildcard = true
+				switch value := node.(type) {
+				case map[string]any:
+					keys := make([]string, 0, len(value))
+					for key := range value {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						if s.keys {
+							next = append(next, key)
+						} else {
+							next = append(next, value[key])
+						}
+					}
+				case []any:
+					for i, item := range value {
+						if s.keys {
+							next = append(next, i)
+						} else {
+							next = append(next, item)
+						}
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+
+	if !wildcard && len(nodes) == 1 {
+		if array, ok := nodes[0].([]any); ok {
+			return array
+		}
+	}
+	return nodes
+}
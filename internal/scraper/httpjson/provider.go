@@ -0,0 +1,303 @@
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/commodity"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/expr-lang/expr"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PriceProvider struct {
+}
+
+// envPrefix limits the environment variables that can be used in the
+// headers, so a price code can't be used to read arbitrary secrets
+const envPrefix = "PAISA_"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func init() {
+	price.RegisterProvider(&PriceProvider{}, 70)
+}
+
+func (p *PriceProvider) Code() string {
+	return "http-json"
+}
+
+func (p *PriceProvider) Label() string {
+	return "HTTP JSON"
+}
+
+func (p *PriceProvider) Description() string {
+	return "Fetches prices from any http endpoint that returns JSON."
+}
+
+func (p *PriceProvider) AutoCompleteFields() []price.AutoCompleteField {
+	return []price.AutoCompleteField{
+		{Label: "URL", ID: "url", Help: "URL template. <code>{{.Commodity}}</code> and <code>{{.Today.Format \"2006-01-02\"}}</code> are replaced with the commodity name and the current date.", InputType: "text"},
+		{Label: "Date", ID: "date", Help: "JSONPath like <code>$.data[*].date</code> or an expr expression like <code>map(data.data, .date)</code> that returns the list of dates.", InputType: "text"},
+		{Label: "Value", ID: "value", Help: "JSONPath like <code>$.data[*].close</code> or an expr expression like <code>map(data.data, .close / 10)</code> that returns the list of prices.", InputType: "text"},
+		{Label: "Date Format", ID: "format", Help: "Date format in <a href='https://pkg.go.dev/time#pkg-constants' target='_blank'>go layout</a>, like <code>2006-01-02</code>. Use <code>unix</code> for timestamps.", InputType: "text", Optional: true},
+		{Label: "Headers", ID: "headers", Help: "Separate multiple headers with <code>|</code>, like <code>Accept: application/json | Authorization: Bearer ${PAISA_TOKEN}</code>. Environment variables starting with <code>PAISA_</code> are expanded, except in the preview.", InputType: "text", Optional: true},
+		{Label: "Preview", ID: "preview", Help: "Fetches the prices with the above details. Select the result to use it."},
+	}
+}
+
+func (p *PriceProvider) AutoComplete(db *gorm.DB, field string, filter map[string]string) []price.AutoCompleteItem {
+	if field != "preview" {
+		return []price.AutoCompleteItem{}
+	}
+
+	headers := lo.Filter(lo.Map(strings.Split(filter["headers"], "|"), func(header string, _ int) string {
+		return strings.TrimSpace(header)
+	}), func(header string, _ int) bool { return header != "" })
+
+	source := Source{URL: filter["url"], Date: filter["date"], Value: filter["value"], Format: filter["format"], Headers: headers}
+	code := source.Encode()
+	prices, err := getPrices(code, "", false)
+	if err != nil {
+		return []price.AutoCompleteItem{{Label: "Error: " + err.Error(), ID: ""}}
+	}
+
+	if len(prices) == 0 {
+		return []price.AutoCompleteItem{{Label: "No prices found", ID: ""}}
+	}
+
+	last := prices[len(prices)-1]
+	return []price.AutoCompleteItem{{
+		Label: fmt.Sprintf("%d prices, latest %s on %s", len(prices), last.Value.String(), last.Date.Format("02 Jan 2006")),
+		ID:    code,
+	}}
+}
+
+func (p *PriceProvider) ClearCache(db *gorm.DB) {
+}
+
+// Source describes the endpoint. It is stored in the commodity price
+// code as an url encoded query string.
+type Source struct {
+	URL     string
+	Date    string
+	Value   string
+	Format  string
+	Headers []string
+}
+
+func (s Source) Encode() string {
+	query := url.Values{}
+	query.Set("url", s.URL)
+	query.Set("date", s.Date)
+	query.Set("value", s.Value)
+	if s.Format != "" {
+		query.Set("format", s.Format)
+	}
+	for _, header := range s.Headers {
+		query.Add("header", header)
+	}
+	return query.Encode()
+}
+
+func DecodeSource(code string) (Source, error) {
+	query, err := url.ParseQuery(code)
+	if err != nil {
+		return Source{}, fmt.Errorf("Invalid code: %w", err)
+	}
+
+	source := Source{
+		URL:     query.Get("url"),
+		Date:    query.Get("date"),
+		Value:   query.Get("value"),
+		Format:  query.Get("format"),
+		Headers: query["header"],
+	}
+
+	if source.URL == "" || source.Date == "" || source.Value == "" {
+		return Source{}, fmt.Errorf("Invalid code: url, date and value are required")
+	}
+	return source, nil
+}
+
+func (p *PriceProvider) GetPrices(code string, commodityName string) ([]*price.Price, error) {
+	return getPrices(code, commodityName, true)
+}
+
+func getPrices(code string, commodityName string, expandEnv bool) ([]*price.Price, error) {
+	source, err := DecodeSource(code)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := fetch(source, commodityName, expandEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	dates, err := evaluate(source.Date, document)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := evaluate(source.Value, document)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dates) != len(values) {
+		return nil, fmt.Errorf("Found %d dates but %d values", len(dates), len(values))
+	}
+
+	commodityType := commodity.FindByName(commodityName).Type
+	if commodityType == "" {
+		commodityType = config.Unknown
+	}
+
+	var prices []*price.Price
+	for i := range dates {
+		if dates[i] == nil || values[i] == nil {
+			continue
+		}
+
+		date, err := price.ParseDate(dates[i], source.Format)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := price.ParseValue(values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		prices = append(prices, &price.Price{Date: date, CommodityType: commodityType, CommodityID: code, CommodityName: commodityName, Value: value})
+	}
+
+	sort.SliceStable(prices, func(i, j int) bool { return prices[i].Date.Before(prices[j].Date) })
+	return prices, nil
+}
+
+func fetch(source Source, commodityName string, expandEnv bool) (any, error) {
+	tmpl, err := template.New("url").Parse(source.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid url template: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, struct {
+		Commodity string
+		Today     time.Time
+	}{Commodity: commodityName, Today: utils.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("Invalid url template: %w", err)
+	}
+
+	u, err := url.Parse(buffer.String())
+	if err != nil {
+		return nil, fmt.Errorf("Invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid url scheme %s, expected http or https", u.Scheme)
+	}
+
+	log.Infof("Fetching prices from %s", u.String())
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	for _, header := range source.Headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return nil, fmt.Errorf("Invalid header: %s", header)
+		}
+		if expandEnv {
+			value, err = expandHeader(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Unexpected status code: %d, body: %s", resp.StatusCode, string(respBytes))
+	}
+
+	var document any
+	err = json.Unmarshal(respBytes, &document)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// expandHeader replaces the environment variables in the header value,
+// only the variables with the PAISA_ prefix are allowed
+func expandHeader(value string) (string, error) {
+	var err error
+	expanded := os.Expand(value, func(name string) string {
+		if !strings.HasPrefix(name, envPrefix) {
+			if err == nil {
+				err = fmt.Errorf("Invalid header variable %s, only the variables starting with %s are expanded", name, envPrefix)
+			}
+			return ""
+		}
+		return os.Getenv(name)
+	})
+	return expanded, err
+}
+
+// evaluate runs the JSONPath, or the expr expression when it doesn't
+// start with $. The response is available as data inside expr.
+func evaluate(expression string, document any) ([]any, error) {
+	if strings.HasPrefix(strings.TrimSpace(expression), "$") {
+		path, err := parseJSONPath(expression)
+		if err != nil {
+			return nil, err
+		}
+		return path.Select(document), nil
+	}
+
+	env := map[string]any{"data": document}
+	program, err := expr.Compile(expression, expr.Env(env))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid expression %s: %w", expression, err)
+	}
+
+	result, err := expr.Run(program, env)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to evaluate %s: %w", expression, err)
+	}
+
+	list, ok := result.([]any)
+	if !ok {
+		return nil, fmt.Errorf("Expression %s should return a list, got %T", expression, result)
+	}
+	return list, nil
+}
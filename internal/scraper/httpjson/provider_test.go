@@ -0,0 +1,107 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath(t *testing.T) {
+	var document any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"data": [{"date": "2023-01-01", "close": 1}, {"date": "2023-01-02", "close": 2}],
+		"chart": {"timestamp": [1672531200, 1672617600]},
+		"Time Series": {"2023-01-02": {"4. close": "20"}, "2023-01-01": {"4. close": "10"}}
+	}`), &document))
+
+	tests := []struct {
+		path     string
+		expected []any
+	}{
+		{"$.data[*].date", []any{"2023-01-01", "2023-01-02"}},
+		{"$.data[-1].close", []any{2.0}},
+		{"$.chart.timestamp", []any{1672531200.0, 1672617600.0}},
+		{"$['Time Series'].*~", []any{"2023-01-01", "2023-01-02"}},
+		{`$["Time Series"].*["4. close"]`, []any{"10", "20"}},
+		{"$.missing[*]", nil},
+	}
+
+	for _, test := range tests {
+		path, err := parseJSONPath(test.path)
+		require.NoError(t, err, test.path)
+		assert.Equal(t, test.expected, path.Select(document), test.path)
+	}
+
+	for _, invalid := range []string{"data.date", "$.data[", "$.data~", "$..date"} {
+		_, err := parseJSONPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/prices/ACME", r.URL.Path)
+		w.Write([]byte(`{"prices": [{"t": 1672617600, "v": "1,210.5"}, {"t": 1672531200, "v": 1200}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("PAISA_PRICE_TOKEN", "secret")
+	source := Source{
+		URL:     server.URL + "/prices/{{.Commodity}}",
+		Date:    "$.prices[*].t",
+		Value:   "$.prices[*].v",
+		Format:  "unix",
+		Headers: []string{"Authorization: Bearer ${PAISA_PRICE_TOKEN}"},
+	}
+
+	provider := &PriceProvider{}
+	prices, err := provider.GetPrices(source.Encode(), "ACME")
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "1200", prices[0].Value.String())
+	assert.Equal(t, "1210.5", prices[1].Value.String())
+	assert.True(t, prices[0].Date.Before(prices[1].Date))
+	assert.Equal(t, source.Encode(), prices[0].CommodityID)
+
+	source.Headers = nil
+	_, err = provider.GetPrices(source.Encode(), "ACME")
+	assert.ErrorContains(t, err, "Unexpected status code: 401")
+
+	source.Headers = []string{"Authorization: Bearer ${PAISA_PRICE_TOKEN}"}
+	source.Value = "$.prices[0].v"
+	_, err = provider.GetPrices(source.Encode(), "ACME")
+	assert.EqualError(t, err, "Found 2 dates but 1 values")
+
+	t.Setenv("PRICE_TOKEN", "secret")
+	source.Headers = []string{"Authorization: Bearer ${PRICE_TOKEN}"}
+	_, err = provider.GetPrices(source.Encode(), "ACME")
+	assert.ErrorContains(t, err, "Invalid header variable PRICE_TOKEN")
+
+	source.Headers = []string{"Authorization: Bearer ${PAISA_PRICE_TOKEN}"}
+	source.Value = "$.prices[*].v"
+	items := provider.AutoComplete(nil, "preview", map[string]string{"url": source.URL, "date": source.Date, "value": source.Value, "format": source.Format, "headers": source.Headers[0]})
+	require.Len(t, items, 1)
+	assert.Contains(t, items[0].Label, "Unexpected status code: 401")
+
+	source.URL = "file:///etc/passwd"
+	_, err = provider.GetPrices(source.Encode(), "ACME")
+	assert.EqualError(t, err, "Invalid url scheme file, expected http or https")
+}
+
+func TestDecodeSource(t *testing.T) {
+	source := Source{URL: "https://example.com/?a=1&b=2", Date: "$.d", Value: "$.v", Headers: []string{"A: 1", "B: 2"}}
+	decoded, err := DecodeSource(source.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, source, decoded)
+
+	_, err = DecodeSource("url=https://example.com")
+	assert.Error(t, err)
+}
@@ -17,6 +17,10 @@ import (
 type PriceProvider struct {
 }
 
+func init() {
+	price.RegisterProvider(&PriceProvider{}, 50)
+}
+
 func (p *PriceProvider) Code() string {
 	return "com-purifiedbytes-metal"
 }
@@ -10,6 +10,10 @@ import (
 type PriceProvider struct {
 }
 
+func init() {
+	price.RegisterProvider(&PriceProvider{}, 20)
+}
+
 func (p *PriceProvider) Code() string {
 	return "in-mfapi"
 }
@@ -0,0 +1,14 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllProviders(t *testing.T) {
+	codes := lo.Map(GetAllProviders(), func(provider price.PriceProvider, _ int) string { return provider.Code() })
+	assert.Equal(t, []string{"com-yahoo", "in-mfapi", "co-alphavantage", "com-purifiedbytes-nps", "com-purifiedbytes-metal", "file", "http-json"}, codes)
+}
@@ -176,6 +176,10 @@ func searchTicker(apiKey, ticker string) (*SearchResponse, error) {
 type AlphaVantagePriceProvider struct {
 }
 
+func init() {
+	price.RegisterProvider(&AlphaVantagePriceProvider{}, 30)
+}
+
 func (p *AlphaVantagePriceProvider) Code() string {
 	return "co-alphavantage"
 }
@@ -159,6 +159,10 @@ func getTicker(ticker string) (*Response, error) {
 type YahooPriceProvider struct {
 }
 
+func init() {
+	price.RegisterProvider(&YahooPriceProvider{}, 10)
+}
+
 func (p *YahooPriceProvider) Code() string {
 	return "com-yahoo"
 }
@@ -36,7 +36,18 @@ type AllocationTarget struct {
 	Aggregates map[string]Aggregate `json:"aggregates"`
 }
 
+type AllocationReport struct {
+	Aggregates         map[string]Aggregate   `json:"aggregates"`
+	AggregatesTimeline []map[string]Aggregate `json:"aggregates_timeline"`
+	AllocationTargets  []AllocationTarget     `json:"allocation_targets"`
+}
+
 func GetAllocation(db *gorm.DB) gin.H {
+	report := allocationReport(db)
+	return gin.H{"aggregates": report.Aggregates, "aggregates_timeline": report.AggregatesTimeline, "allocation_targets": report.AllocationTargets}
+}
+
+func allocationReport(db *gorm.DB) AllocationReport {
 	postings := query.Init(db).Like("Assets:%").All()
 
 	now := utils.EndOfToday()
@@ -44,10 +55,11 @@ func GetAllocation(db *gorm.DB) gin.H {
 		p.MarketAmount = service.GetMarketPrice(db, p, now)
 		return p
 	})
-	aggregates := computeAggregate(db, postings, now)
-	aggregates_timeline := computeAggregateTimeline(db, postings)
-	allocation_targets := computeAllocationTargets(db, postings)
-	return gin.H{"aggregates": aggregates, "aggregates_timeline": aggregates_timeline, "allocation_targets": allocation_targets}
+	return AllocationReport{
+		Aggregates:         computeAggregate(db, postings, now),
+		AggregatesTimeline: computeAggregateTimeline(db, postings),
+		AllocationTargets:  computeAllocationTargets(db, postings),
+	}
 }
 
 func computeAggregateTimeline(db *gorm.DB, postings []posting.Posting) []map[string]Aggregate {
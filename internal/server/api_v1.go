@@ -0,0 +1,448 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/server/assets"
+	"github.com/ananthakumaran/paisa/internal/server/goal"
+	"github.com/ananthakumaran/paisa/internal/server/liabilities"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrorResponse is the body of all the failed /api/v1 requests
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type CurrenciesResponse struct {
+	Default    string   `json:"default"`
+	Currencies []string `json:"currencies"`
+}
+
+type BudgetAlertsResponse struct {
+	Alerts []BudgetAlert `json:"alerts"`
+}
+
+type RecurringResponse struct {
+	TransactionSequences []TransactionSequence `json:"transaction_sequences"`
+}
+
+type CreditCardsResponse struct {
+	CreditCards []CreditCardSummary `json:"credit_cards"`
+}
+
+type LoansResponse struct {
+	Loans []liabilities.Loan `json:"loans"`
+}
+
+type DiagnosisResponse struct {
+	Issues []Issue `json:"issues"`
+}
+
+type AssetsBalanceResponse struct {
+	AssetBreakdowns map[string]assets.AssetBreakdown `json:"asset_breakdowns"`
+}
+
+type GainsResponse struct {
+	Gains []Gain `json:"gains"`
+}
+
+type GoalsResponse struct {
+	Goals []goal.GoalSummary `json:"goals"`
+}
+
+type LiabilitiesBalanceResponse struct {
+	LiabilityBreakdowns map[string]liabilities.AssetBreakdown `json:"liability_breakdowns"`
+}
+
+type LiabilitiesInterestResponse struct {
+	Interests []liabilities.Interest `json:"interests"`
+}
+
+type LiabilitiesRepaymentResponse struct {
+	Repayments []posting.Posting `json:"repayments"`
+}
+
+type LedgerResponse struct {
+	Postings []posting.Posting `json:"postings"`
+}
+
+type ScheduleALResponse struct {
+	ScheduleALs map[string]ScheduleAL `json:"schedule_als"`
+}
+
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func badRequest(err error) *apiError {
+	return &apiError{status: http.StatusBadRequest, code: "bad_request", message: err.Error()}
+}
+
+func notFound(message string) *apiError {
+	return &apiError{status: http.StatusNotFound, code: "not_found", message: message}
+}
+
+func internalError(err error) *apiError {
+	return &apiError{status: http.StatusInternalServerError, code: "internal_error", message: err.Error()}
+}
+
+type apiParameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// apiRoute describes a /api/v1 endpoint. The OpenAPI document is
+// generated from the request and response types of the routes, so the
+// handlers can't return anything other than the declared types.
+type apiRoute struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Parameters  []apiParameter
+	Request     reflect.Type
+	Response    reflect.Type
+	Handler     gin.HandlerFunc
+	// Report is the name of the report the route could be exported as
+	Report string
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func respond[T any](c *gin.Context, response T, err *apiError) {
+	if err != nil {
+		c.JSON(err.status, ErrorResponse{Error: APIError{Code: err.code, Message: err.message}})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func apiGet[T any](path string, operationID string, summary string, parameters []apiParameter, handle func(c *gin.Context) (T, *apiError)) apiRoute {
+	return apiRoute{
+		Method:      http.MethodGet,
+		Path:        path,
+		OperationID: operationID,
+		Summary:     summary,
+		Parameters:  parameters,
+		Response:    typeOf[T](),
+		Handler: func(c *gin.Context) {
+			response, err := handle(c)
+			respond(c, response, err)
+		},
+	}
+}
+
+func apiPost[R any, T any](path string, operationID string, summary string, handle func(c *gin.Context, request R) (T, *apiError)) apiRoute {
+	return apiRoute{
+		Method:      http.MethodPost,
+		Path:        path,
+		OperationID: operationID,
+		Summary:     summary,
+		Request:     typeOf[R](),
+		Response:    typeOf[T](),
+		Handler: func(c *gin.Context) {
+			var request R
+			if err := c.ShouldBindJSON(&request); err != nil {
+				var response T
+				respond(c, response, badRequest(err))
+				return
+			}
+			response, err := handle(c, request)
+			respond(c, response, err)
+		},
+	}
+}
+
+func apiConverter(db *gorm.DB, c *gin.Context) (*service.Converter, *apiError) {
+	converter, err := service.NewConverter(db, c.Query("currency"))
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return converter, nil
+}
+
+var currencyParameter = apiParameter{Name: "currency", Description: "Reporting currency, defaults to the default currency"}
+
+func apiV1Routes(db *gorm.DB) []apiRoute {
+	return []apiRoute{
+		apiGet("/api/v1/currencies", "getCurrencies", "Default and the available reporting currencies", nil,
+			func(c *gin.Context) (CurrenciesResponse, *apiError) {
+				return CurrenciesResponse{Default: config.DefaultCurrency(), Currencies: service.ReportingCurrencies()}, nil
+			}),
+
+		exportable(db, ReportNetworth, apiGet("/api/v1/networth", "getNetworth", "Networth timeline", []apiParameter{currencyParameter},
+			func(c *gin.Context) (NetworthReport, *apiError) {
+				converter, err := apiConverter(db, c)
+				if err != nil {
+					return NetworthReport{}, err
+				}
+				return networthReport(db, converter), nil
+			})),
+
+		exportable(db, ReportExpense, apiGet("/api/v1/expense", "getExpense", "Expenses grouped by month and financial year", []apiParameter{currencyParameter},
+			func(c *gin.Context) (ExpenseReport, *apiError) {
+				converter, err := apiConverter(db, c)
+				if err != nil {
+					return ExpenseReport{}, err
+				}
+				return expenseReport(db, converter), nil
+			})),
+
+		apiGet("/api/v1/assets/balance", "getAssetsBalance", "Balance of each asset account along with its parents", nil,
+			func(c *gin.Context) (AssetsBalanceResponse, *apiError) {
+				return AssetsBalanceResponse{AssetBreakdowns: assets.Breakdowns(db)}, nil
+			}),
+
+		apiGet("/api/v1/investment", "getInvestment", "Investments and the savings rate of each financial year", nil,
+			func(c *gin.Context) (InvestmentReport, *apiError) {
+				return investmentReport(db), nil
+			}),
+
+		exportable(db, ReportGain, apiGet("/api/v1/gain", "getGains", "Gain and XIRR of each asset account", nil,
+			func(c *gin.Context) (GainsResponse, *apiError) {
+				return GainsResponse{Gains: computeGains(db)}, nil
+			})),
+
+		apiGet("/api/v1/gain/:account", "getGain", "Gain timeline, portfolio allocation and balance of the asset account", nil,
+			func(c *gin.Context) (AccountGainReport, *apiError) {
+				report := accountGainReport(db, c.Param("account"))
+				if len(report.Gain.Postings) == 0 {
+					return AccountGainReport{}, notFound(fmt.Sprintf("Account %s not found", c.Param("account")))
+				}
+				return report, nil
+			}),
+
+		apiGet("/api/v1/portfolio_allocation", "getPortfolioAllocation", "Allocation of the assets by the portfolio of the commodities", nil,
+			func(c *gin.Context) (PortfolioAllocationGroups, *apiError) {
+				return GetAccountPortfolioAllocation(db, "Assets"), nil
+			}),
+
+		exportable(db, ReportIncome, apiGet("/api/v1/income", "getIncome", "Income and tax timeline", []apiParameter{currencyParameter},
+			func(c *gin.Context) (IncomeReport, *apiError) {
+				converter, err := apiConverter(db, c)
+				if err != nil {
+					return IncomeReport{}, err
+				}
+				report, _ := incomeReport(db, converter)
+				return report, nil
+			})),
+
+		exportable(db, ReportCashFlow, apiGet("/api/v1/cash_flow", "getCashFlow", "Monthly cash flow", []apiParameter{currencyParameter},
+			func(c *gin.Context) (CashFlowReport, *apiError) {
+				converter, err := apiConverter(db, c)
+				if err != nil {
+					return CashFlowReport{}, err
+				}
+				return cashFlowReport(db, converter), nil
+			})),
+
+		exportable(db, ReportIncomeStatement, apiGet("/api/v1/income_statement", "getIncomeStatement", "Income statement of each financial year", []apiParameter{currencyParameter},
+			func(c *gin.Context) (IncomeStatementReport, *apiError) {
+				converter, err := apiConverter(db, c)
+				if err != nil {
+					return IncomeStatementReport{}, err
+				}
+				return incomeStatementReport(db, converter), nil
+			})),
+
+		exportable(db, ReportBudget, apiGet("/api/v1/budget", "getBudget", "Budget of each month", nil,
+			func(c *gin.Context) (BudgetReport, *apiError) {
+				return budgetReport(db), nil
+			})),
+
+		apiGet("/api/v1/budget/alerts", "getBudgetAlerts", "Accounts that are over the budget in the current month", nil,
+			func(c *gin.Context) (BudgetAlertsResponse, *apiError) {
+				return BudgetAlertsResponse{Alerts: budgetAlerts(db)}, nil
+			}),
+
+		exportable(db, ReportAllocation, apiGet("/api/v1/allocation", "getAllocation", "Asset allocation and the allocation targets", nil,
+			func(c *gin.Context) (AllocationReport, *apiError) {
+				return allocationReport(db), nil
+			})),
+
+		exportable(db, ReportCapitalGains, apiGet("/api/v1/capital_gains", "getCapitalGains", "Capital gains of each financial year", nil,
+			func(c *gin.Context) (CapitalGainsReport, *apiError) {
+				report, err := capitalGainsReport(db)
+				if err != nil {
+					return report, internalError(err)
+				}
+				return report, nil
+			})),
+
+		exportable(db, ReportLotSelection, apiGet("/api/v1/capital_gains/lot_selection", "getLotSelectionComparison", "Tax of each financial year with each of the lot selections", nil,
+			func(c *gin.Context) (LotSelectionReport, *apiError) {
+				report, err := lotSelectionReport(db)
+				if err != nil {
+					return report, internalError(err)
+				}
+				return report, nil
+			})),
+
+		exportable(db, ReportHarvest, apiGet("/api/v1/harvest", "getHarvest", "Harvestable gains of each account and the plan to use up the exemption", nil,
+			func(c *gin.Context) (HarvestReport, *apiError) {
+				report, err := harvestReport(db)
+				if err != nil {
+					return report, internalError(err)
+				}
+				return report, nil
+			})),
+
+		apiGet("/api/v1/schedule_al", "getScheduleAL", "Schedule AL as of the end of each financial year", nil,
+			func(c *gin.Context) (ScheduleALResponse, *apiError) {
+				return ScheduleALResponse{ScheduleALs: computeScheduleALs(db)}, nil
+			}),
+
+		apiGet("/api/v1/goals", "getGoals", "Summary of the retirement and savings goals", nil,
+			func(c *gin.Context) (GoalsResponse, *apiError) {
+				return GoalsResponse{Goals: goal.GetGoalSummaries(db)}, nil
+			}),
+
+		exportable(db, ReportRecurring, apiGet("/api/v1/recurring", "getRecurring", "Recurring transactions", nil,
+			func(c *gin.Context) (RecurringResponse, *apiError) {
+				return RecurringResponse{TransactionSequences: ComputeRecurringTransactions(query.Init(db).All())}, nil
+			})),
+
+		exportable(db, ReportCreditCards, apiGet("/api/v1/credit_cards", "getCreditCards", "Credit cards with the bills", nil,
+			func(c *gin.Context) (CreditCardsResponse, *apiError) {
+				return CreditCardsResponse{CreditCards: creditCards(db)}, nil
+			})),
+
+		apiGet("/api/v1/credit_cards/:account", "getCreditCard", "Credit card with the bills and the transactions", nil,
+			func(c *gin.Context) (CreditCardSummary, *apiError) {
+				creditCard, found := findCreditCard(db, c.Param("account"))
+				if !found {
+					return CreditCardSummary{}, notFound(fmt.Sprintf("Credit card %s not found", c.Param("account")))
+				}
+				return creditCard, nil
+			}),
+
+		exportable(db, ReportLiabilitiesBalance, apiGet("/api/v1/liabilities/balance", "getLiabilitiesBalance", "Balance of each liability account", nil,
+			func(c *gin.Context) (LiabilitiesBalanceResponse, *apiError) {
+				return LiabilitiesBalanceResponse{LiabilityBreakdowns: liabilities.Breakdowns(db)}, nil
+			})),
+
+		exportable(db, ReportLiabilitiesInterest, apiGet("/api/v1/liabilities/interest", "getLiabilitiesInterest", "Interest timeline and APR of each liability account", nil,
+			func(c *gin.Context) (LiabilitiesInterestResponse, *apiError) {
+				return LiabilitiesInterestResponse{Interests: liabilities.Interests(db)}, nil
+			})),
+
+		exportable(db, ReportLiabilitiesRepayment, apiGet("/api/v1/liabilities/repayment", "getLiabilitiesRepayment", "Repayments of the liabilities along with the interest expenses", nil,
+			func(c *gin.Context) (LiabilitiesRepaymentResponse, *apiError) {
+				return LiabilitiesRepaymentResponse{Repayments: liabilities.Repayments(db)}, nil
+			})),
+
+		exportable(db, ReportLoans, apiGet("/api/v1/liabilities/loans", "getLoans", "Amortization schedule of the loans", nil,
+			func(c *gin.Context) (LoansResponse, *apiError) {
+				loans, err := liabilities.BuildLoans(db)
+				if err != nil {
+					return LoansResponse{}, internalError(err)
+				}
+				return LoansResponse{Loans: loans}, nil
+			})),
+
+		apiGet("/api/v1/liabilities/loans/:account", "getLoan", "Amortization schedule of the loan", []apiParameter{{Name: "date", Description: "Date of the outstanding principal in YYYY-MM-DD format, defaults to today"}},
+			func(c *gin.Context) (liabilities.Loan, *apiError) {
+				loan, found, err := liabilities.FindLoan(db, c.Param("account"), c.Query("date"))
+				if !found {
+					return liabilities.Loan{}, notFound(fmt.Sprintf("Loan %s not found", c.Param("account")))
+				}
+				if err != nil {
+					return liabilities.Loan{}, badRequest(err)
+				}
+				return loan, nil
+			}),
+
+		apiPost("/api/v1/liabilities/loans/:account/prepayment", "simulatePrepayment", "Simulate a prepayment of the loan",
+			func(c *gin.Context, request liabilities.PrepaymentRequest) (liabilities.PrepaymentSimulation, *apiError) {
+				simulation, found, err := liabilities.Simulate(c.Param("account"), request)
+				if !found {
+					return liabilities.PrepaymentSimulation{}, notFound(fmt.Sprintf("Loan %s not found", c.Param("account")))
+				}
+				if err != nil {
+					return liabilities.PrepaymentSimulation{}, badRequest(err)
+				}
+				return simulation, nil
+			}),
+
+		apiGet("/api/v1/reconcile", "getReconciliation", "Uncleared postings of the account till the statement date",
+			[]apiParameter{
+				{Name: "account", Description: "Account to reconcile", Required: true},
+				{Name: "date", Description: "Statement end date in YYYY-MM-DD format", Required: true},
+				{Name: "balance", Description: "Closing balance as per the statement", Required: true},
+				{Name: "commodity", Description: "Commodity of the balance, defaults to the default currency"},
+			},
+			func(c *gin.Context) (Reconciliation, *apiError) {
+				balance, err := decimal.NewFromString(c.Query("balance"))
+				if err != nil {
+					return Reconciliation{}, badRequest(errors.New("Invalid balance"))
+				}
+				reconciliation, err := GetReconciliation(db, ReconcileRequest{Account: c.Query("account"), Date: c.Query("date"), Balance: balance, Commodity: c.Query("commodity")})
+				if err != nil {
+					return Reconciliation{}, badRequest(err)
+				}
+				return reconciliation, nil
+			}),
+
+		apiGet("/api/v1/query", "runQuery", "Postings matching the filter expression with the totals and the grouped register",
+			[]apiParameter{
+				{Name: "q", Description: "Filter expression like acct:Expenses date:2024-01.. payee:/swiggy/, matches all the postings if empty"},
+				{Name: "group", Description: "Group the register by day, month, year, account or payee, defaults to month"},
+			},
+			func(c *gin.Context) (QueryResult, *apiError) {
+				result, err := RunQuery(db, c.Query("q"), c.Query("group"))
+				if err != nil {
+					return QueryResult{}, badRequest(err)
+				}
+				return result, nil
+			}),
+
+		apiGet("/api/v1/ledger", "getLedger", "All the postings with the running balance, latest first", nil,
+			func(c *gin.Context) (LedgerResponse, *apiError) {
+				return LedgerResponse{Postings: ledgerPostings(db)}, nil
+			}),
+
+		apiGet("/api/v1/diagnosis", "getDiagnosis", "Issues found in the journal", nil,
+			func(c *gin.Context) (DiagnosisResponse, *apiError) {
+				return DiagnosisResponse{Issues: Diagnose(db)}, nil
+			}),
+	}
+}
+
+// buildAPIV1 registers the /api/v1 routes along with the OpenAPI
+// document generated from them
+func buildAPIV1(router *gin.Engine, db *gorm.DB) {
+	routes := apiV1Routes(db)
+	for _, route := range routes {
+		router.Handle(route.Method, route.Path, route.Handler)
+	}
+
+	spec, err := json.Marshal(buildOpenAPI(routes))
+	if err != nil {
+		log.Fatal(err)
+	}
+	router.GET("/api/v1/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openAPITestDB(t *testing.T) *gorm.DB {
+	// same as the main, the spec documents the decimals as numbers
+	decimal.MarshalJSONWithoutQuotes = true
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+credit_cards:
+  - account: Liabilities:CreditCard:Freedom
+    credit_limit: 100000
+    statement_end_day: 8
+    due_day: 20
+    network: visa
+    number: "0007"
+    expiration_date: "2029-05-01"
+loans:
+  - account: Liabilities:HomeLoan
+    principal: 1000000
+    rate: 12
+    start_date: "2023-01-05"
+    tenure: 12
+`), "/tmp/paisa.yaml"))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	model.AutoMigrate(db)
+
+	transactions := []struct {
+		date    string
+		payee   string
+		account string
+		amount  int64
+	}{
+		{"2023-01-05", "Home Loan", "Liabilities:HomeLoan", -1000000},
+		{"2023-01-10", "Salary", "Income:Salary", -100000},
+		{"2023-01-12", "Groceries", "Expenses:Food", 2000},
+		{"2023-02-05", "EMI", "Liabilities:HomeLoan", 88848},
+		{"2023-02-06", "Card Spend", "Expenses:Shopping", 5000},
+	}
+	for i, txn := range transactions {
+		d := parseDate(txn.date)
+		id := fmt.Sprintf("t%d", i)
+		contra := "Assets:Checking"
+		if txn.payee == "Card Spend" {
+			contra = "Liabilities:CreditCard:Freedom"
+		}
+		for _, p := range []posting.Posting{
+			{TransactionID: id, Date: d, Payee: txn.payee, Account: txn.account, Commodity: "INR", Quantity: decimal.NewFromInt(txn.amount), Amount: decimal.NewFromInt(txn.amount), Status: "unmarked", FileName: "main.ledger"},
+			{TransactionID: id, Date: d, Payee: txn.payee, Account: contra, Commodity: "INR", Quantity: decimal.NewFromInt(-txn.amount), Amount: decimal.NewFromInt(-txn.amount), Status: "unmarked", FileName: "main.ledger"},
+		} {
+			require.NoError(t, db.Create(&p).Error)
+		}
+	}
+	return db
+}
+
+func compileOpenAPI(t *testing.T, spec []byte) *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	require.NoError(t, compiler.AddResource("openapi.json", bytes.NewReader(spec)))
+	return compiler
+}
+
+func responseSchema(t *testing.T, compiler *jsonschema.Compiler, spec map[string]any, path string, method string, status string) *jsonschema.Schema {
+	operation := spec["paths"].(map[string]any)[path].(map[string]any)[method].(map[string]any)
+	require.Contains(t, operation["responses"], status)
+	pointer := fmt.Sprintf("openapi.json#/paths/%s/%s/responses/%s/content/application~1json/schema",
+		strings.ReplaceAll(strings.ReplaceAll(path, "~", "~0"), "/", "~1"), method, status)
+	return compiler.MustCompile(pointer)
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	db := openAPITestDB(t)
+	router := Build(db, false)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	assert.Equal(t, "3.1.0", spec["openapi"])
+
+	documented := []string{}
+	for path, item := range spec["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	registered := []string{}
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/api/v1/") && route.Path != "/api/v1/openapi.json" {
+			registered = append(registered, route.Method+" "+openAPIPath(route.Path))
+		}
+	}
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+	assert.Equal(t, registered, documented)
+
+	// all the references should resolve
+	compiler := compileOpenAPI(t, recorder.Body.Bytes())
+	for name := range spec["components"].(map[string]any)["schemas"].(map[string]any) {
+		_, err := compiler.Compile("openapi.json#/components/schemas/" + name)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	db := openAPITestDB(t)
+	router := Build(db, false)
+	routes := apiV1Routes(db)
+
+	spec, err := json.Marshal(buildOpenAPI(routes))
+	require.NoError(t, err)
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(spec, &document))
+	compiler := compileOpenAPI(t, spec)
+
+	paths := map[string]string{
+		"/api/v1/credit_cards/:account":                 "/api/v1/credit_cards/" + url.PathEscape("Liabilities:CreditCard:Freedom"),
+		"/api/v1/gain/:account":                         "/api/v1/gain/Assets:Checking",
+		"/api/v1/liabilities/loans/:account":            "/api/v1/liabilities/loans/Liabilities:HomeLoan?date=2023-03-01",
+		"/api/v1/liabilities/loans/:account/prepayment": "/api/v1/liabilities/loans/Liabilities:HomeLoan/prepayment",
+		"/api/v1/reconcile":                             "/api/v1/reconcile?account=Assets:Checking&date=2023-01-31&balance=1000",
+		"/api/v1/query":                                 "/api/v1/query?q=acct:Expenses+date:2023-01..&group=account",
+	}
+	bodies := map[string]string{
+		"/api/v1/liabilities/loans/:account/prepayment": `{"date": "2023-03-01", "amount": 100000, "mode": "reduce_tenure"}`,
+	}
+
+	for _, route := range routes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			target, ok := paths[route.Path]
+			if !ok {
+				target = route.Path
+			}
+
+			request := httptest.NewRequest(route.Method, target, strings.NewReader(bodies[route.Path]))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+			var body any
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			schema := responseSchema(t, compiler, document, openAPIPath(route.Path), strings.ToLower(route.Method), "200")
+			assert.NoError(t, schema.Validate(body))
+		})
+	}
+}
+
+func TestAPIV1Errors(t *testing.T) {
+	db := openAPITestDB(t)
+	router := Build(db, false)
+
+	spec, err := json.Marshal(buildOpenAPI(apiV1Routes(db)))
+	require.NoError(t, err)
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(spec, &document))
+	compiler := compileOpenAPI(t, spec)
+
+	cases := []struct {
+		method string
+		path   string
+		target string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/api/v1/networth", "/api/v1/networth?currency=XYZ", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/credit_cards/:account", "/api/v1/credit_cards/Liabilities:CreditCard:Unknown", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/gain/:account", "/api/v1/gain/Assets:Unknown", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/reconcile", "/api/v1/reconcile?account=Assets:Checking&date=2023-01-31&balance=abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/query", "/api/v1/query?q=date:2023-13", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/expense", "/api/v1/expense?format=pdf", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/budget", "/api/v1/budget?format=csv&table=unknown", "", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/liabilities/loans/:account/prepayment", "/api/v1/liabilities/loans/Liabilities:HomeLoan/prepayment", "{", http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, c.status, recorder.Code, c.target)
+
+		var body any
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		schema := responseSchema(t, compiler, document, openAPIPath(c.path), strings.ToLower(c.method), "default")
+		assert.NoError(t, schema.Validate(body), c.target)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"not_found"`)
+}
@@ -36,10 +36,19 @@ func GetBalance(db *gorm.DB) gin.H {
 }
 
 func doGetBalance(db *gorm.DB, pattern string, rollup bool) gin.H {
+	return gin.H{"asset_breakdowns": breakdowns(db, pattern, rollup)}
+}
+
+// Breakdowns returns the balance of each asset account along with its
+// parents
+func Breakdowns(db *gorm.DB) map[string]AssetBreakdown {
+	return breakdowns(db, "Assets:%", true)
+}
+
+func breakdowns(db *gorm.DB, pattern string, rollup bool) map[string]AssetBreakdown {
 	postings := query.Init(db).Like(pattern, "Income:CapitalGains:%").All()
 	postings = service.PopulateMarketPrice(db, postings)
-	breakdowns := ComputeBreakdowns(db, postings, rollup)
-	return gin.H{"asset_breakdowns": breakdowns}
+	return ComputeBreakdowns(db, postings, rollup)
 }
 
 func ComputeBreakdowns(db *gorm.DB, postings []posting.Posting, rollup bool) map[string]AssetBreakdown {
@@ -1,13 +1,16 @@
 package server
 
 import (
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/ananthakumaran/paisa/internal/accounting"
 	"github.com/ananthakumaran/paisa/internal/config"
 	"github.com/ananthakumaran/paisa/internal/model/posting"
 	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/ananthakumaran/paisa/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/lo"
@@ -17,6 +20,7 @@ import (
 
 type AccountBudget struct {
 	Account   string            `json:"account"`
+	Envelope  string            `json:"envelope"`
 	Forecast  decimal.Decimal   `json:"forecast"`
 	Actual    decimal.Decimal   `json:"actual"`
 	Rollover  decimal.Decimal   `json:"rollover"`
@@ -25,29 +29,229 @@ type AccountBudget struct {
 	Expenses  []posting.Posting `json:"expenses"`
 }
 
+type EnvelopeBudget struct {
+	Name      string          `json:"name"`
+	Forecast  decimal.Decimal `json:"forecast"`
+	Actual    decimal.Decimal `json:"actual"`
+	Available decimal.Decimal `json:"available"`
+}
+
 type Budget struct {
-	Date               time.Time       `json:"date"`
-	Accounts           []AccountBudget `json:"accounts"`
-	AvailableThisMonth decimal.Decimal `json:"availableThisMonth"`
-	EndOfMonthBalance  decimal.Decimal `json:"endOfMonthBalance"`
-	Forecast           decimal.Decimal `json:"forecast"`
+	Date               time.Time        `json:"date"`
+	Accounts           []AccountBudget  `json:"accounts"`
+	Envelopes          []EnvelopeBudget `json:"envelopes"`
+	AvailableThisMonth decimal.Decimal  `json:"availableThisMonth"`
+	EndOfMonthBalance  decimal.Decimal  `json:"endOfMonthBalance"`
+	Forecast           decimal.Decimal  `json:"forecast"`
+}
+
+type BudgetAlertStatus string
+
+const (
+	Overspent          BudgetAlertStatus = "overspent"
+	ProjectedOverspent BudgetAlertStatus = "projected_overspent"
+)
+
+type BudgetAlert struct {
+	Account   string            `json:"account"`
+	Envelope  string            `json:"envelope"`
+	Budget    decimal.Decimal   `json:"budget"`
+	Actual    decimal.Decimal   `json:"actual"`
+	Projected decimal.Decimal   `json:"projected"`
+	Excess    decimal.Decimal   `json:"excess"`
+	Status    BudgetAlertStatus `json:"status"`
+}
+
+type BudgetReport struct {
+	BudgetsByMonth        map[string]Budget `json:"budgets_by_month"`
+	CheckingBalance       decimal.Decimal   `json:"checking_balance"`
+	AvailableForBudgeting decimal.Decimal   `json:"available_for_budgeting"`
 }
 
 func GetBudget(db *gorm.DB) gin.H {
+	report := budgetReport(db)
+	return gin.H{
+		"budgetsByMonth":        report.BudgetsByMonth,
+		"checkingBalance":       report.CheckingBalance,
+		"availableForBudgeting": report.AvailableForBudgeting,
+	}
+}
+
+func budgetReport(db *gorm.DB) BudgetReport {
 	forecastPostings := query.Init(db).Like("Expenses:%").Forecast().All()
 	expenses := query.Init(db).Like("Expenses:%").All()
-	return computeBudet(db, forecastPostings, expenses)
+	end := time.Date(utils.Now().Year(), 12, 31, 0, 0, 0, 0, config.TimeZone())
+	forecastPostings = accounting.SortAsc(append(forecastPostings, configBudgetPostings(db, end)...))
+	budgetsByMonth, checkingBalance, availableForBudgeting := computeBudgetsByMonth(db, forecastPostings, expenses)
+	return BudgetReport{BudgetsByMonth: budgetsByMonth, CheckingBalance: checkingBalance, AvailableForBudgeting: availableForBudgeting}
 }
 
 func GetCurrentBudget(db *gorm.DB) gin.H {
+	forecastPostings, expenses := currentBudgetPostings(db)
+	return computeBudet(db, forecastPostings, expenses)
+}
+
+func currentBudgetPostings(db *gorm.DB) ([]posting.Posting, []posting.Posting) {
 	forecastPostings := query.Init(db).Like("Expenses:%").Forecast().UntilThisMonthEnd().All()
 	expenses := query.Init(db).Like("Expenses:%").UntilThisMonthEnd().All()
-	return computeBudet(db, forecastPostings, expenses)
+	forecastPostings = accounting.SortAsc(append(forecastPostings, configBudgetPostings(db, utils.EndOfMonth(utils.Now()))...))
+	return forecastPostings, expenses
+}
+
+// GetBudgetAlerts lists the accounts of the current month that are
+// already over the budget or will be by the month end if the spending
+// continues at the same pace.
+func GetBudgetAlerts(db *gorm.DB) gin.H {
+	return gin.H{"alerts": budgetAlerts(db)}
+}
+
+func budgetAlerts(db *gorm.DB) []BudgetAlert {
+	forecastPostings, expenses := currentBudgetPostings(db)
+	budgetsByMonth, _, _ := computeBudgetsByMonth(db, forecastPostings, expenses)
+
+	now := utils.Now()
+	alerts := []BudgetAlert{}
+	budget, ok := budgetsByMonth[now.Format("2006-01")]
+	if !ok {
+		return alerts
+	}
+
+	for _, ab := range budget.Accounts {
+		total := ab.Available.Add(ab.Actual)
+		projected := projectSpend(ab.Actual, ab.Expenses, now)
+
+		alert := BudgetAlert{Account: ab.Account, Envelope: ab.Envelope, Budget: total, Actual: ab.Actual, Projected: projected}
+		if ab.Actual.GreaterThan(total) {
+			alert.Status = Overspent
+			alert.Excess = ab.Actual.Sub(total)
+		} else if projected.GreaterThan(total) {
+			alert.Status = ProjectedOverspent
+			alert.Excess = projected.Sub(total)
+		} else {
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].Excess.GreaterThan(alerts[j].Excess) })
+	return alerts
+}
+
+// the spending is projected only after this many days of the month
+const budgetProjectionMinDays = 7
+
+// projectSpend projects the spending of the month at the same pace till
+// the month end. Only the spending spread across multiple days accrues
+// over the month, a one off payment like rent is not projected.
+func projectSpend(actual decimal.Decimal, expenses []posting.Posting, now time.Time) decimal.Decimal {
+	days := lo.Uniq(lo.Map(expenses, func(p posting.Posting, _ int) string { return p.Date.Format("2006-01-02") }))
+	if now.Day() < budgetProjectionMinDays || len(days) < 2 {
+		return actual
+	}
+
+	elapsed := decimal.NewFromInt(int64(now.Day()))
+	total := decimal.NewFromInt(int64(utils.EndOfMonth(now).Day()))
+	return actual.Div(elapsed).Mul(total).Round(2)
+}
+
+// configBudgetPostings converts the budgets in the config to monthly
+// forecast postings, till the given date. The yearly and quarterly
+// budgets are spread evenly across the months. The months after the
+// last income use the latest available average income.
+func configBudgetPostings(db *gorm.DB, until time.Time) []posting.Posting {
+	envelopes := config.GetConfig().Budget.Envelopes
+	if len(envelopes) == 0 {
+		return nil
+	}
+
+	now := utils.Now()
+	start := time.Date(now.Year()-3, 1, 1, 0, 0, 0, 0, config.TimeZone())
+	if first := query.Init(db).Like("Expenses:%").First(); first != nil && first.Date.After(start) {
+		start = utils.BeginningOfMonth(first.Date)
+	}
+
+	incomes := utils.GroupByMonth(lo.Filter(query.Init(db).Like("Income:%").All(), func(p posting.Posting, _ int) bool {
+		return !service.IsCapitalGains(p)
+	}))
+	latest := lo.Max(lo.Keys(incomes))
+	averageIncome := func(month time.Time) decimal.Decimal {
+		if latest != "" {
+			if next := lo.Must(time.ParseInLocation("2006-01", latest, config.TimeZone())).AddDate(0, 1, 0); month.After(next) {
+				month = next
+			}
+		}
+
+		total := decimal.Zero
+		for i := 1; i <= 3; i++ {
+			total = total.Add(accounting.CostSum(incomes[month.AddDate(0, -i, 0).Format("2006-01")]).Neg())
+		}
+		return total.Div(decimal.NewFromInt(3))
+	}
+
+	var postings []posting.Posting
+	for month := start; !month.After(until); month = month.AddDate(0, 1, 0) {
+		for _, envelope := range envelopes {
+			for _, b := range envelope.Accounts {
+				var amount decimal.Decimal
+				if b.Percentage > 0 {
+					amount = averageIncome(month).Mul(decimal.NewFromFloat(b.Percentage)).Div(decimal.NewFromInt(100))
+					if b.Cap > 0 {
+						amount = decimal.Min(amount, decimal.NewFromFloat(b.Cap))
+					}
+				} else {
+					amount = decimal.NewFromFloat(b.Amount).Div(decimal.NewFromInt(int64(budgetPeriodMonths(b.Period))))
+				}
+				amount = amount.Round(2)
+
+				postings = append(postings, posting.Posting{
+					Date:      month,
+					Payee:     envelope.Name,
+					Account:   b.Account,
+					Commodity: config.DefaultCurrency(),
+					Quantity:  amount,
+					Amount:    amount,
+					Forecast:  true,
+				})
+			}
+		}
+	}
+	return postings
+}
+
+func budgetPeriodMonths(period config.BudgetPeriod) int {
+	switch period {
+	case config.Quarterly:
+		return 3
+	case config.Yearly:
+		return 12
+	default:
+		return 1
+	}
+}
+
+func envelopeByAccount() map[string]string {
+	envelopes := make(map[string]string)
+	for _, envelope := range config.GetConfig().Budget.Envelopes {
+		for _, b := range envelope.Accounts {
+			envelopes[b.Account] = envelope.Name
+		}
+	}
+	return envelopes
 }
 
 func computeBudet(db *gorm.DB, forecastPostings, expensesPostings []posting.Posting) gin.H {
+	budgetsByMonth, checkingBalance, availableForBudgeting := computeBudgetsByMonth(db, forecastPostings, expensesPostings)
+	return gin.H{
+		"budgetsByMonth":        budgetsByMonth,
+		"checkingBalance":       checkingBalance,
+		"availableForBudgeting": availableForBudgeting,
+	}
+}
+
+func computeBudgetsByMonth(db *gorm.DB, forecastPostings, expensesPostings []posting.Posting) (map[string]Budget, decimal.Decimal, decimal.Decimal) {
 	checkingBalance := accounting.CostSum(query.Init(db).AccountPrefix("Assets:Checking").All())
 	availableForBudgeting := checkingBalance
+	envelopes := envelopeByAccount()
 
 	forecasts := utils.GroupByMonth(forecastPostings)
 	expenses := utils.GroupByMonth(expensesPostings)
@@ -88,6 +292,7 @@ func computeBudet(db *gorm.DB, forecastPostings, expensesPostings []posting.Post
 				}
 
 				budget := buildBudget(date, account, balance[account], fs, es, date.Before(currentMonth))
+				budget.Envelope = envelopes[account]
 				if budget.Available.IsPositive() {
 					balance[account] = budget.Available
 				} else {
@@ -119,6 +324,7 @@ func computeBudet(db *gorm.DB, forecastPostings, expensesPostings []posting.Post
 			budgetsByMonth[month] = Budget{
 				Date:               date,
 				Accounts:           accountBudgets,
+				Envelopes:          buildEnvelopes(accountBudgets),
 				EndOfMonthBalance:  endOfMonthBalance,
 				AvailableThisMonth: availableThisMonth,
 				Forecast:           forecast,
@@ -126,11 +332,21 @@ func computeBudet(db *gorm.DB, forecastPostings, expensesPostings []posting.Post
 		}
 	}
 
-	return gin.H{
-		"budgetsByMonth":        budgetsByMonth,
-		"checkingBalance":       checkingBalance,
-		"availableForBudgeting": availableForBudgeting,
+	return budgetsByMonth, checkingBalance, availableForBudgeting
+}
+
+func buildEnvelopes(accountBudgets []AccountBudget) []EnvelopeBudget {
+	envelopes := []EnvelopeBudget{}
+	for _, name := range lo.Uniq(lo.FilterMap(accountBudgets, func(ab AccountBudget, _ int) (string, bool) { return ab.Envelope, ab.Envelope != "" })) {
+		abs := lo.Filter(accountBudgets, func(ab AccountBudget, _ int) bool { return ab.Envelope == name })
+		envelopes = append(envelopes, EnvelopeBudget{
+			Name:      name,
+			Forecast:  utils.SumBy(abs, func(ab AccountBudget) decimal.Decimal { return ab.Forecast }),
+			Actual:    utils.SumBy(abs, func(ab AccountBudget) decimal.Decimal { return ab.Actual }),
+			Available: utils.SumBy(abs, func(ab AccountBudget) decimal.Decimal { return ab.Available }),
+		})
 	}
+	return envelopes
 }
 
 func buildBudget(date time.Time, account string, balance decimal.Decimal, forecasts []posting.Posting, expenses []posting.Posting, past bool) AccountBudget {
@@ -161,7 +377,7 @@ func buildBudget(date time.Time, account string, balance decimal.Decimal, foreca
 func popExpenses(forecastAccount string, expensesByAccount map[string][]posting.Posting) []posting.Posting {
 	expenses := []posting.Posting{}
 	for account, es := range expensesByAccount {
-		if utils.IsSameOrParent(account, forecastAccount) {
+		if matchBudgetAccount(account, forecastAccount) {
 			expenses = append(expenses, es...)
 			delete(expensesByAccount, account)
 		}
@@ -169,3 +385,13 @@ func popExpenses(forecastAccount string, expensesByAccount map[string][]posting.
 	return expenses
 
 }
+
+// matchBudgetAccount checks if the expense account is covered by the
+// budget account, which can be a parent account or a glob.
+func matchBudgetAccount(account string, budgetAccount string) bool {
+	if strings.ContainsAny(budgetAccount, "*?[") {
+		match, err := filepath.Match(budgetAccount, account)
+		return err == nil && match
+	}
+	return utils.IsSameOrParent(account, budgetAccount)
+}
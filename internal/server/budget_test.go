@@ -0,0 +1,134 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openBudgetDB(t *testing.T) (*gorm.DB, time.Time) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+budget:
+  rollover: no
+  envelopes:
+    - name: Essentials
+      accounts:
+        - account: Expenses:Food:*
+          amount: 10000
+        - account: Expenses:Insurance
+          period: yearly
+          amount: 24000
+        - account: Expenses:Maintenance
+          period: quarterly
+          amount: 3000
+    - name: Fun
+      accounts:
+        - account: Expenses:Entertainment
+          percentage: 10
+          cap: 5000
+        - account: Expenses:Travel
+          percentage: 5
+`), "/tmp/paisa.yaml"))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	model.AutoMigrate(db)
+
+	month := utils.BeginningOfMonth(utils.Now())
+	create := func(date time.Time, account string, amount int64) {
+		require.NoError(t, db.Create(&posting.Posting{TransactionID: date.String() + account, Date: date, Payee: account, Account: account, Commodity: "INR", Quantity: decimal.NewFromInt(amount), Amount: decimal.NewFromInt(amount), FileName: "main.ledger"}).Error)
+	}
+	for i, amount := range []int64{30000, 60000, 90000} {
+		create(month.AddDate(0, i-3, 0), "Income:Salary", -amount)
+	}
+	create(month.AddDate(0, -3, 0), "Expenses:Food:Groceries", 2000)
+	create(month, "Expenses:Food:Groceries", 3000)
+	create(month, "Expenses:Food:Dining", 1500)
+	create(month, "Expenses:Food", 700)
+	return db, month
+}
+
+func TestConfigBudgetPostings(t *testing.T) {
+	db, month := openBudgetDB(t)
+	postings := configBudgetPostings(db, month.AddDate(0, 2, 0))
+
+	amount := func(date time.Time, account string) string {
+		p, ok := lo.Find(postings, func(p posting.Posting) bool { return p.Date.Equal(date) && p.Account == account })
+		require.True(t, ok, account)
+		assert.True(t, p.Forecast)
+		return p.Amount.String()
+	}
+
+	assert.Len(t, postings, 6*5)
+	assert.True(t, postings[0].Date.Equal(month.AddDate(0, -3, 0)))
+	assert.Equal(t, "10000", amount(month, "Expenses:Food:*"))
+	assert.Equal(t, "2000", amount(month, "Expenses:Insurance"))
+	assert.Equal(t, "1000", amount(month, "Expenses:Maintenance"))
+
+	// average income of the previous 3 months is 60000
+	assert.Equal(t, "5000", amount(month, "Expenses:Entertainment"))
+	assert.Equal(t, "3000", amount(month, "Expenses:Travel"))
+	assert.Equal(t, "1500", amount(month.AddDate(0, -1, 0), "Expenses:Travel"))
+
+	// the months after the last income use the latest average
+	assert.Equal(t, "3000", amount(month.AddDate(0, 1, 0), "Expenses:Travel"))
+	assert.Equal(t, "3000", amount(month.AddDate(0, 2, 0), "Expenses:Travel"))
+}
+
+func TestBudgetEnvelopes(t *testing.T) {
+	db, month := openBudgetDB(t)
+	forecastPostings, expenses := currentBudgetPostings(db)
+	budgetsByMonth, _, _ := computeBudgetsByMonth(db, forecastPostings, expenses)
+
+	budget := budgetsByMonth[month.Format("2006-01")]
+	food, ok := lo.Find(budget.Accounts, func(ab AccountBudget) bool { return ab.Account == "Expenses:Food:*" })
+	require.True(t, ok)
+	assert.Equal(t, "Essentials", food.Envelope)
+	assert.Equal(t, "4500", food.Actual.String())
+	assert.Equal(t, "5500", food.Available.String())
+
+	require.Len(t, budget.Envelopes, 2)
+	assert.Equal(t, "Fun", budget.Envelopes[0].Name)
+	assert.Equal(t, "8000", budget.Envelopes[0].Forecast.String())
+	assert.Equal(t, "0", budget.Envelopes[0].Actual.String())
+	assert.Equal(t, "Essentials", budget.Envelopes[1].Name)
+	assert.Equal(t, "13000", budget.Envelopes[1].Forecast.String())
+	assert.Equal(t, "4500", budget.Envelopes[1].Actual.String())
+}
+
+func TestMatchBudgetAccount(t *testing.T) {
+	assert.True(t, matchBudgetAccount("Expenses:Food", "Expenses:Food"))
+	assert.True(t, matchBudgetAccount("Expenses:Food:Dining", "Expenses:Food"))
+	assert.False(t, matchBudgetAccount("Expenses:FoodCourt", "Expenses:Food"))
+	assert.True(t, matchBudgetAccount("Expenses:Food:Dining", "Expenses:Food:*"))
+	assert.False(t, matchBudgetAccount("Expenses:Food", "Expenses:Food:*"))
+	assert.True(t, matchBudgetAccount("Expenses:Utilities:Power", "Expenses:*:Power"))
+}
+
+func TestProjectSpend(t *testing.T) {
+	expense := func(date string, amount int64) posting.Posting {
+		return posting.Posting{Date: parseDate(date), Amount: decimal.NewFromInt(amount)}
+	}
+
+	rent := []posting.Posting{expense("2023-06-01", 30000)}
+	assert.Equal(t, "30000", projectSpend(decimal.NewFromInt(30000), rent, parseDate("2023-06-10")).String())
+
+	food := []posting.Posting{expense("2023-06-02", 1000), expense("2023-06-03", 500), expense("2023-06-08", 1500)}
+	assert.Equal(t, "9000", projectSpend(decimal.NewFromInt(3000), food, parseDate("2023-06-10")).String())
+
+	// too early in the month to project
+	assert.Equal(t, "1500", projectSpend(decimal.NewFromInt(1500), food[:2], parseDate("2023-06-03")).String())
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/server/goal"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type CalendarEvent struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	Category    string
+}
+
+// recurring transactions are scheduled up to this many days ahead, at
+// least the next expected date is always included
+const calendarRecurringDays = 90
+
+// GetCalendar builds an iCalendar feed of the credit card dues, loan
+// maturities, recurring transactions and savings goal target dates.
+func GetCalendar(db *gorm.DB) string {
+	now := utils.Now()
+	events := []CalendarEvent{}
+	events = append(events, creditCardEvents(db, now)...)
+	events = append(events, loanEvents(db)...)
+	events = append(events, recurringEvents(db, now)...)
+	events = append(events, goalEvents(db)...)
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return renderCalendar(events, now)
+}
+
+func formatCalendarAmount(amount decimal.Decimal) string {
+	return amount.StringFixed(int32(config.GetConfig().DisplayPrecision)) + " " + config.DefaultCurrency()
+}
+
+func calendarEventUID(parts ...string) string {
+	return utils.Sha256(strings.Join(parts, "|"))[:32] + "@paisa"
+}
+
+func creditCardEvents(db *gorm.DB, now time.Time) []CalendarEvent {
+	events := []CalendarEvent{}
+	today := utils.BeginningOfDay(now)
+	for _, creditCardConfig := range config.GetConfig().CreditCards {
+		ps := query.Init(db).Where("account = ?", creditCardConfig.Account).All()
+		bills := computeBills(db, creditCardConfig, ps, false)
+
+		upcoming := false
+		for _, bill := range bills {
+			if bill.DueDate.Before(today.AddDate(-1, 0, 0)) || !bill.ClosingBalance.IsPositive() {
+				continue
+			}
+
+			description := fmt.Sprintf("Account: %s\nStatement: %s to %s\nAmount: %s",
+				creditCardConfig.Account,
+				bill.StatementStartDate.Format("2006-01-02"),
+				bill.StatementEndDate.Format("2006-01-02"),
+				formatCalendarAmount(bill.ClosingBalance))
+			if bill.PaidDate != nil {
+				description += "\nPaid on " + bill.PaidDate.Format("2006-01-02")
+			}
+
+			events = append(events, CalendarEvent{
+				UID:         calendarEventUID("credit_card", creditCardConfig.Account, bill.DueDate.Format("2006-01-02")),
+				Date:        bill.DueDate,
+				Summary:     fmt.Sprintf("%s bill due %s", creditCardName(creditCardConfig), formatCalendarAmount(bill.ClosingBalance)),
+				Description: description,
+				Category:    "Credit Card",
+			})
+			upcoming = upcoming || !bill.DueDate.Before(today)
+		}
+
+		// no spends in the current billing cycle yet, the due date is
+		// still worth a reminder
+		if !upcoming {
+			dueDate := nextDueDate(creditCardConfig.DueDay, today)
+			events = append(events, CalendarEvent{
+				UID:         calendarEventUID("credit_card", creditCardConfig.Account, dueDate.Format("2006-01-02")),
+				Date:        dueDate,
+				Summary:     fmt.Sprintf("%s bill due", creditCardName(creditCardConfig)),
+				Description: "Account: " + creditCardConfig.Account,
+				Category:    "Credit Card",
+			})
+		}
+	}
+	return events
+}
+
+func creditCardName(creditCardConfig config.CreditCard) string {
+	parts := strings.Split(creditCardConfig.Account, ":")
+	name := parts[len(parts)-1]
+	if creditCardConfig.Number != "" {
+		name += " " + creditCardConfig.Number
+	}
+	return name
+}
+
+// nextDueDate returns the first date on or after the given date that
+// falls on the due day, the due day is capped at the end of the month.
+func nextDueDate(dueDay int, date time.Time) time.Time {
+	for i := 0; i < 2; i++ {
+		month := utils.BeginningOfMonth(date).AddDate(0, i, 0)
+		lastDay := utils.EndOfMonth(month).Day()
+		dueDate := month.AddDate(0, 0, min(dueDay, lastDay)-1)
+		if !dueDate.Before(date) {
+			return dueDate
+		}
+	}
+	return date
+}
+
+func loanEvents(db *gorm.DB) []CalendarEvent {
+	events := []CalendarEvent{}
+	for _, loan := range service.GetLoans(db) {
+		if loan.MaturityDate == nil || loan.Status == service.LoanStatusClosed {
+			continue
+		}
+
+		events = append(events, CalendarEvent{
+			UID:     calendarEventUID("loan", loan.Account, loan.MaturityDate.Format("2006-01-02")),
+			Date:    *loan.MaturityDate,
+			Summary: "Loan matures " + loan.Account,
+			Description: fmt.Sprintf("Account: %s\nPrincipal: %s\nCurrent Value: %s\nInterest Rate: %.2f%%",
+				loan.Account,
+				formatCalendarAmount(loan.Principal),
+				formatCalendarAmount(loan.CurrentValue),
+				loan.InterestRate),
+			Category: "Loan",
+		})
+	}
+	return events
+}
+
+func recurringEvents(db *gorm.DB, now time.Time) []CalendarEvent {
+	events := []CalendarEvent{}
+	today := utils.BeginningOfDay(now)
+	horizon := today.AddDate(0, 0, calendarRecurringDays)
+	for _, ts := range ComputeRecurringTransactions(query.Init(db).All()) {
+		last := ts.Transactions[0]
+		amount := transactionAmount(last)
+		for _, date := range expectedDates(ts, today, horizon) {
+			events = append(events, CalendarEvent{
+				UID:     calendarEventUID("recurring", ts.Key, date.Format("2006-01-02")),
+				Date:    date,
+				Summary: fmt.Sprintf("%s %s", ts.Key, formatCalendarAmount(amount)),
+				Description: fmt.Sprintf("Account: %s\nLast paid on %s",
+					primaryAccount(last),
+					last.Date.Format("2006-01-02")),
+				Category: "Recurring",
+			})
+		}
+	}
+	return events
+}
+
+// expectedDates returns the schedules from today till the horizon. The
+// Period metadata is used if available, otherwise the interval between
+// the last two transactions. A sequence that missed more than one
+// schedule is considered cancelled and has no expected dates.
+func expectedDates(ts TransactionSequence, today time.Time, horizon time.Time) []time.Time {
+	next := func(date time.Time) (time.Time, bool) {
+		if ts.Period != "" {
+			if date, err := nextPeriodDate(ts.Period, date); err == nil {
+				return date, true
+			}
+		}
+
+		switch {
+		case ts.Interval <= 0:
+			return date, false
+		case ts.Interval >= 28 && ts.Interval <= 33:
+			return date.AddDate(0, 1, 0), true
+		case ts.Interval >= 360 && ts.Interval <= 370:
+			return date.AddDate(1, 0, 0), true
+		}
+		return date.AddDate(0, 0, ts.Interval), true
+	}
+
+	dates := []time.Time{}
+	date, ok := next(ts.Transactions[0].Date)
+	if !ok {
+		return dates
+	}
+
+	if date.Before(today) {
+		if following, ok := next(date); !ok || following.Before(today) {
+			return dates
+		}
+	}
+
+	for ok && (len(dates) == 0 || (!date.After(horizon) && len(dates) < 100)) {
+		if !date.Before(today) {
+			dates = append(dates, date)
+		}
+		date, ok = next(date)
+	}
+	return dates
+}
+
+func goalEvents(db *gorm.DB) []CalendarEvent {
+	events := []CalendarEvent{}
+	for _, summary := range goal.GetGoalSummaries(db) {
+		if summary.Type != "savings" || summary.TargetDate == "" {
+			continue
+		}
+
+		targetDate, err := time.ParseInLocation("2006-01-02", summary.TargetDate, config.TimeZone())
+		if err != nil {
+			continue
+		}
+
+		events = append(events, CalendarEvent{
+			UID:     calendarEventUID("goal", summary.Id, summary.TargetDate),
+			Date:    targetDate,
+			Summary: "Savings goal " + summary.Name,
+			Description: fmt.Sprintf("Target: %s\nCurrent: %s",
+				formatCalendarAmount(summary.Target),
+				formatCalendarAmount(summary.Current)),
+			Category: "Goal",
+		})
+	}
+	return events
+}
+
+// renderCalendar serializes the events as all day events as per RFC
+// 5545.
+func renderCalendar(events []CalendarEvent, now time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Paisa//Paisa//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Paisa",
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.UID,
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+event.Date.Format("20060102"),
+			"DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+escapeCalendarText(event.Summary),
+			"DESCRIPTION:"+escapeCalendarText(event.Description),
+			"CATEGORIES:"+escapeCalendarText(event.Category),
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(foldCalendarLine(line))
+		builder.WriteString("\r\n")
+	}
+	return builder.String()
+}
+
+var calendarTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeCalendarText(text string) string {
+	return calendarTextEscaper.Replace(text)
+}
+
+// foldCalendarLine splits the lines longer than 75 octets, the
+// continuation lines start with a space. Multi byte characters are
+// never split.
+func foldCalendarLine(line string) string {
+	var builder strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > 75 {
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		builder.WriteRune(r)
+		width += size
+	}
+	return builder.String()
+}
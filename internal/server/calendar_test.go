@@ -0,0 +1,166 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func parseDate(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestNextPeriodDate(t *testing.T) {
+	cases := []struct {
+		period   string
+		after    string
+		expected string
+	}{
+		{"5 * ?", "2023-07-05", "2023-08-05"},
+		{"L * ?", "2023-01-31", "2023-02-28"},
+		{"L MAR,JUN,SEP,DEC ?", "2023-04-10", "2023-06-30"},
+		{"15 2-4 ?", "2023-04-20", "2024-02-15"},
+		{"? * 0", "2023-07-05", "2023-07-09"},
+		{"? * SAT", "2023-07-05", "2023-07-08"},
+		{"1 JAN ? | 7 FEB ?", "2023-01-01", "2023-02-07"},
+		{"15W * ?", "2023-07-01", "2023-07-14"},
+		{"1W * ?", "2023-06-30", "2023-07-03"},
+		{"? * 5L", "2023-07-01", "2023-07-28"},
+		{"31 * ?", "2023-03-31", "2023-05-31"},
+	}
+
+	for _, c := range cases {
+		date, err := nextPeriodDate(c.period, parseDate(c.after))
+		require.NoError(t, err, c.period)
+		assert.Equal(t, c.expected, date.Format("2006-01-02"), c.period)
+	}
+
+	for _, period := range []string{"5 *", "32 * ?", "1 FOO ?", "5-1 * ?"} {
+		_, err := nextPeriodDate(period, parseDate("2023-01-01"))
+		assert.Error(t, err, period)
+	}
+
+	_, err := nextPeriodDate("30 FEB ?", parseDate("2023-01-01"))
+	assert.Error(t, err)
+}
+
+func TestExpectedDates(t *testing.T) {
+	ts := TransactionSequence{
+		Transactions: []transaction.Transaction{{Date: parseDate("2023-07-05")}},
+		Interval:     30,
+	}
+	dates := expectedDates(ts, parseDate("2023-07-06"), parseDate("2023-09-10"))
+	assert.Equal(t, []time.Time{parseDate("2023-08-05"), parseDate("2023-09-05")}, dates)
+
+	// the schedules before today are left out
+	dates = expectedDates(ts, parseDate("2023-08-10"), parseDate("2023-10-10"))
+	assert.Equal(t, []time.Time{parseDate("2023-09-05"), parseDate("2023-10-05")}, dates)
+
+	// missed more than one schedule
+	dates = expectedDates(ts, parseDate("2023-09-06"), parseDate("2023-12-10"))
+	assert.Empty(t, dates)
+
+	ts.Period = "L * ?"
+	dates = expectedDates(ts, parseDate("2023-07-06"), parseDate("2023-08-31"))
+	assert.Equal(t, []time.Time{parseDate("2023-07-31"), parseDate("2023-08-31")}, dates)
+
+	// the next expected date is included even if it is past the horizon
+	dates = expectedDates(ts, parseDate("2023-07-06"), parseDate("2023-07-10"))
+	assert.Equal(t, []time.Time{parseDate("2023-07-31")}, dates)
+
+	ts.Period = ""
+	ts.Interval = 0
+	dates = expectedDates(ts, parseDate("2023-07-06"), parseDate("2023-09-10"))
+	assert.Empty(t, dates)
+}
+
+func TestNextDueDate(t *testing.T) {
+	assert.Equal(t, "2023-07-20", nextDueDate(20, parseDate("2023-07-20")).Format("2006-01-02"))
+	assert.Equal(t, "2023-08-20", nextDueDate(20, parseDate("2023-07-21")).Format("2006-01-02"))
+	assert.Equal(t, "2023-02-28", nextDueDate(31, parseDate("2023-02-01")).Format("2006-01-02"))
+}
+
+func TestRenderCalendar(t *testing.T) {
+	events := []CalendarEvent{{
+		UID:         "abc@paisa",
+		Date:        parseDate("2023-07-20"),
+		Summary:     "Freedom bill due 1,200 INR",
+		Description: "Account: Liabilities:CreditCard:Freedom\nStatement: 2023-06-09 to 2023-07-08; paid",
+		Category:    "Credit Card",
+	}}
+
+	calendar := renderCalendar(events, time.Date(2023, 7, 10, 5, 30, 0, 0, time.UTC))
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, calendar, "DTSTAMP:20230710T053000Z\r\n")
+	assert.Contains(t, calendar, "DTSTART;VALUE=DATE:20230720\r\nDTEND;VALUE=DATE:20230721\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Freedom bill due 1\\,200 INR\r\n")
+	assert.Contains(t, calendar, "DESCRIPTION:Account: Liabilities:CreditCard:Freedom\\nStatement: 2023-06-09 \r\n to 2023-07-08\\; paid\r\n")
+
+	for _, line := range strings.Split(calendar, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+func TestFoldCalendarLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("₹", 30)
+	folded := strings.Split(foldCalendarLine(line), "\r\n ")
+	require.Len(t, folded, 2)
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("₹", 22), folded[0])
+	assert.Equal(t, strings.Repeat("₹", 8), folded[1])
+}
+
+func TestCalendarAuthentication(t *testing.T) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+user_accounts:
+  - username: john
+    password: sha256:`+utils.Sha256(utils.Sha256("secret"))+`
+`), "/tmp/paisa.yaml"))
+	t.Cleanup(func() {
+		require.NoError(t, config.LoadConfig([]byte("journal_path: main.ledger\ndb_path: paisa.db\n"), "/tmp/paisa.yaml"))
+	})
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	model.AutoMigrate(db)
+	router := Build(db, false)
+
+	request := func(path string, authenticate func(r *http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		authenticate(r)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	response := request("/api/calendar.ics", func(r *http.Request) { r.SetBasicAuth("john", "secret") })
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(response.Body.String(), "BEGIN:VCALENDAR\r\n"))
+
+	response = request("/api/calendar.ics", func(r *http.Request) { r.Header.Set("X-Auth", "john:"+utils.Sha256("secret")) })
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response = request("/api/calendar.ics", func(r *http.Request) { r.SetBasicAuth("john", "wrong") })
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, `Basic realm="paisa", charset="UTF-8"`, response.Header().Get("WWW-Authenticate"))
+
+	// basic auth is only accepted for the calendar feed
+	response = request("/api/ping", func(r *http.Request) { r.SetBasicAuth("john", "secret") })
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Empty(t, response.Header().Get("WWW-Authenticate"))
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"sort"
+
+	"github.com/ananthakumaran/paisa/internal/accounting"
 	"github.com/ananthakumaran/paisa/internal/config"
 	c "github.com/ananthakumaran/paisa/internal/model/commodity"
 	"github.com/ananthakumaran/paisa/internal/model/posting"
@@ -33,63 +36,156 @@ type CapitalGain struct {
 	FY          map[string]FYCapitalGain `json:"fy"`
 }
 
-func GetCapitalGains(db *gorm.DB) gin.H {
-	commodities := lo.Filter(c.All(), func(c config.Commodity, _ int) bool {
+type CapitalGainsReport struct {
+	CapitalGains map[string]CapitalGain        `json:"capital_gains"`
+	Exemptions   map[string]taxation.Exemption `json:"exemptions"`
+}
+
+func GetCapitalGains(db *gorm.DB) (gin.H, error) {
+	report, err := capitalGainsReport(db)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{"capital_gains": report.CapitalGains, "exemptions": report.Exemptions}, nil
+}
+
+func capitalGainsReport(db *gorm.DB) (CapitalGainsReport, error) {
+	regime, err := taxation.CurrentRegime()
+	if err != nil {
+		return CapitalGainsReport{}, err
+	}
+	capitalGains := computeAllCapitalGains(db, regime)
+	return CapitalGainsReport{CapitalGains: capitalGains, Exemptions: computeExemptions(regime, capitalGains)}, nil
+}
+
+type LotSelectionReport struct {
+	LotSelections []config.LotSelectionType                           `json:"lot_selections"`
+	Comparison    map[string]map[config.LotSelectionType]taxation.Tax `json:"comparison"`
+	Current       map[string]config.LotSelectionType                  `json:"current"`
+}
+
+// GetLotSelectionComparison calculates the tax of every financial year
+// with each of the lot selections, to compare them with the configured
+// one.
+func GetLotSelectionComparison(db *gorm.DB) (gin.H, error) {
+	report, err := lotSelectionReport(db)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{"lot_selections": report.LotSelections, "comparison": report.Comparison, "current": report.Current}, nil
+}
+
+func lotSelectionReport(db *gorm.DB) (LotSelectionReport, error) {
+	regime, err := taxation.CurrentRegime()
+	if err != nil {
+		return LotSelectionReport{}, err
+	}
+	comparison, current := computeLotSelectionComparison(db, regime, ReportFilter{})
+	return LotSelectionReport{LotSelections: accounting.LotSelections, Comparison: comparison, Current: current}, nil
+}
+
+// computeLotSelectionComparison returns the tax of each financial year
+// for each lot selection method, summed across the matching accounts,
+// along with the current method of each account
+func computeLotSelectionComparison(db *gorm.DB, regime *taxation.Regime, filter ReportFilter) (map[string]map[config.LotSelectionType]taxation.Tax, map[string]config.LotSelectionType) {
+	postings := query.Init(db).Like("Assets:%").Commodities(taxableCommodities()).All()
+	postings = lo.Filter(postings, func(p posting.Posting, _ int) bool { return filter.matches(p.Account) })
+	byAccount := lo.GroupBy(postings, func(p posting.Posting) string { return p.Account })
+
+	comparison := make(map[string]map[config.LotSelectionType]taxation.Tax)
+	current := make(map[string]config.LotSelectionType)
+	for account, ps := range byAccount {
+		commodity := c.FindByName(ps[0].Commodity)
+		current[account] = accounting.LotSelection(commodity.Name)
+		for _, selection := range accounting.LotSelections {
+			for fy, fyCapitalGain := range computeCapitalGains(db, regime, account, commodity, ps, selection).FY {
+				if comparison[fy] == nil {
+					comparison[fy] = make(map[config.LotSelectionType]taxation.Tax)
+				}
+				comparison[fy][selection] = taxation.Add(comparison[fy][selection], fyCapitalGain.Tax)
+			}
+		}
+	}
+	return comparison, current
+}
+
+func taxableCommodities() []config.Commodity {
+	return lo.Filter(c.All(), func(c config.Commodity, _ int) bool {
 		return (c.Type == config.MutualFund || c.Type == config.Stock) &&
 			(c.TaxCategory == config.Debt || c.TaxCategory == config.Equity || c.TaxCategory == config.Equity65 || c.TaxCategory == config.Equity35 || c.TaxCategory == config.UnlistedEquity)
 	})
-	postings := query.Init(db).Like("Assets:%").Commodities(commodities).All()
+}
+
+func computeAllCapitalGains(db *gorm.DB, regime *taxation.Regime) map[string]CapitalGain {
+	postings := query.Init(db).Like("Assets:%").Commodities(taxableCommodities()).All()
 	byAccount := lo.GroupBy(postings, func(p posting.Posting) string { return p.Account })
-	capitalGains := lo.MapValues(byAccount, func(postings []posting.Posting, account string) CapitalGain {
-		return computeCapitalGains(db, account, c.FindByName(postings[0].Commodity), postings)
+	return lo.MapValues(byAccount, func(postings []posting.Posting, account string) CapitalGain {
+		commodity := c.FindByName(postings[0].Commodity)
+		return computeCapitalGains(db, regime, account, commodity, postings, accounting.LotSelection(commodity.Name))
 	})
-	return gin.H{"capital_gains": capitalGains}
 }
 
-func computeCapitalGains(db *gorm.DB, account string, commodity config.Commodity, postings []posting.Posting) CapitalGain {
-	capitalGain := CapitalGain{Account: account, TaxCategory: string(commodity.TaxCategory), FY: make(map[string]FYCapitalGain)}
-	var available []posting.Posting
-	for _, p := range postings {
-		if p.Quantity.GreaterThan(decimal.Zero) {
-			available = append(available, p)
-		} else {
-			quantity := p.Quantity.Neg()
-			totalTax := taxation.Tax{}
-			purchasePrice := decimal.Zero
-			postingPairs := make([]PostingPair, 0)
-			for quantity.GreaterThan(decimal.Zero) && len(available) > 0 {
-				first := available[0]
-				q := decimal.Zero
-
-				if first.Quantity.GreaterThan(quantity) {
-					first.AddQuantity(quantity.Neg())
-					q = quantity
-					available[0] = first
-					quantity = decimal.Zero
-				} else {
-					quantity = quantity.Sub(first.Quantity)
-					q = first.Quantity
-					available = available[1:]
-				}
-
-				purchasePrice = purchasePrice.Add(q.Mul(first.Price()))
-				tax := taxation.Calculate(db, q, commodity, first.Price(), first.Date, p.Price(), p.Date)
-				totalTax = taxation.Add(totalTax, tax)
-				postingPair := PostingPair{Purchase: first.WithQuantity(q), Sell: p.WithQuantity(q.Neg()), Tax: tax}
-				postingPairs = append(postingPairs, postingPair)
+// computeExemptions applies the long term gains of each financial year,
+// across all the accounts, to the yearly exemption.
+func computeExemptions(regime *taxation.Regime, capitalGains map[string]CapitalGain) map[string]taxation.Exemption {
+	type sale struct {
+		category config.TaxCategoryType
+		pair     PostingPair
+	}
 
+	var sales []sale
+	for _, capitalGain := range capitalGains {
+		for _, fyCapitalGain := range capitalGain.FY {
+			for _, pair := range fyCapitalGain.PostingPairs {
+				sales = append(sales, sale{category: config.TaxCategoryType(capitalGain.TaxCategory), pair: pair})
 			}
-			fy := utils.FY(p.Date)
-			fyCapitalGain := capitalGain.FY[fy]
-			fyCapitalGain.Tax = taxation.Add(fyCapitalGain.Tax, totalTax)
-			fyCapitalGain.Units = fyCapitalGain.Units.Add(p.Quantity.Neg())
-			fyCapitalGain.PurchasePrice = fyCapitalGain.PurchasePrice.Add(purchasePrice)
-			fyCapitalGain.SellPrice = fyCapitalGain.SellPrice.Add(p.Amount.Neg())
-			fyCapitalGain.PostingPairs = append(fyCapitalGain.PostingPairs, postingPairs...)
+		}
+	}
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].pair.Sell.Date.Before(sales[j].pair.Sell.Date) })
 
-			capitalGain.FY[fy] = fyCapitalGain
+	exemptions := make(map[string]taxation.Exemption)
+	for _, s := range sales {
+		rule, eligible := regime.ExemptionRule(s.category, s.pair.Purchase.Date, s.pair.Sell.Date)
+		if !eligible {
+			continue
+		}
+
+		fy := utils.FY(s.pair.Sell.Date)
+		exemption, ok := exemptions[fy]
+		if !ok {
+			exemption = regime.NewExemption(s.pair.Sell.Date)
+		}
+		exemption.Use(rule, s.pair.Tax.Taxable)
+		exemptions[fy] = exemption
+	}
+	return exemptions
+}
 
+func computeCapitalGains(db *gorm.DB, regime *taxation.Regime, account string, commodity config.Commodity, postings []posting.Posting, selection config.LotSelectionType) CapitalGain {
+	capitalGain := CapitalGain{Account: account, TaxCategory: string(commodity.TaxCategory), FY: make(map[string]FYCapitalGain)}
+	sales, _ := accounting.MatchLots(postings, selection)
+	for _, sale := range sales {
+		p := sale.Posting
+		totalTax := taxation.Tax{}
+		purchasePrice := decimal.Zero
+		postingPairs := make([]PostingPair, 0)
+		for _, match := range sale.Matches {
+			purchase := match.Purchase
+			purchasePrice = purchasePrice.Add(purchase.Amount)
+			tax := regime.Calculate(db, purchase.Quantity, commodity, purchase.Price(), purchase.Date, p.Price(), p.Date)
+			totalTax = taxation.Add(totalTax, tax)
+			postingPairs = append(postingPairs, PostingPair{Purchase: purchase, Sell: match.Sell, Tax: tax})
 		}
+
+		fy := utils.FY(p.Date)
+		fyCapitalGain := capitalGain.FY[fy]
+		fyCapitalGain.Tax = taxation.Add(fyCapitalGain.Tax, totalTax)
+		fyCapitalGain.Units = fyCapitalGain.Units.Add(p.Quantity.Neg())
+		fyCapitalGain.PurchasePrice = fyCapitalGain.PurchasePrice.Add(purchasePrice)
+		fyCapitalGain.SellPrice = fyCapitalGain.SellPrice.Add(p.Amount.Neg())
+		fyCapitalGain.PostingPairs = append(fyCapitalGain.PostingPairs, postingPairs...)
+
+		capitalGain.FY[fy] = fyCapitalGain
 	}
 
 	return capitalGain
@@ -5,6 +5,7 @@ import (
 
 	"github.com/ananthakumaran/paisa/internal/accounting"
 	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/ananthakumaran/paisa/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -26,24 +27,34 @@ func (c CashFlow) GroupDate() time.Time {
 	return c.Date
 }
 
-func GetCashFlow(db *gorm.DB) gin.H {
-	return gin.H{"cash_flows": computeCashFlow(db, query.Init(db), decimal.Zero)}
+type CashFlowReport struct {
+	Currency  string     `json:"currency"`
+	CashFlows []CashFlow `json:"cash_flows"`
+}
+
+func GetCashFlow(db *gorm.DB, converter *service.Converter) gin.H {
+	report := cashFlowReport(db, converter)
+	return gin.H{"cash_flows": report.CashFlows, "currency": report.Currency}
+}
+
+func cashFlowReport(db *gorm.DB, converter *service.Converter) CashFlowReport {
+	return CashFlowReport{Currency: converter.Currency, CashFlows: computeCashFlow(db, query.Init(db), decimal.Zero, converter)}
 }
 
 func GetCurrentCashFlow(db *gorm.DB) []CashFlow {
 	balance := accounting.CostSum(query.Init(db).BeforeNMonths(3).AccountPrefix("Assets:Checking").All())
-	return computeCashFlow(db, query.Init(db).LastNMonths(3), balance)
+	return computeCashFlow(db, query.Init(db).LastNMonths(3), balance, service.DefaultConverter(db))
 }
 
-func computeCashFlow(db *gorm.DB, q *query.Query, balance decimal.Decimal) []CashFlow {
+func computeCashFlow(db *gorm.DB, q *query.Query, balance decimal.Decimal, converter *service.Converter) []CashFlow {
 	var cashFlows []CashFlow
 
-	expenses := utils.GroupByMonth(q.Clone().Like("Expenses:%").NotAccountPrefix("Expenses:Tax").All())
-	incomes := utils.GroupByMonth(q.Clone().Like("Income:%").All())
-	liabilities := utils.GroupByMonth(q.Clone().Like("Liabilities:%").All())
-	investments := utils.GroupByMonth(q.Clone().Like("Assets:%").NotAccountPrefix("Assets:Checking").All())
-	taxes := utils.GroupByMonth(q.Clone().AccountPrefix("Expenses:Tax").All())
-	checkings := utils.GroupByMonth(q.Clone().AccountPrefix("Assets:Checking").All())
+	expenses := utils.GroupByMonth(converter.Postings(q.Clone().Like("Expenses:%").NotAccountPrefix("Expenses:Tax").All()))
+	incomes := utils.GroupByMonth(converter.Postings(q.Clone().Like("Income:%").All()))
+	liabilities := utils.GroupByMonth(converter.Postings(q.Clone().Like("Liabilities:%").All()))
+	investments := utils.GroupByMonth(converter.Postings(q.Clone().Like("Assets:%").NotAccountPrefix("Assets:Checking").All()))
+	taxes := utils.GroupByMonth(converter.Postings(q.Clone().AccountPrefix("Expenses:Tax").All()))
+	checkings := utils.GroupByMonth(converter.Postings(q.Clone().AccountPrefix("Assets:Checking").All()))
 	postings := q.Clone().All()
 
 	if len(postings) == 0 {
@@ -26,6 +26,7 @@ type CreditCardSummary struct {
 	CreditLimit    decimal.Decimal                       `json:"creditLimit"`
 	YearlySpends   map[string]map[string]decimal.Decimal `json:"yearlySpends"`
 	ExpirationDate time.Time                             `json:"expirationDate"`
+	Health         CreditCardHealth                      `json:"health"`
 }
 
 type CreditCardBill struct {
@@ -40,9 +41,30 @@ type CreditCardBill struct {
 	ClosingBalance       decimal.Decimal           `json:"closingBalance"`
 	Postings             []posting.Posting         `json:"postings"`
 	Transactions         []transaction.Transaction `json:"transactions"`
+	MinimumDue           decimal.Decimal           `json:"minimumDue"`
+	PaidByDueDate        decimal.Decimal           `json:"paidByDueDate"`
+	PaymentStatus        string                    `json:"paymentStatus"`
+	Utilization          decimal.Decimal           `json:"utilization"`
+	PeakUtilization      decimal.Decimal           `json:"peakUtilization"`
+	FinanceCharges       decimal.Decimal           `json:"financeCharges"`
+	LateFees             decimal.Decimal           `json:"lateFees"`
+	OtherCharges         decimal.Decimal           `json:"otherCharges"`
+	Charges              []posting.Posting         `json:"charges"`
 }
 
 func GetCreditCards(db *gorm.DB) gin.H {
+	return gin.H{"creditCards": creditCards(db)}
+}
+
+func GetCreditCard(db *gorm.DB, account string) gin.H {
+	creditCard, found := findCreditCard(db, account)
+	if !found {
+		return gin.H{"found": false}
+	}
+	return gin.H{"creditCard": creditCard, "found": true}
+}
+
+func creditCards(db *gorm.DB) []CreditCardSummary {
 	creditCards := []CreditCardSummary{}
 
 	for _, creditCardConfig := range config.GetConfig().CreditCards {
@@ -50,19 +72,18 @@ func GetCreditCards(db *gorm.DB) gin.H {
 		creditCards = append(creditCards, buildCreditCard(db, creditCardConfig, ps, false))
 	}
 
-	return gin.H{"creditCards": creditCards}
+	return creditCards
 }
 
-func GetCreditCard(db *gorm.DB, account string) gin.H {
+func findCreditCard(db *gorm.DB, account string) (CreditCardSummary, bool) {
 	for _, creditCardConfig := range config.GetConfig().CreditCards {
 		if creditCardConfig.Account == account {
 			ps := query.Init(db).Where("account = ?", creditCardConfig.Account).All()
-			creditCard := buildCreditCard(db, creditCardConfig, ps, true)
-			return gin.H{"creditCard": creditCard, "found": true}
+			return buildCreditCard(db, creditCardConfig, ps, true), true
 		}
 	}
 
-	return gin.H{"found": false}
+	return CreditCardSummary{}, false
 }
 
 func yearlySpends(db *gorm.DB, date time.Time, postings []posting.Posting) map[string]map[string]decimal.Decimal {
@@ -105,6 +126,7 @@ func buildCreditCard(db *gorm.DB, creditCardConfig config.CreditCard, ps []posti
 		CreditLimit:    decimal.NewFromInt(int64(creditCardConfig.CreditLimit)),
 		YearlySpends:   ys,
 		ExpirationDate: expirationDate,
+		Health:         creditCardHealth(creditCardConfig, balance, bills),
 	}
 }
 
@@ -117,6 +139,7 @@ func computeBills(db *gorm.DB, creditCardConfig config.CreditCard, ps []posting.
 	creditsRunningBalance := decimal.Zero
 	debitsRunningBalance := decimal.Zero
 	unpaidBill := 0
+	creditLimit := decimal.NewFromInt(int64(creditCardConfig.CreditLimit))
 
 	for _, month := range utils.SortedKeys(grouped) {
 		statementEndDate, err := time.ParseInLocation("2006-01", month, config.TimeZone())
@@ -144,9 +167,11 @@ func computeBills(db *gorm.DB, creditCardConfig config.CreditCard, ps []posting.
 		}
 
 		transactionIDs := map[string]bool{}
+		peakBalance := balance
 
 		for _, p := range grouped[month] {
 			balance = balance.Add(p.Amount.Neg())
+			peakBalance = decimal.Max(peakBalance, balance)
 
 			if p.Amount.IsPositive() {
 				creditsRunningBalance = creditsRunningBalance.Add(p.Amount)
@@ -174,6 +199,8 @@ func computeBills(db *gorm.DB, creditCardConfig config.CreditCard, ps []posting.
 
 		bill.DebitsRunningBalance = debitsRunningBalance
 		bill.ClosingBalance = balance
+		bill.Utilization = utilization(balance, creditLimit)
+		bill.PeakUtilization = utilization(peakBalance, creditLimit)
 		bill.Transactions = lo.Map(lo.Keys(transactionIDs), func(id string, _ int) transaction.Transaction {
 			t, _ := transaction.GetById(db, id)
 			return t
@@ -182,5 +209,6 @@ func computeBills(db *gorm.DB, creditCardConfig config.CreditCard, ps []posting.
 		bills = append(bills, bill)
 	}
 
+	annotateBills(db, creditCardConfig, ps, bills)
 	return bills
 }
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	BillNoDues     = "no_dues"
+	BillUpcoming   = "upcoming"
+	BillPaidOnTime = "paid_on_time"
+	BillPaidLate   = "paid_late"
+	BillPartial    = "partial"
+	BillUnpaid     = "unpaid"
+)
+
+const (
+	CreditCardHealthGood = "good"
+	CreditCardHealthFair = "fair"
+	CreditCardHealthPoor = "poor"
+)
+
+type CreditCardHealth struct {
+	Status               string          `json:"status"`
+	OnTime               int             `json:"onTime"`
+	Late                 int             `json:"late"`
+	Partial              int             `json:"partial"`
+	Unpaid               int             `json:"unpaid"`
+	Utilization          decimal.Decimal `json:"utilization"`
+	AverageUtilization   decimal.Decimal `json:"averageUtilization"`
+	PeakUtilization      decimal.Decimal `json:"peakUtilization"`
+	UtilizationThreshold decimal.Decimal `json:"utilizationThreshold"`
+	BillsOverThreshold   int             `json:"billsOverThreshold"`
+	FinanceCharges       decimal.Decimal `json:"financeCharges"`
+	LateFees             decimal.Decimal `json:"lateFees"`
+	OtherCharges         decimal.Decimal `json:"otherCharges"`
+}
+
+var (
+	lateFeePattern       = regexp.MustCompile(`(?i)\blate\b`)
+	financeChargePattern = regexp.MustCompile(`(?i)interest|finance`)
+)
+
+func minimumDuePercent(creditCardConfig config.CreditCard) decimal.Decimal {
+	if creditCardConfig.MinimumDuePercent > 0 {
+		return decimal.NewFromFloat(creditCardConfig.MinimumDuePercent)
+	}
+	return decimal.NewFromInt(5)
+}
+
+func utilizationThreshold(creditCardConfig config.CreditCard) decimal.Decimal {
+	if creditCardConfig.UtilizationThreshold > 0 {
+		return decimal.NewFromFloat(creditCardConfig.UtilizationThreshold)
+	}
+	return decimal.NewFromInt(30)
+}
+
+// utilization returns the balance as a percentage of the credit limit
+func utilization(balance decimal.Decimal, creditLimit decimal.Decimal) decimal.Decimal {
+	if !creditLimit.IsPositive() || !balance.IsPositive() {
+		return decimal.Zero
+	}
+	return balance.Div(creditLimit).Mul(decimal.NewFromInt(100)).Round(2)
+}
+
+// cardCharges returns the postings to Expenses:Charges that are part of
+// the credit card transactions
+func cardCharges(db *gorm.DB, ps []posting.Posting) []posting.Posting {
+	ids := lo.Uniq(lo.Map(ps, func(p posting.Posting, _ int) string { return p.TransactionID }))
+	if len(ids) == 0 {
+		return []posting.Posting{}
+	}
+	return query.Init(db).AccountPrefix("Expenses:Charges").Where("transaction_id IN ?", ids).All()
+}
+
+// cardPayments returns the transactions that pay the credit card from
+// an asset account. Refunds and cashbacks are credited from an expense
+// or income account, so they are not counted as the payment.
+func cardPayments(db *gorm.DB, ps []posting.Posting) map[string]bool {
+	ids := lo.Uniq(lo.FilterMap(ps, func(p posting.Posting, _ int) (string, bool) { return p.TransactionID, p.Amount.IsPositive() }))
+	if len(ids) == 0 {
+		return map[string]bool{}
+	}
+
+	contras := query.Init(db).Like("Assets:%", "Expenses:%", "Income:%").Where("transaction_id IN ?", ids).All()
+	payments := make(map[string]bool)
+	for id, cs := range lo.GroupBy(contras, func(p posting.Posting) string { return p.TransactionID }) {
+		payments[id] = lo.EveryBy(cs, func(p posting.Posting) bool { return utils.IsSameOrParent(p.Account, "Assets") })
+	}
+	return payments
+}
+
+func addCharge(bill *CreditCardBill, p posting.Posting) {
+	description := p.Account + " " + p.Payee
+	switch {
+	case lateFeePattern.MatchString(description):
+		bill.LateFees = bill.LateFees.Add(p.Amount)
+	case financeChargePattern.MatchString(description):
+		bill.FinanceCharges = bill.FinanceCharges.Add(p.Amount)
+	default:
+		bill.OtherCharges = bill.OtherCharges.Add(p.Amount)
+	}
+	bill.Charges = append(bill.Charges, p)
+}
+
+// annotateBills fills the payment status and the charges of the bills.
+// The payments from an asset account made after the statement end date
+// till the due date are considered as the payment of the bill.
+func annotateBills(db *gorm.DB, creditCardConfig config.CreditCard, ps []posting.Posting, bills []CreditCardBill) {
+	minimumDue := minimumDuePercent(creditCardConfig).Div(decimal.NewFromInt(100))
+	charges := cardCharges(db, ps)
+	payments := cardPayments(db, ps)
+	today := utils.BeginningOfDay(utils.Now())
+
+	for i := range bills {
+		bill := &bills[i]
+		bill.Charges = []posting.Posting{}
+
+		for _, p := range ps {
+			if p.Amount.IsPositive() && payments[p.TransactionID] && p.Date.After(bill.StatementEndDate) && !p.Date.After(bill.DueDate) {
+				bill.PaidByDueDate = bill.PaidByDueDate.Add(p.Amount)
+			}
+		}
+
+		for _, p := range charges {
+			if !p.Date.Before(bill.StatementStartDate) && !p.Date.After(bill.StatementEndDate) {
+				addCharge(bill, p)
+			}
+		}
+
+		if bill.ClosingBalance.IsPositive() {
+			bill.MinimumDue = bill.ClosingBalance.Mul(minimumDue).Round(2)
+		}
+		bill.PaymentStatus = billPaymentStatus(*bill, today)
+	}
+}
+
+func billPaymentStatus(bill CreditCardBill, today time.Time) string {
+	switch {
+	case !bill.ClosingBalance.IsPositive():
+		return BillNoDues
+	case bill.PaidByDueDate.GreaterThanOrEqual(bill.ClosingBalance):
+		return BillPaidOnTime
+	case bill.PaidDate != nil && !bill.PaidDate.After(bill.DueDate):
+		return BillPaidOnTime
+	case !bill.DueDate.Before(today):
+		return BillUpcoming
+	case bill.PaidByDueDate.IsPositive() && bill.PaidByDueDate.GreaterThanOrEqual(bill.MinimumDue):
+		return BillPartial
+	case bill.PaidDate != nil:
+		return BillPaidLate
+	default:
+		return BillUnpaid
+	}
+}
+
+// creditCardHealth summarizes the bills of the last one year. Any late
+// or missed payment makes the health poor, while partial payments,
+// charges or high utilization make it fair.
+func creditCardHealth(creditCardConfig config.CreditCard, balance decimal.Decimal, bills []CreditCardBill) CreditCardHealth {
+	creditLimit := decimal.NewFromInt(int64(creditCardConfig.CreditLimit))
+	health := CreditCardHealth{
+		Utilization:          utilization(balance, creditLimit),
+		UtilizationThreshold: utilizationThreshold(creditCardConfig),
+	}
+
+	since := utils.Now().AddDate(-1, 0, 0)
+	recent := lo.Filter(bills, func(bill CreditCardBill, _ int) bool {
+		return bill.StatementEndDate.After(since)
+	})
+
+	total := decimal.Zero
+	for _, bill := range recent {
+		switch bill.PaymentStatus {
+		case BillPaidOnTime:
+			health.OnTime++
+		case BillPaidLate:
+			health.Late++
+		case BillPartial:
+			health.Partial++
+		case BillUnpaid:
+			health.Unpaid++
+		}
+
+		total = total.Add(bill.Utilization)
+		health.PeakUtilization = decimal.Max(health.PeakUtilization, bill.PeakUtilization)
+		if bill.Utilization.GreaterThan(health.UtilizationThreshold) {
+			health.BillsOverThreshold++
+		}
+
+		health.FinanceCharges = health.FinanceCharges.Add(bill.FinanceCharges)
+		health.LateFees = health.LateFees.Add(bill.LateFees)
+		health.OtherCharges = health.OtherCharges.Add(bill.OtherCharges)
+	}
+
+	if len(recent) > 0 {
+		health.AverageUtilization = total.Div(decimal.NewFromInt(int64(len(recent)))).Round(2)
+	}
+
+	switch {
+	case health.Late > 0 || health.Unpaid > 0:
+		health.Status = CreditCardHealthPoor
+	case health.Partial > 0 || health.BillsOverThreshold > 0 ||
+		health.Utilization.GreaterThan(health.UtilizationThreshold) ||
+		health.FinanceCharges.IsPositive() || health.LateFees.IsPositive():
+		health.Status = CreditCardHealthFair
+	default:
+		health.Status = CreditCardHealthGood
+	}
+	return health
+}
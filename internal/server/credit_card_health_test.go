@@ -0,0 +1,115 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func buildBill(closingBalance int64, paidByDueDate int64, paidDate string) CreditCardBill {
+	bill := CreditCardBill{
+		StatementEndDate: parseDate("2023-01-08"),
+		DueDate:          parseDate("2023-01-20"),
+		ClosingBalance:   decimal.NewFromInt(closingBalance),
+		PaidByDueDate:    decimal.NewFromInt(paidByDueDate),
+		MinimumDue:       decimal.NewFromInt(closingBalance).Mul(decimal.NewFromFloat(0.05)),
+	}
+	if paidDate != "" {
+		date := parseDate(paidDate)
+		bill.PaidDate = &date
+	}
+	return bill
+}
+
+func TestBillPaymentStatus(t *testing.T) {
+	today := parseDate("2023-03-01")
+	assert.Equal(t, BillNoDues, billPaymentStatus(buildBill(0, 0, ""), today))
+	assert.Equal(t, BillPaidOnTime, billPaymentStatus(buildBill(1000, 1000, "2023-01-15"), today))
+	assert.Equal(t, BillPaidLate, billPaymentStatus(buildBill(1000, 0, "2023-01-25"), today))
+	assert.Equal(t, BillPartial, billPaymentStatus(buildBill(1000, 100, "2023-02-10"), today))
+	assert.Equal(t, BillPaidLate, billPaymentStatus(buildBill(1000, 10, "2023-02-10"), today))
+	assert.Equal(t, BillUnpaid, billPaymentStatus(buildBill(1000, 0, ""), today))
+	assert.Equal(t, BillUpcoming, billPaymentStatus(buildBill(1000, 0, ""), parseDate("2023-01-10")))
+}
+
+func TestUtilization(t *testing.T) {
+	assert.Equal(t, "33.33", utilization(decimal.NewFromInt(1000), decimal.NewFromInt(3000)).String())
+	assert.True(t, utilization(decimal.NewFromInt(-1000), decimal.NewFromInt(3000)).IsZero())
+	assert.True(t, utilization(decimal.NewFromInt(1000), decimal.Zero).IsZero())
+}
+
+func TestCreditCardHealth(t *testing.T) {
+	card := config.CreditCard{CreditLimit: 10000}
+	recent := func(bill CreditCardBill, status string, utilization int64) CreditCardBill {
+		bill.StatementEndDate = utils.Now().AddDate(0, -1, 0)
+		bill.PaymentStatus = status
+		bill.Utilization = decimal.NewFromInt(utilization)
+		bill.PeakUtilization = decimal.NewFromInt(utilization)
+		return bill
+	}
+
+	old := recent(CreditCardBill{}, BillUnpaid, 90)
+	old.StatementEndDate = utils.Now().AddDate(-2, 0, 0)
+	bills := []CreditCardBill{old, recent(CreditCardBill{}, BillPaidOnTime, 10), recent(CreditCardBill{}, BillPaidOnTime, 20)}
+
+	health := creditCardHealth(card, decimal.NewFromInt(1000), bills)
+	assert.Equal(t, CreditCardHealthGood, health.Status)
+	assert.Equal(t, 2, health.OnTime)
+	assert.Equal(t, 0, health.Unpaid)
+	assert.Equal(t, "15", health.AverageUtilization.String())
+	assert.Equal(t, "20", health.PeakUtilization.String())
+	assert.Equal(t, "10", health.Utilization.String())
+
+	bills = append(bills, recent(CreditCardBill{}, BillPartial, 40))
+	health = creditCardHealth(card, decimal.NewFromInt(1000), bills)
+	assert.Equal(t, CreditCardHealthFair, health.Status)
+	assert.Equal(t, 1, health.BillsOverThreshold)
+
+	bills = append(bills, recent(CreditCardBill{LateFees: decimal.NewFromInt(500)}, BillPaidLate, 10))
+	health = creditCardHealth(card, decimal.NewFromInt(1000), bills)
+	assert.Equal(t, CreditCardHealthPoor, health.Status)
+	assert.Equal(t, "500", health.LateFees.String())
+}
+
+func TestAnnotateBillsIgnoresRefunds(t *testing.T) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+`), "/tmp/paisa.yaml"))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	model.AutoMigrate(db)
+
+	card := "Liabilities:CreditCard:Freedom"
+	var ps []posting.Posting
+	create := func(id string, date string, contra string, amount int64) {
+		for _, p := range []posting.Posting{
+			{TransactionID: id, Date: parseDate(date), Payee: id, Account: card, Commodity: "INR", Quantity: decimal.NewFromInt(amount), Amount: decimal.NewFromInt(amount), FileName: "main.ledger"},
+			{TransactionID: id, Date: parseDate(date), Payee: id, Account: contra, Commodity: "INR", Quantity: decimal.NewFromInt(-amount), Amount: decimal.NewFromInt(-amount), FileName: "main.ledger"},
+		} {
+			require.NoError(t, db.Create(&p).Error)
+			if p.Account == card {
+				ps = append(ps, p)
+			}
+		}
+	}
+	create("spend", "2023-01-05", "Expenses:Shopping", -5000)
+	create("refund", "2023-01-12", "Expenses:Shopping", 1000)
+	create("cashback", "2023-01-14", "Income:Cashback", 200)
+	create("payment", "2023-01-15", "Assets:Checking", 3000)
+
+	bills := []CreditCardBill{buildBill(5000, 0, "")}
+	annotateBills(db, config.CreditCard{Account: card}, ps, bills)
+	assert.Equal(t, "3000", bills[0].PaidByDueDate.String())
+	assert.Equal(t, BillPartial, bills[0].PaymentStatus)
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reportingCurrency reads the currency query parameter. On failure,
+// the error response is already written.
+func reportingCurrency(db *gorm.DB, c *gin.Context) (*service.Converter, bool) {
+	converter, err := service.NewConverter(db, c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return converter, true
+}
+
+func GetCurrencies(db *gorm.DB) gin.H {
+	return gin.H{"default": config.DefaultCurrency(), "currencies": service.ReportingCurrencies()}
+}
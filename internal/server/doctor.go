@@ -31,6 +31,20 @@ type Issue struct {
 	Summary     string `json:"summary"`
 	Description string `json:"description"`
 	Details     string `json:"details"`
+	FileName    string `json:"file_name"`
+	Line        uint64 `json:"line"`
+}
+
+// journalError is an error that can be traced back to a transaction
+// in the journal
+type journalError struct {
+	error
+	fileName string
+	line     uint64
+}
+
+func postingError(p posting.Posting, err error) error {
+	return journalError{error: err, fileName: p.FileName, line: p.TransactionBeginLine}
 }
 
 type Rule struct {
@@ -83,15 +97,25 @@ func init() {
 }
 
 func GetDiagnosis(db *gorm.DB) gin.H {
+	return gin.H{"issues": Diagnose(db)}
+}
+
+// Diagnose runs both the built-in and the custom rules
+func Diagnose(db *gorm.DB) []Issue {
 	issues := make([]Issue, 0)
-	for _, rule := range rules {
-		for _, error := range rule.Predicate(db) {
+	for _, rule := range append(append([]Rule{}, rules...), customRules()...) {
+		for _, err := range rule.Predicate(db) {
 			issue := rule.Issue
-			issue.Details = error.Error()
+			issue.Details = err.Error()
+			var located journalError
+			if errors.As(err, &located) {
+				issue.FileName = located.fileName
+				issue.Line = located.line
+			}
 			issues = append(issues, issue)
 		}
 	}
-	return gin.H{"issues": issues}
+	return issues
 }
 
 func ruleAssetRegisterNonNegative(db *gorm.DB) []error {
@@ -113,7 +137,7 @@ func ruleNonCreditAccount(db *gorm.DB) []error {
 	incomes := query.Init(db).Like("Income:%").NotLike("Income:CapitalGains:%").All()
 	for _, p := range incomes {
 		if p.Amount.GreaterThan(decimal.NewFromFloat(0.01)) {
-			errs = append(errs, errors.New(fmt.Sprintf("<b>%.4f</b> got credited to <b>%s</b> on %s", p.Amount.InexactFloat64(), p.Account, p.Date.Format(DATE_FORMAT))))
+			errs = append(errs, postingError(p, errors.New(fmt.Sprintf("<b>%.4f</b> got credited to <b>%s</b> on %s", p.Amount.InexactFloat64(), p.Account, p.Date.Format(DATE_FORMAT)))))
 		}
 	}
 	return errs
@@ -124,7 +148,7 @@ func ruleNonDebitAccount(db *gorm.DB) []error {
 	incomes := query.Init(db).Like("Expenses:%").All()
 	for _, p := range incomes {
 		if p.Amount.LessThan(decimal.NewFromFloat(0.01).Neg()) {
-			errs = append(errs, errors.New(fmt.Sprintf("<b>%.4f</b> got debited from <b>%s</b> on %s", p.Amount.InexactFloat64(), p.Account, p.Date.Format(DATE_FORMAT))))
+			errs = append(errs, postingError(p, errors.New(fmt.Sprintf("<b>%.4f</b> got debited from <b>%s</b> on %s", p.Amount.InexactFloat64(), p.Account, p.Date.Format(DATE_FORMAT)))))
 		}
 	}
 	return errs
@@ -138,7 +162,7 @@ func ruleExchangePriceMissing(db *gorm.DB) []error {
 		if !utils.IsCurrency(p.Commodity) {
 			externalPrice := service.GetUnitPrice(db, p.Commodity, p.Date)
 			if externalPrice.CommodityName != "" && externalPrice.CommodityName != p.Commodity {
-				errs = append(errs, errors.New(fmt.Sprintf("Exchange price from <b>%s</b> to your default currency <b>%s</b> is not specified for posting %s", p.Commodity, config.DefaultCurrency(), formatPosting(p))))
+				errs = append(errs, postingError(p, errors.New(fmt.Sprintf("Exchange price from <b>%s</b> to your default currency <b>%s</b> is not specified for posting %s", p.Commodity, config.DefaultCurrency(), formatPosting(p)))))
 			}
 		}
 	}
@@ -156,7 +180,7 @@ func ruleJournalPriceMismatch(db *gorm.DB) []error {
 				externalPrice.CommodityType != config.Unknown &&
 				!service.IsSellWithCapitalGains(db, p) &&
 				diff.GreaterThanOrEqual(decimal.NewFromFloat(0.0001)) {
-				errs = append(errs, errors.New(fmt.Sprintf("The price specified in your posting %s doesn't match the price <b>%.4f</b> (%s) fetched from external system", formatPosting(p), externalPrice.Value.InexactFloat64(), externalPrice.Date.Format(DATE_FORMAT))))
+				errs = append(errs, postingError(p, errors.New(fmt.Sprintf("The price specified in your posting %s doesn't match the price <b>%.4f</b> (%s) fetched from external system", formatPosting(p), externalPrice.Value.InexactFloat64(), externalPrice.Date.Format(DATE_FORMAT)))))
 			}
 		}
 	}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// RulePosting provides the variables available in the doctor rule
+// expressions of a posting.
+type RulePosting struct {
+	Date      time.Time         `expr:"date"`
+	Payee     string            `expr:"payee"`
+	Account   string            `expr:"account"`
+	Commodity string            `expr:"commodity"`
+	Quantity  float64           `expr:"quantity"`
+	Amount    float64           `expr:"amount"`
+	Status    string            `expr:"status"`
+	Note      string            `expr:"note"`
+	Tags      map[string]string `expr:"tags"`
+}
+
+// RuleTransaction provides the variables available in the doctor rule
+// expressions of a transaction.
+type RuleTransaction struct {
+	Date     time.Time         `expr:"date"`
+	Payee    string            `expr:"payee"`
+	Note     string            `expr:"note"`
+	Tags     map[string]string `expr:"tags"`
+	Postings []RulePosting     `expr:"postings"`
+}
+
+// customRules builds the doctor rules declared in the config
+func customRules() []Rule {
+	return lo.Map(config.GetConfig().DoctorRules, func(conf config.DoctorRule, _ int) Rule {
+		return Rule{
+			Issue: Issue{
+				Level:       lo.Ternary(conf.Level == config.RuleError, ERROR, WARN),
+				Summary:     html.EscapeString(conf.Summary),
+				Description: html.EscapeString(conf.Description)},
+			Predicate: func(db *gorm.DB) []error {
+				return checkCustomRule(conf, transaction.Build(query.Init(db).All()))
+			}}
+	})
+}
+
+func buildRuleTransaction(t transaction.Transaction) RuleTransaction {
+	tags := posting.ParseNoteTags(t.Note, map[string]string{})
+	return RuleTransaction{
+		Date:  t.Date,
+		Payee: t.Payee,
+		Note:  t.Note,
+		Tags:  tags,
+		Postings: lo.Map(t.Postings, func(p posting.Posting, _ int) RulePosting {
+			// posting tags override the transaction tags
+			return RulePosting{
+				Date:      p.Date,
+				Payee:     p.Payee,
+				Account:   p.Account,
+				Commodity: p.Commodity,
+				Quantity:  p.Quantity.InexactFloat64(),
+				Amount:    p.Amount.InexactFloat64(),
+				Status:    p.Status,
+				Note:      p.Note,
+				Tags:      posting.ParseNoteTags(p.Note, lo.Assign(tags)),
+			}
+		}),
+	}
+}
+
+func compileRuleExpression(input string, env any) (*vm.Program, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+	return expr.Compile(input, expr.Env(env), expr.AsBool())
+}
+
+// evaluateRule returns true if the env is selected by the when
+// expression and fails the assert expression
+func evaluateRule(when *vm.Program, assert *vm.Program, env any) (bool, error) {
+	if when != nil {
+		selected, err := expr.Run(when, env)
+		if err != nil {
+			return false, err
+		}
+		if selected != true {
+			return false, nil
+		}
+	}
+
+	valid, err := expr.Run(assert, env)
+	if err != nil {
+		return false, err
+	}
+	return valid != true, nil
+}
+
+func checkCustomRule(conf config.DoctorRule, transactions []transaction.Transaction) []error {
+	errs := make([]error, 0)
+
+	var env any = RulePosting{}
+	if conf.On == config.RuleTransaction {
+		env = RuleTransaction{}
+	}
+
+	when, err := compileRuleExpression(conf.When, env)
+	if err != nil {
+		return append(errs, fmt.Errorf("Invalid <b>when</b> expression: %s", html.EscapeString(err.Error())))
+	}
+	if strings.TrimSpace(conf.Assert) == "" {
+		return append(errs, errors.New("The <b>assert</b> expression is empty"))
+	}
+	assert, err := compileRuleExpression(conf.Assert, env)
+	if err != nil {
+		return append(errs, fmt.Errorf("Invalid <b>assert</b> expression: %s", html.EscapeString(err.Error())))
+	}
+
+	for _, t := range transactions {
+		rt := buildRuleTransaction(t)
+
+		if conf.On == config.RuleTransaction {
+			failed, err := evaluateRule(when, assert, rt)
+			if err != nil {
+				return append(errs, transactionError(t, fmt.Errorf("Failed to evaluate the rule for transaction %s: %s", formatTransaction(t), html.EscapeString(err.Error()))))
+			}
+			if failed {
+				errs = append(errs, transactionError(t, errors.New(formatTransaction(t))))
+			}
+			continue
+		}
+
+		for i, p := range t.Postings {
+			failed, err := evaluateRule(when, assert, rt.Postings[i])
+			if err != nil {
+				return append(errs, postingError(p, fmt.Errorf("Failed to evaluate the rule for posting %s: %s", formatPosting(p), html.EscapeString(err.Error()))))
+			}
+			if failed {
+				errs = append(errs, postingError(p, errors.New(formatPosting(p))))
+			}
+		}
+	}
+	return errs
+}
+
+func transactionError(t transaction.Transaction, err error) error {
+	return journalError{error: err, fileName: t.FileName, line: t.BeginLine}
+}
+
+func formatTransaction(t transaction.Transaction) string {
+	transactionUrl := fmt.Sprintf("/ledger/editor/%s#%d", url.PathEscape(t.FileName), t.BeginLine)
+	return fmt.Sprintf("<a href=\"%s\"> %s\t%s</a>", transactionUrl, t.Date.Format(DATE_FORMAT), html.EscapeString(t.Payee))
+}
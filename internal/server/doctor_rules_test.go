@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleTransaction(t *testing.T) {
+	txn := buildTransaction("2023-01-05", "Goa", "Expenses:Travel", 5000)
+	txn.Note = " Trip: Goa"
+	txn.Postings[0].Note = " Trip: Goa 2023"
+
+	rt := buildRuleTransaction(txn)
+	assert.Equal(t, "Goa", rt.Tags["Trip"])
+	require.Len(t, rt.Postings, 2)
+	assert.Equal(t, "Goa 2023", rt.Postings[0].Tags["Trip"])
+	assert.Equal(t, "Goa", rt.Postings[1].Tags["Trip"])
+	assert.Equal(t, 5000.0, rt.Postings[0].Amount)
+}
+
+func TestCheckCustomRule(t *testing.T) {
+	trip := buildTransaction("2023-01-05", "Goa", "Expenses:Travel", 5000)
+	trip.Note = " Trip: Goa"
+	transactions := []transaction.Transaction{
+		trip,
+		buildTransaction("2023-01-06", "Taxi", "Expenses:Travel", 500),
+		buildTransaction("2023-01-07", "Salary", "Income:Salary", -100000),
+	}
+
+	errs := checkCustomRule(config.DoctorRule{
+		On:     config.RulePosting,
+		When:   `account startsWith "Expenses:Travel"`,
+		Assert: `"Trip" in tags`,
+	}, transactions)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "Expenses:Travel")
+
+	errs = checkCustomRule(config.DoctorRule{
+		On:     config.RuleTransaction,
+		When:   `any(postings, .account startsWith "Income:Salary")`,
+		Assert: `any(postings, .account == "Expenses:Taxes:TDS")`,
+	}, transactions)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "Salary")
+
+	errs = checkCustomRule(config.DoctorRule{On: config.RulePosting, Assert: `amount +`}, transactions)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "Invalid <b>assert</b> expression")
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"os"
@@ -129,6 +130,39 @@ func SaveFile(db *gorm.DB, file LedgerFile) gin.H {
 	return gin.H{"errors": errors, "saved": true, "file": readLedgerFileWithVersions(dir, filePath)}
 }
 
+// updateLedgerFile rewrites the lines of a file in the journal
+// directory, keeping a backup of the previous content like the editor.
+func updateLedgerFile(name string, update func(lines []string) ([]string, error)) error {
+	dir := filepath.Dir(config.GetJournalPath())
+	filePath, err := utils.BuildSubPath(dir, name)
+	if err != nil {
+		return err
+	}
+
+	fileStat, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	lines, err := update(strings.Split(string(content), "\n"))
+	if err != nil {
+		return err
+	}
+
+	backupPath := filePath + ".backup." + time.Now().Format("2006-01-02-15-04-05.000")
+	err = os.WriteFile(backupPath, content, fileStat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), fileStat.Mode().Perm())
+}
+
 func ValidateFile(file LedgerFile) gin.H {
 	errors, output, _ := validateFile(file)
 	return gin.H{"errors": errors, "output": output}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Event struct {
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+}
+
+type eventBroker struct {
+	sync.Mutex
+	clients map[chan Event]bool
+}
+
+var events = &eventBroker{clients: make(map[chan Event]bool)}
+
+func (b *eventBroker) subscribe() chan Event {
+	b.Lock()
+	defer b.Unlock()
+	client := make(chan Event, 8)
+	b.clients[client] = true
+	return client
+}
+
+func (b *eventBroker) unsubscribe(client chan Event) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.clients, client)
+}
+
+// Publish sends the event to every connected browser. Slow clients
+// miss events instead of blocking the publisher.
+func (b *eventBroker) Publish(event Event) {
+	b.Lock()
+	defer b.Unlock()
+	for client := range b.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+}
+
+func StreamEvents(c *gin.Context) {
+	client := events.subscribe()
+	defer events.unsubscribe(client)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", Event{Type: "ready"})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-client:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-time.After(30 * time.Second):
+			c.SSEvent("ping", Event{Type: "ping"})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ananthakumaran/paisa/internal/model/posting"
 	"github.com/ananthakumaran/paisa/internal/model/transaction"
 	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/ananthakumaran/paisa/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/lo"
@@ -40,31 +41,57 @@ func GetCurrentExpense(db *gorm.DB) map[string][]posting.Posting {
 	return utils.GroupByMonth(expenses)
 }
 
-func GetExpense(db *gorm.DB) gin.H {
-	expenses := query.Init(db).Like("Expenses:%").NotAccountPrefix("Expenses:Tax").All()
-	incomes := query.Init(db).Like("Income:%").All()
-	investments := query.Init(db).Like("Assets:%").NotAccountPrefix("Assets:Checking").All()
-	taxes := query.Init(db).AccountPrefix("Expenses:Tax").All()
-	postings := query.Init(db).All()
+type PeriodPostings struct {
+	Expenses    map[string][]posting.Posting `json:"expenses"`
+	Incomes     map[string][]posting.Posting `json:"incomes"`
+	Investments map[string][]posting.Posting `json:"investments"`
+	Taxes       map[string][]posting.Posting `json:"taxes"`
+}
+
+type ExpenseReport struct {
+	Currency  string            `json:"currency"`
+	Expenses  []posting.Posting `json:"expenses"`
+	MonthWise PeriodPostings    `json:"month_wise"`
+	YearWise  PeriodPostings    `json:"year_wise"`
+	Graph     map[string]Graph  `json:"graph"`
+}
+
+func GetExpense(db *gorm.DB, converter *service.Converter) gin.H {
+	report := expenseReport(db, converter)
+	return gin.H{
+		"expenses":   report.Expenses,
+		"month_wise": report.MonthWise,
+		"year_wise":  report.YearWise,
+		"graph":      report.Graph,
+		"currency":   report.Currency}
+}
+
+func expenseReport(db *gorm.DB, converter *service.Converter) ExpenseReport {
+	expenses := converter.Postings(query.Init(db).Like("Expenses:%").NotAccountPrefix("Expenses:Tax").All())
+	incomes := converter.Postings(query.Init(db).Like("Income:%").All())
+	investments := converter.Postings(query.Init(db).Like("Assets:%").NotAccountPrefix("Assets:Checking").All())
+	taxes := converter.Postings(query.Init(db).AccountPrefix("Expenses:Tax").All())
+	postings := converter.Postings(query.Init(db).All())
 
 	graph := make(map[string]Graph)
 	for fy, ps := range utils.GroupByFY(postings) {
 		graph[fy] = sortGraph(computeHierarchyGraph(ps))
 	}
 
-	return gin.H{
-		"expenses": expenses,
-		"month_wise": gin.H{
-			"expenses":    utils.GroupByMonth(expenses),
-			"incomes":     utils.GroupByMonth(incomes),
-			"investments": utils.GroupByMonth(investments),
-			"taxes":       utils.GroupByMonth(taxes)},
-		"year_wise": gin.H{
-			"expenses":    utils.GroupByFY(expenses),
-			"incomes":     utils.GroupByFY(incomes),
-			"investments": utils.GroupByFY(investments),
-			"taxes":       utils.GroupByFY(taxes)},
-		"graph": graph}
+	return ExpenseReport{
+		Currency: converter.Currency,
+		Expenses: expenses,
+		MonthWise: PeriodPostings{
+			Expenses:    utils.GroupByMonth(expenses),
+			Incomes:     utils.GroupByMonth(incomes),
+			Investments: utils.GroupByMonth(investments),
+			Taxes:       utils.GroupByMonth(taxes)},
+		YearWise: PeriodPostings{
+			Expenses:    utils.GroupByFY(expenses),
+			Incomes:     utils.GroupByFY(incomes),
+			Investments: utils.GroupByFY(investments),
+			Taxes:       utils.GroupByFY(taxes)},
+		Graph: graph}
 }
 
 func sortGraph(graph Graph) Graph {
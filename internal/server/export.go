@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/export"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+var exportParameters = []apiParameter{
+	{Name: "format", Description: "Export the report as csv, xlsx or parquet instead of json"},
+	{Name: "from", Description: "Export the rows on or after the date in YYYY-MM-DD format"},
+	{Name: "to", Description: "Export the rows on or before the date in YYYY-MM-DD format"},
+	{Name: "account", Description: "Export the rows of the account and its children"},
+	{Name: "table", Description: "Table to export in csv and parquet formats, defaults to the first table of the report"},
+}
+
+// exportRequested tells whether the report should be exported in one of
+// the tabular formats instead of the JSON response
+func exportRequested(c *gin.Context) bool {
+	format := c.Query("format")
+	return format != "" && format != "json"
+}
+
+func exportReportFilter(c *gin.Context) (ReportFilter, error) {
+	filter := ReportFilter{Account: c.Query("account"), Currency: c.Query("currency")}
+	for _, parameter := range []string{"from", "to"} {
+		value := c.Query(parameter)
+		if value == "" {
+			continue
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", value, config.TimeZone())
+		if err != nil {
+			return filter, fmt.Errorf("Invalid %s date %s, expected YYYY-MM-DD", parameter, value)
+		}
+		if parameter == "from" {
+			filter.From = utils.BeginningOfDay(date)
+		} else {
+			filter.To = utils.EndOfDay(date)
+		}
+	}
+	return filter, nil
+}
+
+// exportReport encodes the report in the requested format, the file
+// name includes the table name if the format can hold only one table
+func exportReport(db *gorm.DB, c *gin.Context, name string) ([]byte, string, error) {
+	format := c.Query("format")
+	if !lo.Contains(export.Formats, format) {
+		return nil, "", fmt.Errorf("Invalid format %s, expected one of json, csv, xlsx or parquet", format)
+	}
+
+	filter, err := exportReportFilter(c)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tables, err := BuildReport(db, name, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if table := c.Query("table"); table != "" {
+		selected, found := lo.Find(tables, func(t export.Table) bool { return t.Name == table })
+		if !found {
+			return nil, "", fmt.Errorf("Invalid table %s, expected one of %s", table, strings.Join(lo.Map(tables, func(t export.Table, _ int) string { return t.Name }), ", "))
+		}
+		tables = []export.Table{selected}
+	}
+
+	var buffer bytes.Buffer
+	if err := export.Write(&buffer, format, tables); err != nil {
+		return nil, "", err
+	}
+
+	filename := name
+	if !export.MultiTable(format) && tables[0].Name != name {
+		filename = name + "-" + tables[0].Name
+	}
+	return buffer.Bytes(), filename + "." + format, nil
+}
+
+func sendExport(c *gin.Context, data []byte, filename string) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, export.ContentType(c.Query("format")), data)
+}
+
+// reportHandler serves the report in the tabular formats if requested
+// via the format parameter, otherwise falls back to the JSON handler
+func reportHandler(db *gorm.DB, name string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !exportRequested(c) {
+			handler(c)
+			return
+		}
+
+		data, filename, err := exportReport(db, c, name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sendExport(c, data, filename)
+	}
+}
+
+// exportable lets the /api/v1 route be exported in the tabular formats,
+// the errors are returned in the same envelope as the JSON handler
+func exportable(db *gorm.DB, name string, route apiRoute) apiRoute {
+	handler := route.Handler
+	route.Report = name
+	route.Parameters = append(route.Parameters, exportParameters...)
+	route.Handler = func(c *gin.Context) {
+		if !exportRequested(c) {
+			handler(c)
+			return
+		}
+
+		data, filename, err := exportReport(db, c, name)
+		if err != nil {
+			respond(c, struct{}{}, badRequest(err))
+			return
+		}
+		sendExport(c, data, filename)
+	}
+	return route
+}
@@ -30,6 +30,10 @@ type AccountGain struct {
 }
 
 func GetGain(db *gorm.DB) gin.H {
+	return gin.H{"gain_breakdown": computeGains(db)}
+}
+
+func computeGains(db *gorm.DB) []Gain {
 	postings := query.Init(db).Like("Assets:%", "Income:CapitalGains:%").NotAccountPrefix("Assets:Checking").All()
 	postings = service.PopulateMarketPrice(db, postings)
 	byAccount := lo.GroupBy(postings, func(p posting.Posting) string {
@@ -41,17 +45,27 @@ func GetGain(db *gorm.DB) gin.H {
 	var gains []Gain
 	for _, account := range utils.SortedKeys(byAccount) {
 		ps := byAccount[account]
-		gains = append(gains, Gain{Account: account, XIRR: service.XIRR(db, ps), Networth: computeNetworth(db, ps), Postings: ps})
+		gains = append(gains, Gain{Account: account, XIRR: service.XIRR(db, ps), Networth: computeNetworth(db, ps, service.DefaultConverter(db)), Postings: ps})
 	}
+	return gains
+}
 
-	return gin.H{"gain_breakdown": gains}
+type AccountGainReport struct {
+	Gain                AccountGain               `json:"gain"`
+	PortfolioAllocation PortfolioAllocationGroups `json:"portfolio_allocation"`
+	AssetBreakdown      assets.AssetBreakdown     `json:"asset_breakdown"`
 }
 
 func GetAccountGain(db *gorm.DB, account string) gin.H {
+	report := accountGainReport(db, account)
+	return gin.H{"gain_timeline_breakdown": report.Gain, "portfolio_allocation": report.PortfolioAllocation, "asset_breakdown": report.AssetBreakdown}
+}
+
+func accountGainReport(db *gorm.DB, account string) AccountGainReport {
 	capitalGainsAccount := strings.Replace(account, "Assets", "Income:CapitalGains", 1)
 	postings := query.Init(db).AccountPrefix(account, capitalGainsAccount).All()
 	postings = service.PopulateMarketPrice(db, postings)
-	gain := AccountGain{Account: account, XIRR: service.XIRR(db, postings), NetworthTimeline: computeNetworthTimeline(db, postings, accounting.IsLeafAccount(db, account)), Postings: postings}
+	gain := AccountGain{Account: account, XIRR: service.XIRR(db, postings), NetworthTimeline: computeNetworthTimeline(db, postings, accounting.IsLeafAccount(db, account), service.DefaultConverter(db)), Postings: postings}
 
 	commodities := lo.Uniq(lo.Map(postings, func(p posting.Posting, _ int) string { return p.Commodity }))
 	var portfolio_groups PortfolioAllocationGroups
@@ -62,5 +76,5 @@ func GetAccountGain(db *gorm.DB, account string) gin.H {
 
 	assetBreakdown := assets.ComputeBreakdown(db, postings, false, account)
 
-	return gin.H{"gain_timeline_breakdown": gain, "portfolio_allocation": portfolio_groups, "asset_breakdown": assetBreakdown}
+	return AccountGainReport{Gain: gain, PortfolioAllocation: portfolio_groups, AssetBreakdown: assetBreakdown}
 }
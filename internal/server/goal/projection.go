@@ -0,0 +1,167 @@
+package goal
+
+import (
+	"math"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/accounting"
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+type SavingsStatus string
+
+const (
+	Achieved SavingsStatus = "achieved"
+	OnTrack  SavingsStatus = "on_track"
+	Behind   SavingsStatus = "behind"
+)
+
+type SavingsProjection struct {
+	RequiredContribution decimal.Decimal    `json:"requiredContribution"`
+	MonthlyContribution  decimal.Decimal    `json:"monthlyContribution"`
+	TargetDate           string             `json:"targetDate"`
+	ExpectedDate         string             `json:"expectedDate"`
+	Status               SavingsStatus      `json:"status"`
+	Shortfall            decimal.Decimal    `json:"shortfall"`
+	Projection           []accounting.Point `json:"projection"`
+}
+
+// The payments are made at the beginning of each month, same as the
+// savings goal page. All the amounts follow the sign convention of the
+// spreadsheet financial functions, money paid is positive.
+
+func fv(rate float64, nper float64, pmt float64, pv float64) float64 {
+	if rate == 0 {
+		return -(pv + pmt*nper)
+	}
+	temp := math.Pow(1+rate, nper)
+	return -(pv*temp + pmt*(1+rate)/rate*(temp-1))
+}
+
+func pmt(rate float64, nper float64, pv float64, fv float64) float64 {
+	if rate == 0 {
+		return -(fv + pv) / nper
+	}
+	temp := math.Pow(1+rate, nper)
+	return -(fv + pv*temp) / ((1 + rate) / rate * (temp - 1))
+}
+
+func nper(rate float64, pmt float64, pv float64, fv float64) float64 {
+	if rate == 0 {
+		return -(fv + pv) / pmt
+	}
+	z := pmt * (1 + rate) / rate
+	return math.Log((-fv+z)/(pv+z)) / math.Log(1+rate)
+}
+
+// monthsBetween returns the number of whole months from start to end.
+func monthsBetween(start time.Time, end time.Time) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	if end.AddDate(0, -months, 0).Before(start) {
+		months--
+	}
+	return months
+}
+
+// monthlyContribution is the average amount invested per month in the
+// last year, or since the first investment if it's more recent.
+func monthlyContribution(savings []posting.Posting, now time.Time) float64 {
+	if len(savings) == 0 {
+		return 0
+	}
+
+	start := now.AddDate(-1, 0, 0)
+	first := lo.MinBy(savings, func(a posting.Posting, b posting.Posting) bool { return a.Date.Before(b.Date) }).Date
+	months := 12.0
+	if first.After(start) {
+		start = first
+		months = math.Max(1, math.Ceil(now.Sub(first).Hours()/24/30.4375))
+	}
+
+	recent := lo.Filter(savings, func(p posting.Posting, _ int) bool { return !p.Date.Before(start) && !p.Date.After(now) })
+	return accounting.CostSum(recent).InexactFloat64() / months
+}
+
+func projectSavings(conf config.SavingsGoal, savings []posting.Posting, savingsTotal decimal.Decimal) SavingsProjection {
+	now := utils.Now()
+	today := utils.BeginningOfMonth(now)
+	rate := conf.Rate / (100 * 12)
+	target := conf.Target
+	pv := savingsTotal.InexactFloat64()
+	pace := monthlyContribution(savings, now)
+
+	projection := SavingsProjection{
+		MonthlyContribution: decimal.NewFromFloat(pace).Round(2),
+		Projection:          []accounting.Point{},
+	}
+
+	// the date by which the goal is planned, either configured or
+	// derived from the payment per period
+	var targetDate time.Time
+	payment := conf.PaymentPerPeriod
+	if conf.TargetDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", conf.TargetDate, config.TimeZone())
+		if err == nil {
+			targetDate = date
+			if n := monthsBetween(today, targetDate); n > 0 {
+				payment = math.Max(0, pmt(rate, float64(n), pv, -target))
+				projection.RequiredContribution = decimal.NewFromFloat(payment).Round(2)
+			}
+		}
+	} else if payment > 0 {
+		n := nper(rate, payment, pv, -target)
+		if !math.IsNaN(n) && !math.IsInf(n, 0) && n >= 0 {
+			targetDate = today.AddDate(0, int(math.Ceil(n)), 0)
+		}
+	}
+
+	var expectedDate time.Time
+	if pv < target {
+		n := nper(rate, pace, pv, -target)
+		if !math.IsNaN(n) && !math.IsInf(n, 0) && n >= 0 {
+			expectedDate = today.AddDate(0, int(math.Ceil(n)), 0)
+			projection.ExpectedDate = expectedDate.Format("2006-01-02")
+		}
+	}
+
+	if !targetDate.IsZero() {
+		projection.TargetDate = targetDate.Format("2006-01-02")
+	}
+
+	switch {
+	case pv >= target:
+		projection.Status = Achieved
+	case !targetDate.IsZero():
+		projected := pv
+		if n := monthsBetween(today, targetDate); n > 0 {
+			projected = fv(rate, float64(n), -pace, -pv)
+		}
+		if projected >= target {
+			projection.Status = OnTrack
+		} else {
+			projection.Status = Behind
+			projection.Shortfall = decimal.NewFromFloat(target - projected).Round(2)
+		}
+	}
+
+	if pv >= target {
+		return projection
+	}
+
+	end := targetDate
+	if end.IsZero() {
+		end, payment = expectedDate, pace
+	}
+	if n := monthsBetween(today, end); n > 0 {
+		for i := 1; i <= n; i++ {
+			value := fv(rate, float64(i), -payment, -pv)
+			projection.Projection = append(projection.Projection, accounting.Point{Date: today.AddDate(0, i, 0), Value: decimal.NewFromFloat(value).Round(2)})
+		}
+	}
+
+	return projection
+}
@@ -0,0 +1,66 @@
+package goal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinancialFunctions(t *testing.T) {
+	rate := 0.1 / 12
+
+	payment := pmt(rate, 60, 100000, -1000000)
+	assert.InDelta(t, 10699.8416, payment, 0.0001)
+	assert.InDelta(t, 60, nper(rate, payment, 100000, -1000000), 0.0001)
+	assert.InDelta(t, 1000000, fv(rate, 60, -payment, -100000), 0.0001)
+
+	assert.InDelta(t, 15000, pmt(0, 60, 100000, -1000000), 0.0001)
+	assert.InDelta(t, 90, nper(0, 10000, 100000, -1000000), 0.0001)
+	assert.InDelta(t, 700000, fv(0, 60, -10000, -100000), 0.0001)
+}
+
+func TestMonthsBetween(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	assert.Equal(t, 12, monthsBetween(date("2023-01-01"), date("2024-01-01")))
+	assert.Equal(t, 11, monthsBetween(date("2023-01-15"), date("2024-01-14")))
+	assert.Equal(t, 0, monthsBetween(date("2023-01-15"), date("2023-01-31")))
+}
+
+func TestProjectSavings(t *testing.T) {
+	utils.SetNow("2024-01-15")
+
+	var savings []posting.Posting
+	for i := 1; i <= 12; i++ {
+		savings = append(savings, posting.Posting{Date: time.Date(2023, time.Month(i), 20, 0, 0, 0, 0, time.UTC), Amount: decimal.NewFromInt(10000)})
+	}
+
+	conf := config.SavingsGoal{Target: 250000, TargetDate: "2025-01-01"}
+	projection := projectSavings(conf, savings, decimal.NewFromInt(120000))
+	assert.Equal(t, "10000", projection.MonthlyContribution.String())
+	assert.Equal(t, "10833.33", projection.RequiredContribution.String())
+	assert.Equal(t, "2025-02-01", projection.ExpectedDate)
+	assert.Equal(t, Behind, projection.Status)
+	assert.Equal(t, "10000", projection.Shortfall.String())
+	assert.Len(t, projection.Projection, 12)
+	assert.Equal(t, "250000", projection.Projection[11].Value.String())
+
+	conf = config.SavingsGoal{Target: 220000, PaymentPerPeriod: 5000}
+	projection = projectSavings(conf, savings, decimal.NewFromInt(120000))
+	assert.Equal(t, "2025-09-01", projection.TargetDate)
+	assert.Equal(t, "2024-11-01", projection.ExpectedDate)
+	assert.Equal(t, OnTrack, projection.Status)
+	assert.Len(t, projection.Projection, 20)
+
+	conf = config.SavingsGoal{Target: 100000}
+	projection = projectSavings(conf, savings, decimal.NewFromInt(120000))
+	assert.Equal(t, Achieved, projection.Status)
+	assert.Empty(t, projection.Projection)
+}
@@ -51,6 +51,7 @@ func getSavingsDetail(db *gorm.DB, conf config.SavingsGoal) gin.H {
 		"targetDate":       conf.TargetDate,
 		"rate":             conf.Rate,
 		"paymentPerPeriod": conf.PaymentPerPeriod,
+		"projection":       projectSavings(conf, savings, savingsTotal),
 		"xirr":             service.XIRR(db, savingsWithCapitalGains),
 		"postings":         savingsWithCapitalGains,
 		"balances":         balances,
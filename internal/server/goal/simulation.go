@@ -0,0 +1,277 @@
+package goal
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/accounting"
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var simulationPercentiles = []int{10, 25, 50, 75, 90}
+
+type SimulationPath struct {
+	Percentile int                `json:"percentile"`
+	Points     []accounting.Point `json:"points"`
+}
+
+type simulationParams struct {
+	savings            float64
+	expectedReturn     float64
+	volatility         float64
+	inflation          float64
+	yearlyContribution float64
+	yearlyExpenses     float64
+	withdrawalRate     float64
+	accumulationYears  int
+	years              int
+	runs               int
+	seed               int64
+}
+
+// simulate runs the given number of yearly wealth paths. The returns
+// are drawn from a log normal distribution with the expected return
+// and volatility. Contributions are added till the retirement and the
+// withdrawals start after, both grow with inflation. A path fails if
+// the savings run out before the end.
+func simulate(p simulationParams) (float64, [][]float64) {
+	rng := rand.New(rand.NewSource(p.seed))
+
+	sigma := math.Sqrt(math.Log(1 + math.Pow(p.volatility, 2)/math.Pow(1+p.expectedReturn, 2)))
+	mu := math.Log(1+p.expectedReturn) - math.Pow(sigma, 2)/2
+
+	successful := 0
+	yearly := make([][]float64, p.years+1)
+	for year := range yearly {
+		yearly[year] = make([]float64, p.runs)
+	}
+
+	for run := 0; run < p.runs; run++ {
+		wealth := p.savings
+		withdrawal := 0.0
+		depleted := false
+		yearly[0][run] = wealth
+
+		for year := 1; year <= p.years; year++ {
+			growth := math.Exp(mu + sigma*rng.NormFloat64())
+			inflation := math.Pow(1+p.inflation, float64(year-1))
+
+			if year <= p.accumulationYears {
+				wealth = wealth*growth + p.yearlyContribution*inflation
+			} else {
+				if year == p.accumulationYears+1 {
+					if p.withdrawalRate > 0 {
+						withdrawal = wealth * p.withdrawalRate
+					} else {
+						withdrawal = p.yearlyExpenses * inflation
+					}
+				} else {
+					withdrawal = withdrawal * (1 + p.inflation)
+				}
+				wealth = (wealth - withdrawal) * growth
+			}
+
+			if wealth <= 0 {
+				wealth = 0
+				depleted = true
+			}
+			yearly[year][run] = wealth
+		}
+
+		if !depleted {
+			successful++
+		}
+	}
+
+	percentiles := make([][]float64, len(simulationPercentiles))
+	for i := range percentiles {
+		percentiles[i] = make([]float64, p.years+1)
+	}
+	for year, values := range yearly {
+		sort.Float64s(values)
+		for i, percentile := range simulationPercentiles {
+			percentiles[i][year] = values[(len(values)-1)*percentile/100]
+		}
+	}
+
+	return float64(successful) / float64(p.runs), percentiles
+}
+
+// historicalVolatility computes the annualized standard deviation of
+// the monthly returns of the savings, with each commodity weighted by
+// its current market value.
+func historicalVolatility(db *gorm.DB, savings []posting.Posting) float64 {
+	if len(savings) == 0 {
+		return 0
+	}
+
+	weights := make(map[string]float64)
+	total := 0.0
+	for commodity, ps := range lo.GroupBy(savings, func(p posting.Posting) string { return p.Commodity }) {
+		value := accounting.CurrentBalance(ps).InexactFloat64()
+		if value > 0 && !utils.IsCurrency(commodity) {
+			weights[commodity] = value
+		}
+		if value > 0 {
+			total += value
+		}
+	}
+
+	now := utils.Now()
+	start := utils.BeginningOfMonth(savings[0].Date)
+	if tenYearsAgo := utils.BeginningOfMonth(now.AddDate(-10, 0, 0)); start.Before(tenYearsAgo) {
+		start = tenYearsAgo
+	}
+
+	var returns []float64
+	for commodity, weight := range weights {
+		previous := 0.0
+		i := 0
+		for month := start; !month.After(now); month = month.AddDate(0, 1, 0) {
+			current := service.GetUnitPrice(db, commodity, utils.EndOfMonth(month)).Value.InexactFloat64()
+			if i >= len(returns) {
+				returns = append(returns, 0)
+			}
+			if previous > 0 && current > 0 {
+				returns[i] += (weight / total) * (current/previous - 1)
+			}
+			previous = current
+			i++
+		}
+	}
+
+	if len(returns) < 3 {
+		return 0
+	}
+	// the first month doesn't have a return
+	returns = returns[1:]
+
+	mean := lo.Sum(returns) / float64(len(returns))
+	variance := lo.SumBy(returns, func(r float64) float64 { return math.Pow(r-mean, 2) }) / float64(len(returns)-1)
+	return math.Sqrt(variance) * math.Sqrt(12)
+}
+
+func GetRetirementSimulation(db *gorm.DB, name string, params url.Values) (gin.H, error) {
+	conf, found := lo.Find(config.GetConfig().Goals.Retirement, func(conf config.RetirementGoal) bool { return conf.Name == name })
+	if !found {
+		return nil, fmt.Errorf("Goal %s not found", name)
+	}
+
+	savings := accounting.FilterByGlob(query.Init(db).Like("Assets:%").All(), conf.Savings)
+	savings = service.PopulateMarketPrice(db, savings)
+	savingsWithCapitalGains := accounting.FilterByGlob(query.Init(db).Like("Assets:%", "Income:CapitalGains:%").All(), conf.Savings)
+	savingsWithCapitalGains = service.PopulateMarketPrice(db, savingsWithCapitalGains)
+
+	yearlyExpenses := decimal.NewFromFloat(conf.YearlyExpenses)
+	if !(yearlyExpenses.GreaterThan(decimal.Zero)) {
+		yearlyExpenses = calculateAverageExpense(db, conf)
+	}
+
+	now := lo.Must(time.ParseInLocation("2006-01-02", utils.Now().Format("2006-01-02"), config.TimeZone()))
+	retirementDate := now
+	if conf.RetirementDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", conf.RetirementDate, config.TimeZone())
+		if err != nil {
+			return nil, err
+		}
+		retirementDate = date
+	}
+
+	years := conf.Years
+	if years <= 0 {
+		years = 30
+	}
+
+	p := simulationParams{
+		savings:            accounting.CurrentBalance(savings).InexactFloat64(),
+		expectedReturn:     service.XIRR(db, savingsWithCapitalGains).InexactFloat64() / 100,
+		volatility:         historicalVolatility(db, savings),
+		inflation:          conf.Inflation / 100,
+		yearlyContribution: conf.YearlyContribution,
+		yearlyExpenses:     yearlyExpenses.InexactFloat64(),
+		withdrawalRate:     conf.WithdrawalRate / 100,
+		years:              years,
+		runs:               1000,
+		seed:               1,
+	}
+
+	var err error
+	percentages := map[string]*float64{
+		"expected_return": &p.expectedReturn,
+		"volatility":      &p.volatility,
+		"inflation":       &p.inflation,
+		"withdrawal_rate": &p.withdrawalRate,
+	}
+	for key, value := range percentages {
+		if params.Has(key) {
+			if *value, err = strconv.ParseFloat(params.Get(key), 64); err != nil {
+				return nil, fmt.Errorf("Invalid %s: %s", key, params.Get(key))
+			}
+			*value = *value / 100
+		}
+	}
+	if params.Has("yearly_contribution") {
+		if p.yearlyContribution, err = strconv.ParseFloat(params.Get("yearly_contribution"), 64); err != nil {
+			return nil, fmt.Errorf("Invalid yearly_contribution: %s", params.Get("yearly_contribution"))
+		}
+	}
+	integers := map[string]*int{"years": &p.years, "runs": &p.runs}
+	for key, value := range integers {
+		if params.Has(key) {
+			if *value, err = strconv.Atoi(params.Get(key)); err != nil || *value <= 0 {
+				return nil, fmt.Errorf("Invalid %s: %s", key, params.Get(key))
+			}
+		}
+	}
+	if params.Has("retirement_date") {
+		if retirementDate, err = time.ParseInLocation("2006-01-02", params.Get("retirement_date"), config.TimeZone()); err != nil {
+			return nil, fmt.Errorf("Invalid retirement_date: %s", params.Get("retirement_date"))
+		}
+	}
+	if p.runs > 100000 || p.years > 100 {
+		return nil, fmt.Errorf("Simulation is limited to 100 years and 100000 runs")
+	}
+
+	if retirementDate.After(now) {
+		p.accumulationYears = int(math.Round(retirementDate.Sub(now).Hours() / 24 / 365.25))
+	}
+
+	successProbability, percentiles := simulate(p)
+	paths := lo.Map(percentiles, func(values []float64, i int) SimulationPath {
+		return SimulationPath{
+			Percentile: simulationPercentiles[i],
+			Points: lo.Map(values, func(value float64, year int) accounting.Point {
+				return accounting.Point{Date: now.AddDate(year, 0, 0), Value: decimal.NewFromFloat(value).Round(2)}
+			}),
+		}
+	})
+
+	return gin.H{
+		"name":               conf.Name,
+		"savingsTotal":       decimal.NewFromFloat(p.savings),
+		"expectedReturn":     p.expectedReturn * 100,
+		"volatility":         p.volatility * 100,
+		"inflation":          p.inflation * 100,
+		"yearlyContribution": p.yearlyContribution,
+		"yearlyExpense":      yearlyExpenses,
+		"withdrawalRate":     p.withdrawalRate * 100,
+		"retirementDate":     retirementDate,
+		"years":              p.years,
+		"runs":               p.runs,
+		"successProbability": successProbability * 100,
+		"paths":              paths,
+	}, nil
+}
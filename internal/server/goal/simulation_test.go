@@ -0,0 +1,66 @@
+package goal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateWithoutVolatility(t *testing.T) {
+	success, paths := simulate(simulationParams{
+		savings:            1000,
+		expectedReturn:     0.1,
+		inflation:          0,
+		yearlyContribution: 100,
+		yearlyExpenses:     150,
+		accumulationYears:  2,
+		years:              4,
+		runs:               10,
+		seed:               1,
+	})
+
+	assert.Equal(t, 1.0, success)
+	assert.Len(t, paths, len(simulationPercentiles))
+	for _, path := range paths {
+		assert.InDeltaSlice(t, []float64{1000, 1200, 1420, 1397, 1371.7}, path, 0.001)
+	}
+}
+
+func TestSimulateDepletion(t *testing.T) {
+	success, paths := simulate(simulationParams{
+		savings:        1000,
+		expectedReturn: 0,
+		inflation:      0.1,
+		withdrawalRate: 0.5,
+		years:          3,
+		runs:           10,
+		seed:           1,
+	})
+
+	assert.Equal(t, 0.0, success)
+	assert.InDeltaSlice(t, []float64{1000, 500, 0, 0}, paths[2], 0.001)
+}
+
+func TestSimulateVolatility(t *testing.T) {
+	params := simulationParams{
+		savings:        1000,
+		expectedReturn: 0.07,
+		volatility:     0.2,
+		yearlyExpenses: 60,
+		years:          30,
+		runs:           2000,
+		seed:           1,
+	}
+	success, paths := simulate(params)
+
+	assert.Greater(t, success, 0.5)
+	assert.Less(t, success, 1.0)
+	for year := range paths[0] {
+		for i := 1; i < len(paths); i++ {
+			assert.LessOrEqual(t, paths[i-1][year], paths[i][year])
+		}
+	}
+
+	again, _ := simulate(params)
+	assert.Equal(t, success, again)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ananthakumaran/paisa/internal/config"
 	c "github.com/ananthakumaran/paisa/internal/model/commodity"
 	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
 	"github.com/ananthakumaran/paisa/internal/query"
 	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/ananthakumaran/paisa/internal/taxation"
@@ -38,20 +39,158 @@ type Harvestable struct {
 	CurrentUnitDate       time.Time          `json:"current_unit_date"`
 }
 
-func GetHarvest(db *gorm.DB) gin.H {
+type HarvestPlanLot struct {
+	Account           string          `json:"account"`
+	Units             decimal.Decimal `json:"units"`
+	PurchaseDate      time.Time       `json:"purchase_date"`
+	PurchaseUnitPrice decimal.Decimal `json:"purchase_unit_price"`
+	CurrentUnitPrice  decimal.Decimal `json:"current_unit_price"`
+	Tax               taxation.Tax    `json:"tax"`
+}
+
+type HarvestPlan struct {
+	Exemption   taxation.Exemption `json:"exemption"`
+	Lots        []HarvestPlanLot   `json:"lots"`
+	Amount      decimal.Decimal    `json:"amount"`
+	TaxableGain decimal.Decimal    `json:"taxable_gain"`
+}
+
+type HarvestReport struct {
+	Harvestables map[string]Harvestable `json:"harvestables"`
+	HarvestPlan  HarvestPlan            `json:"harvest_plan"`
+}
+
+func GetHarvest(db *gorm.DB) (gin.H, error) {
+	report, err := harvestReport(db)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{"harvestables": report.Harvestables, "harvest_plan": report.HarvestPlan}, nil
+}
+
+func harvestReport(db *gorm.DB) (HarvestReport, error) {
+	regime, err := taxation.CurrentRegime()
+	if err != nil {
+		return HarvestReport{}, err
+	}
+	return HarvestReport{Harvestables: computeHarvestables(db, regime), HarvestPlan: computeHarvestPlan(db, regime)}, nil
+}
+
+func computeHarvestables(db *gorm.DB, regime *taxation.Regime) map[string]Harvestable {
 	commodities := lo.Filter(c.All(), func(c config.Commodity, _ int) bool {
 		return c.Harvest > 0
 	})
 	postings := query.Init(db).Like("Assets:%").Commodities(commodities).All()
 	byAccount := lo.GroupBy(postings, func(p posting.Posting) string { return p.Account })
-	harvestables := lo.MapValues(byAccount, func(postings []posting.Posting, account string) Harvestable {
-		return computeHarvestable(db, account, c.FindByName(postings[0].Commodity), postings)
+	return lo.MapValues(byAccount, func(postings []posting.Posting, account string) Harvestable {
+		return computeHarvestable(db, regime, account, c.FindByName(postings[0].Commodity), postings)
 	})
-	return gin.H{"harvestables": harvestables}
 }
 
-func computeHarvestable(db *gorm.DB, account string, commodity config.Commodity, postings []posting.Posting) Harvestable {
-	available := accounting.FIFO(postings)
+// computeHarvestPlan sells the long term lots, oldest first, till the
+// gain uses up the exemption left for the current financial year. The
+// lots of an account are sold in the order of its lot selection, so an
+// account is skipped from the first lot that is not yet eligible. A lot
+// at loss ahead of a lot at gain has to be sold first, its loss nets
+// against the gain and frees up the exemption. The lots at loss after
+// the last lot at gain are left out of the plan.
+func computeHarvestPlan(db *gorm.DB, regime *taxation.Regime) HarvestPlan {
+	today := utils.EndOfToday()
+	fy := utils.FY(today)
+	exemption, ok := computeExemptions(regime, computeAllCapitalGains(db, regime))[fy]
+	if !ok {
+		exemption = regime.NewExemption(today)
+	}
+
+	plan := HarvestPlan{Exemption: exemption, Lots: []HarvestPlanLot{}}
+
+	type queue struct {
+		account      string
+		commodity    config.Commodity
+		currentPrice price.Price
+		lots         []posting.Posting
+	}
+
+	var queues []*queue
+	postings := query.Init(db).Like("Assets:%").Commodities(taxableCommodities()).All()
+	for account, ps := range lo.GroupBy(postings, func(p posting.Posting) string { return p.Account }) {
+		commodity := c.FindByName(ps[0].Commodity)
+		currentPrice := service.GetUnitPrice(db, commodity.Name, today)
+		if currentPrice.Value.IsZero() {
+			continue
+		}
+
+		cutoff := utils.Now().AddDate(0, 0, -commodity.Harvest)
+		var lots []posting.Posting
+		for _, p := range accounting.SaleOrder(accounting.Lots(ps), accounting.LotSelection(commodity.Name)) {
+			_, eligible := regime.ExemptionRule(commodity.TaxCategory, p.Date, currentPrice.Date)
+			if !eligible || !p.Date.Before(cutoff) {
+				break
+			}
+			lots = append(lots, p)
+		}
+
+		for len(lots) > 0 {
+			last := lots[len(lots)-1]
+			if regime.Calculate(db, last.Quantity, commodity, last.Price(), last.Date, currentPrice.Value, currentPrice.Date).Taxable.IsPositive() {
+				break
+			}
+			lots = lots[:len(lots)-1]
+		}
+
+		if len(lots) > 0 {
+			queues = append(queues, &queue{account: account, commodity: commodity, currentPrice: currentPrice, lots: lots})
+		}
+	}
+
+	remaining := exemption.Remaining
+	for remaining.GreaterThan(decimal.Zero) {
+		queues = lo.Filter(queues, func(q *queue, _ int) bool { return len(q.lots) > 0 })
+		if len(queues) == 0 {
+			break
+		}
+
+		q := lo.MinBy(queues, func(a *queue, b *queue) bool {
+			return a.lots[0].Date.Before(b.lots[0].Date) || a.lots[0].Date.Equal(b.lots[0].Date) && a.account < b.account
+		})
+		lot := q.lots[0]
+		q.lots = q.lots[1:]
+
+		units := lot.Quantity
+		tax := regime.Calculate(db, units, q.commodity, lot.Price(), lot.Date, q.currentPrice.Value, q.currentPrice.Date)
+		if tax.Taxable.GreaterThan(remaining) {
+			units = units.Mul(remaining).Div(tax.Taxable)
+			if q.commodity.Type == config.Stock {
+				units = units.Floor()
+			} else {
+				units = units.Truncate(3)
+			}
+
+			q.lots = nil
+			if units.IsZero() {
+				continue
+			}
+			tax = regime.Calculate(db, units, q.commodity, lot.Price(), lot.Date, q.currentPrice.Value, q.currentPrice.Date)
+		}
+
+		remaining = remaining.Sub(tax.Taxable)
+		plan.Amount = plan.Amount.Add(q.currentPrice.Value.Mul(units))
+		plan.TaxableGain = plan.TaxableGain.Add(tax.Taxable)
+		plan.Lots = append(plan.Lots, HarvestPlanLot{
+			Account:           q.account,
+			Units:             units,
+			PurchaseDate:      lot.Date,
+			PurchaseUnitPrice: lot.Price(),
+			CurrentUnitPrice:  q.currentPrice.Value,
+			Tax:               tax,
+		})
+	}
+
+	return plan
+}
+
+func computeHarvestable(db *gorm.DB, regime *taxation.Regime, account string, commodity config.Commodity, postings []posting.Posting) Harvestable {
+	available := accounting.SaleOrder(accounting.Lots(postings), accounting.LotSelection(commodity.Name))
 
 	today := utils.EndOfToday()
 	currentPrice := service.GetUnitPrice(db, commodity.Name, today)
@@ -61,7 +200,7 @@ func computeHarvestable(db *gorm.DB, account string, commodity config.Commodity,
 	for _, p := range available {
 		harvestable.TotalUnits = harvestable.TotalUnits.Add(p.Quantity)
 		if p.Date.Before(cutoff) {
-			tax := taxation.Calculate(db, p.Quantity, commodity, p.Price(), p.Date, currentPrice.Value, currentPrice.Date)
+			tax := regime.Calculate(db, p.Quantity, commodity, p.Price(), p.Date, currentPrice.Value, currentPrice.Date)
 			harvestable.HarvestableUnits = harvestable.HarvestableUnits.Add(p.Quantity)
 			harvestable.UnrealizedGain = harvestable.UnrealizedGain.Add(tax.Gain)
 			harvestable.TaxableUnrealizedGain = harvestable.TaxableUnrealizedGain.Add(tax.Taxable)
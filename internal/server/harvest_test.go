@@ -0,0 +1,71 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/ananthakumaran/paisa/internal/taxation"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestHarvestPlanSellsLotsInOrder(t *testing.T) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+commodities:
+  - name: ABC
+    type: stock
+    harvest: 365
+    tax_category: equity
+    price:
+      provider: com-yahoo
+      code: ABC
+`), "/tmp/paisa.yaml"))
+	service.ClearPriceCache()
+	t.Cleanup(service.ClearPriceCache)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	model.AutoMigrate(db)
+
+	today := utils.BeginningOfDay(utils.Now())
+	lots := []struct {
+		date  int
+		units int64
+		price int64
+	}{
+		{-800, 100, 2000},
+		{-600, 100, 1000},
+		{-500, 100, 1800},
+	}
+	for _, lot := range lots {
+		date := today.AddDate(0, 0, lot.date)
+		amount := decimal.NewFromInt(lot.units * lot.price)
+		require.NoError(t, db.Create(&posting.Posting{TransactionID: date.String(), Date: date, Account: "Assets:Equity:ABC", Commodity: "ABC", Quantity: decimal.NewFromInt(lot.units), Amount: amount, FileName: "main.ledger"}).Error)
+		require.NoError(t, db.Create(&posting.Posting{TransactionID: date.String(), Date: date, Account: "Assets:Checking", Commodity: "INR", Quantity: amount.Neg(), Amount: amount.Neg(), FileName: "main.ledger"}).Error)
+	}
+	require.NoError(t, db.Create(&price.Price{Date: today, CommodityType: config.Stock, CommodityID: "ABC", CommodityName: "ABC", Value: decimal.NewFromInt(1500)}).Error)
+
+	regime, err := taxation.CurrentRegime()
+	require.NoError(t, err)
+	plan := computeHarvestPlan(db, regime)
+
+	require.Len(t, plan.Lots, 2)
+	assert.True(t, today.AddDate(0, 0, -800).Equal(plan.Lots[0].PurchaseDate))
+	assert.Equal(t, "100", plan.Lots[0].Units.String())
+	assert.Equal(t, "-50000", plan.Lots[0].Tax.Taxable.String())
+	assert.True(t, today.AddDate(0, 0, -600).Equal(plan.Lots[1].PurchaseDate))
+	assert.Equal(t, "100", plan.Lots[1].Units.String())
+	assert.Equal(t, "0", plan.TaxableGain.String())
+	assert.Equal(t, "300000", plan.Amount.String())
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/ananthakumaran/paisa/internal/importer"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Import(db *gorm.DB, c *gin.Context) (int, gin.H) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+
+	result, err := importer.Import(db, c.PostForm("template"), header.Filename, data, c.PostForm("reverse") == "true")
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+
+	return http.StatusOK, gin.H{"transactions": result.Transactions, "duplicates": result.Duplicates, "ledger": result.Ledger(false)}
+}
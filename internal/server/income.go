@@ -5,6 +5,7 @@ import (
 
 	"github.com/ananthakumaran/paisa/internal/model/posting"
 	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/ananthakumaran/paisa/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
@@ -31,16 +32,38 @@ type Tax struct {
 	Postings  []posting.Posting `json:"postings"`
 }
 
-func GetIncome(db *gorm.DB) gin.H {
-	incomePostings := query.Init(db).Like("Income:%").All()
-	taxPostings := query.Init(db).AccountPrefix("Expenses:Tax").All()
+type IncomeReport struct {
+	Currency       string             `json:"currency"`
+	IncomeTimeline []Income           `json:"income_timeline"`
+	TaxTimeline    []Tax              `json:"tax_timeline"`
+	YearlyCards    []IncomeYearlyCard `json:"yearly_cards"`
+}
+
+func GetIncome(db *gorm.DB, converter *service.Converter) gin.H {
+	report, ok := incomeReport(db, converter)
+	if !ok {
+		return gin.H{"income_timeline": report.IncomeTimeline, "tax_timeline": report.TaxTimeline, "yearly_cards": report.YearlyCards}
+	}
+
+	return gin.H{"income_timeline": report.IncomeTimeline, "tax_timeline": report.TaxTimeline, "yearly_cards": report.YearlyCards, "currency": report.Currency}
+}
+
+// incomeReport returns false if the journal is empty
+func incomeReport(db *gorm.DB, converter *service.Converter) (IncomeReport, bool) {
+	incomePostings := converter.Postings(query.Init(db).Like("Income:%").All())
+	taxPostings := converter.Postings(query.Init(db).AccountPrefix("Expenses:Tax").All())
 	p := query.Init(db).First()
 
 	if p == nil {
-		return gin.H{"income_timeline": []Income{}, "tax_timeline": []Tax{}, "yearly_cards": []IncomeYearlyCard{}}
+		return IncomeReport{Currency: converter.Currency, IncomeTimeline: []Income{}, TaxTimeline: []Tax{}, YearlyCards: []IncomeYearlyCard{}}, false
 	}
 
-	return gin.H{"income_timeline": computeIncomeTimeline(incomePostings), "tax_timeline": computeTaxTimeline(taxPostings), "yearly_cards": computeIncomeYearlyCard(p.Date, taxPostings, incomePostings)}
+	return IncomeReport{
+		Currency:       converter.Currency,
+		IncomeTimeline: computeIncomeTimeline(incomePostings),
+		TaxTimeline:    computeTaxTimeline(taxPostings),
+		YearlyCards:    computeIncomeYearlyCard(p.Date, taxPostings, incomePostings),
+	}, true
 }
 
 func computeIncomeTimeline(postings []posting.Posting) []Income {
@@ -33,13 +33,27 @@ type RunningBalance struct {
 	quantity map[string]decimal.Decimal
 }
 
-func GetIncomeStatement(db *gorm.DB) gin.H {
+type IncomeStatementReport struct {
+	Currency string                     `json:"currency"`
+	Yearly   map[string]IncomeStatement `json:"yearly"`
+}
+
+func GetIncomeStatement(db *gorm.DB, converter *service.Converter) gin.H {
+	report := incomeStatementReport(db, converter)
+	return gin.H{"yearly": report.Yearly, "currency": report.Currency}
+}
+
+func incomeStatementReport(db *gorm.DB, converter *service.Converter) IncomeStatementReport {
 	postings := query.Init(db).All()
-	statements := computeStatement(db, postings)
-	return gin.H{"yearly": statements}
+	return IncomeStatementReport{Currency: converter.Currency, Yearly: computeStatement(db, postings, converter)}
 }
 
-func computeStatement(db *gorm.DB, postings []posting.Posting) map[string]IncomeStatement {
+// computeStatement converts the flows on the transaction date and the
+// balances on the last day of the year, so the exchange rate movement
+// shows up in the pnl of the account.
+func computeStatement(db *gorm.DB, postings []posting.Posting, converter *service.Converter) map[string]IncomeStatement {
+	postings = converter.Postings(postings)
+
 	statements := make(map[string]IncomeStatement)
 
 	grouped := utils.GroupByFY(postings)
@@ -107,7 +121,11 @@ func computeStatement(db *gorm.DB, postings []posting.Posting) map[string]Income
 		for account, r := range runnings {
 			diff := r.amount.Neg()
 			for commodity, quantity := range r.quantity {
-				diff = diff.Add(service.GetPrice(db, commodity, quantity, end))
+				if commodity == converter.Currency {
+					diff = diff.Add(quantity)
+				} else {
+					diff = diff.Add(converter.Convert(service.GetPrice(db, commodity, quantity, end), end))
+				}
 			}
 			incomeStatement.Pnl[account] = diff
 
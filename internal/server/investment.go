@@ -28,7 +28,17 @@ type InvestmentYearlyCard struct {
 	SavingsRate       decimal.Decimal   `json:"savings_rate"`
 }
 
+type InvestmentReport struct {
+	Assets      []posting.Posting      `json:"assets"`
+	YearlyCards []InvestmentYearlyCard `json:"yearly_cards"`
+}
+
 func GetInvestment(db *gorm.DB) gin.H {
+	report := investmentReport(db)
+	return gin.H{"assets": report.Assets, "yearly_cards": report.YearlyCards}
+}
+
+func investmentReport(db *gorm.DB) InvestmentReport {
 	assets := query.Init(db).Like("Assets:%").NotAccountPrefix("Assets:Checking").
 		Where("transaction_id not in (select transaction_id from postings p where p.account like ? and p.transaction_id = transaction_id)", "Liabilities:%").
 		All()
@@ -37,11 +47,11 @@ func GetInvestment(db *gorm.DB) gin.H {
 	p := query.Init(db).First()
 
 	if p == nil {
-		return gin.H{"assets": []posting.Posting{}, "yearly_cards": []InvestmentYearlyCard{}}
+		return InvestmentReport{Assets: []posting.Posting{}, YearlyCards: []InvestmentYearlyCard{}}
 	}
 
 	assets = lo.Filter(assets, func(p posting.Posting, _ int) bool { return !service.IsStockSplit(db, p) })
-	return gin.H{"assets": assets, "yearly_cards": computeInvestmentYearlyCard(p.Date, assets, expenses, incomes)}
+	return InvestmentReport{Assets: assets, YearlyCards: computeInvestmentYearlyCard(p.Date, assets, expenses, incomes)}
 }
 
 func computeInvestmentYearlyCard(start time.Time, assets []posting.Posting, expenses []posting.Posting, incomes []posting.Posting) []InvestmentYearlyCard {
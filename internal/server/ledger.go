@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/ananthakumaran/paisa/internal/accounting"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
 	"github.com/ananthakumaran/paisa/internal/query"
 	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/gin-gonic/gin"
@@ -9,9 +10,13 @@ import (
 )
 
 func GetLedger(db *gorm.DB) gin.H {
+	return gin.H{"postings": ledgerPostings(db)}
+}
+
+func ledgerPostings(db *gorm.DB) []posting.Posting {
 	postings := query.Init(db).Desc().All()
 	postings = service.PopulateMarketPrice(db, postings)
 	postings = accounting.PopulateBalance(postings)
 	accounting.SortDesc(postings)
-	return gin.H{"postings": postings}
+	return postings
 }
@@ -0,0 +1,174 @@
+package liabilities
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ReduceEMI    = "reduce_emi"
+	ReduceTenure = "reduce_tenure"
+)
+
+// maxInstallments guards against the EMI that doesn't cover the
+// interest, in which case the loan would never be repaid
+const maxInstallments = 1200
+
+type Installment struct {
+	Number         int             `json:"number"`
+	Date           time.Time       `json:"date"`
+	Rate           decimal.Decimal `json:"rate"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	EMI            decimal.Decimal `json:"emi"`
+	Interest       decimal.Decimal `json:"interest"`
+	Principal      decimal.Decimal `json:"principal"`
+	Prepayment     decimal.Decimal `json:"prepayment"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+}
+
+type RateChange struct {
+	Date time.Time
+	Rate decimal.Decimal
+	EMI  decimal.Decimal
+}
+
+type Prepayment struct {
+	Date   time.Time       `json:"date"`
+	Amount decimal.Decimal `json:"amount"`
+	Mode   string          `json:"mode"`
+}
+
+type LoanTerms struct {
+	Principal   decimal.Decimal
+	Rate        decimal.Decimal
+	StartDate   time.Time
+	Tenure      int
+	EMI         decimal.Decimal
+	RateChanges []RateChange
+}
+
+type Schedule struct {
+	EMI           decimal.Decimal `json:"emi"`
+	EndDate       time.Time       `json:"end_date"`
+	Tenure        int             `json:"tenure"`
+	TotalInterest decimal.Decimal `json:"total_interest"`
+	TotalPayment  decimal.Decimal `json:"total_payment"`
+	Installments  []Installment   `json:"installments"`
+}
+
+func monthlyRate(rate decimal.Decimal) decimal.Decimal {
+	return rate.Div(decimal.NewFromInt(1200))
+}
+
+// computeEMI returns the monthly installment that repays the balance in
+// the given number of months
+func computeEMI(balance decimal.Decimal, rate decimal.Decimal, months int) decimal.Decimal {
+	if months <= 0 {
+		return balance
+	}
+
+	r := monthlyRate(rate)
+	if r.IsZero() {
+		return balance.Div(decimal.NewFromInt(int64(months))).RoundUp(2)
+	}
+
+	factor := decimal.NewFromInt(1).Add(r).Pow(decimal.NewFromInt(int64(months)))
+	return balance.Mul(r).Mul(factor).Div(factor.Sub(decimal.NewFromInt(1))).RoundUp(2)
+}
+
+// remainingMonths returns the number of installments needed to repay
+// the balance with the given EMI
+func remainingMonths(balance decimal.Decimal, rate decimal.Decimal, emi decimal.Decimal) int {
+	if !balance.IsPositive() {
+		return 0
+	}
+
+	r := monthlyRate(rate).InexactFloat64()
+	if r == 0 {
+		return int(balance.Div(emi).Ceil().IntPart())
+	}
+
+	x := 1 - balance.InexactFloat64()*r/emi.InexactFloat64()
+	if x <= 0 {
+		return maxInstallments
+	}
+	return int(math.Ceil(-math.Log(x)/math.Log(1+r) - 1e-9))
+}
+
+// computeSchedule builds the monthly amortization schedule. The interest
+// is charged monthly on the opening balance, the rate changes apply from
+// the first installment on or after their date and the prepayments are
+// applied after the installment of the month.
+func computeSchedule(terms LoanTerms, prepayments []Prepayment) (Schedule, error) {
+	rate := terms.Rate
+	emi := terms.EMI
+	if !emi.IsPositive() {
+		emi = computeEMI(terms.Principal, rate, terms.Tenure)
+	}
+
+	schedule := Schedule{EMI: emi, Installments: []Installment{}}
+	balance := terms.Principal
+	changes := terms.RateChanges
+	previous := terms.StartDate
+
+	for n := 1; balance.IsPositive(); n++ {
+		if n > maxInstallments {
+			return schedule, errors.New("The EMI doesn't cover the interest, the loan will never be repaid")
+		}
+
+		date := terms.StartDate.AddDate(0, n, 0)
+		for len(changes) > 0 && !changes[0].Date.After(date) {
+			rate = changes[0].Rate
+			if changes[0].EMI.IsPositive() {
+				emi = changes[0].EMI
+			}
+			changes = changes[1:]
+		}
+
+		installment := Installment{Number: n, Date: date, Rate: rate, OpeningBalance: balance}
+		installment.Interest = balance.Mul(monthlyRate(rate)).Round(2)
+		installment.EMI = decimal.Min(emi, balance.Add(installment.Interest))
+		installment.Principal = installment.EMI.Sub(installment.Interest)
+		balance = balance.Sub(installment.Principal)
+
+		for _, prepayment := range prepayments {
+			if prepayment.Date.After(previous) && !prepayment.Date.After(date) && balance.IsPositive() {
+				amount := decimal.Min(prepayment.Amount, balance)
+				remaining := remainingMonths(balance, rate, emi)
+				installment.Prepayment = installment.Prepayment.Add(amount)
+				balance = balance.Sub(amount)
+				if prepayment.Mode == ReduceEMI && balance.IsPositive() {
+					emi = computeEMI(balance, rate, remaining)
+				}
+			}
+		}
+
+		installment.ClosingBalance = balance
+		schedule.TotalInterest = schedule.TotalInterest.Add(installment.Interest)
+		schedule.TotalPayment = schedule.TotalPayment.Add(installment.EMI).Add(installment.Prepayment)
+		schedule.Installments = append(schedule.Installments, installment)
+		previous = date
+	}
+
+	schedule.Tenure = len(schedule.Installments)
+	if schedule.Tenure > 0 {
+		schedule.EndDate = schedule.Installments[schedule.Tenure-1].Date
+	}
+	return schedule, nil
+}
+
+// scheduledBalance returns the outstanding principal as per the
+// schedule on the given date
+func scheduledBalance(principal decimal.Decimal, schedule Schedule, date time.Time) decimal.Decimal {
+	balance := principal
+	for _, installment := range schedule.Installments {
+		if installment.Date.After(date) {
+			break
+		}
+		balance = installment.ClosingBalance
+	}
+	return balance
+}
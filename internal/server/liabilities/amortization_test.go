@@ -0,0 +1,87 @@
+package liabilities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func terms() LoanTerms {
+	return LoanTerms{
+		Principal: decimal.NewFromInt(1000000),
+		Rate:      decimal.NewFromInt(12),
+		StartDate: date("2023-01-05"),
+		Tenure:    12,
+	}
+}
+
+func TestComputeEMI(t *testing.T) {
+	assert.Equal(t, "88848.79", computeEMI(decimal.NewFromInt(1000000), decimal.NewFromInt(12), 12).String())
+	assert.Equal(t, "100", computeEMI(decimal.NewFromInt(1200), decimal.Zero, 12).String())
+	assert.Equal(t, 12, remainingMonths(decimal.NewFromInt(1000000), decimal.NewFromInt(12), decimal.RequireFromString("88848.79")))
+	assert.Equal(t, 0, remainingMonths(decimal.Zero, decimal.NewFromInt(12), decimal.NewFromInt(100)))
+}
+
+func TestComputeSchedule(t *testing.T) {
+	schedule, err := computeSchedule(terms(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 12, schedule.Tenure)
+	assert.Equal(t, date("2024-01-05"), schedule.EndDate)
+
+	first := schedule.Installments[0]
+	assert.Equal(t, date("2023-02-05"), first.Date)
+	assert.Equal(t, "10000", first.Interest.String())
+	assert.Equal(t, "78848.79", first.Principal.String())
+	assert.True(t, schedule.Installments[11].ClosingBalance.IsZero())
+	assert.True(t, schedule.TotalPayment.Sub(schedule.TotalInterest).Equal(decimal.NewFromInt(1000000)))
+
+	assert.Equal(t, "921151.21", scheduledBalance(decimal.NewFromInt(1000000), schedule, date("2023-03-01")).String())
+	assert.Equal(t, "1000000", scheduledBalance(decimal.NewFromInt(1000000), schedule, date("2023-01-31")).String())
+}
+
+func TestComputeScheduleRateChange(t *testing.T) {
+	loan := terms()
+	loan.RateChanges = []RateChange{{Date: date("2023-04-01"), Rate: decimal.NewFromInt(18)}}
+	schedule, err := computeSchedule(loan, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 13, schedule.Tenure)
+	assert.Equal(t, "12", schedule.Installments[1].Rate.String())
+	assert.Equal(t, "18", schedule.Installments[2].Rate.String())
+
+	loan.RateChanges[0].EMI = decimal.NewFromInt(150000)
+	schedule, err = computeSchedule(loan, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8, schedule.Tenure)
+
+	loan.RateChanges = []RateChange{{Date: date("2023-04-01"), Rate: decimal.NewFromInt(240)}}
+	_, err = computeSchedule(loan, nil)
+	assert.Error(t, err)
+}
+
+func TestComputeSchedulePrepayment(t *testing.T) {
+	current, err := computeSchedule(terms(), nil)
+	require.NoError(t, err)
+
+	prepayment := Prepayment{Date: date("2023-03-01"), Amount: decimal.NewFromInt(200000), Mode: ReduceTenure}
+	tenure, err := computeSchedule(terms(), []Prepayment{prepayment})
+	require.NoError(t, err)
+	assert.Equal(t, 10, tenure.Tenure)
+	assert.Equal(t, "200000", tenure.Installments[1].Prepayment.String())
+	assert.True(t, tenure.TotalInterest.LessThan(current.TotalInterest))
+
+	prepayment.Mode = ReduceEMI
+	emi, err := computeSchedule(terms(), []Prepayment{prepayment})
+	require.NoError(t, err)
+	assert.Equal(t, 12, emi.Tenure)
+	assert.True(t, emi.Installments[2].EMI.LessThan(current.EMI))
+	assert.True(t, emi.TotalInterest.LessThan(current.TotalInterest))
+	assert.True(t, tenure.TotalInterest.LessThan(emi.TotalInterest))
+}
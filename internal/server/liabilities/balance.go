@@ -25,11 +25,16 @@ type AssetBreakdown struct {
 }
 
 func GetBalance(db *gorm.DB) gin.H {
+	return gin.H{"liability_breakdowns": Breakdowns(db)}
+}
+
+// Breakdowns returns the balance of each liability account and its
+// parents
+func Breakdowns(db *gorm.DB) map[string]AssetBreakdown {
 	postings := query.Init(db).Like("Liabilities:%").All()
 	expenses := query.Init(db).Like("Expenses:Interest:%").All()
 	postings = service.PopulateMarketPrice(db, postings)
-	breakdowns := computeBreakdown(db, postings, expenses)
-	return gin.H{"liability_breakdowns": breakdowns}
+	return computeBreakdown(db, postings, expenses)
 }
 
 func computeBreakdown(db *gorm.DB, postings, expenses []posting.Posting) map[string]AssetBreakdown {
@@ -28,6 +28,12 @@ type Interest struct {
 }
 
 func GetInterest(db *gorm.DB) gin.H {
+	return gin.H{"interest_timeline_breakdown": Interests(db)}
+}
+
+// Interests returns the daily timeline of the drawn, repaid and the
+// interest amount of each liability account
+func Interests(db *gorm.DB) []Interest {
 	postings := query.Init(db).Like("Liabilities:%").All()
 	expenses := query.Init(db).Like("Expenses:Interest:%").All()
 	postings = service.PopulateMarketPrice(db, postings)
@@ -38,8 +44,7 @@ func GetInterest(db *gorm.DB) gin.H {
 		ps = append(ps, es...)
 		interests = append(interests, Interest{Account: "Liabilities:" + account, APR: service.APR(db, ps), OverviewTimeline: computeOverviewTimeline(db, ps)})
 	}
-
-	return gin.H{"interest_timeline_breakdown": interests}
+	return interests
 }
 
 func computeOverviewTimeline(db *gorm.DB, postings []posting.Posting) []Overview {
@@ -0,0 +1,279 @@
+package liabilities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type LoanPayment struct {
+	Date             time.Time       `json:"date"`
+	Scheduled        decimal.Decimal `json:"scheduled"`
+	Actual           decimal.Decimal `json:"actual"`
+	Difference       decimal.Decimal `json:"difference"`
+	ScheduledBalance decimal.Decimal `json:"scheduled_balance"`
+	ActualBalance    decimal.Decimal `json:"actual_balance"`
+}
+
+type Outstanding struct {
+	Date      time.Time       `json:"date"`
+	Scheduled decimal.Decimal `json:"scheduled"`
+	Actual    decimal.Decimal `json:"actual"`
+}
+
+type Loan struct {
+	Account     string          `json:"account"`
+	Principal   decimal.Decimal `json:"principal"`
+	Rate        decimal.Decimal `json:"rate"`
+	StartDate   time.Time       `json:"start_date"`
+	Schedule    Schedule        `json:"schedule"`
+	Payments    []LoanPayment   `json:"payments"`
+	Outstanding Outstanding     `json:"outstanding"`
+}
+
+type PrepaymentRequest struct {
+	Date   string          `json:"date"`
+	Amount decimal.Decimal `json:"amount"`
+	Mode   string          `json:"mode"`
+}
+
+type PrepaymentSimulation struct {
+	Prepayment    Prepayment      `json:"prepayment"`
+	Current       Schedule        `json:"current"`
+	Simulated     Schedule        `json:"simulated"`
+	InterestSaved decimal.Decimal `json:"interest_saved"`
+	MonthsSaved   int             `json:"months_saved"`
+}
+
+func GetLoans(db *gorm.DB) gin.H {
+	loans, err := BuildLoans(db)
+	if err != nil {
+		return gin.H{"success": false, "message": err.Error()}
+	}
+	return gin.H{"success": true, "loans": loans}
+}
+
+// BuildLoans computes the schedule of all the loans in the config
+func BuildLoans(db *gorm.DB) ([]Loan, error) {
+	loans := []Loan{}
+	for _, loanConfig := range config.GetConfig().Loans {
+		loan, err := buildLoan(db, loanConfig, utils.EndOfToday())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", loanConfig.Account, err.Error())
+		}
+		loans = append(loans, loan)
+	}
+	return loans, nil
+}
+
+func GetLoan(db *gorm.DB, account string, date string) gin.H {
+	loan, found, err := FindLoan(db, account, date)
+	if !found {
+		return gin.H{"found": false}
+	}
+	if err != nil {
+		return gin.H{"found": true, "success": false, "message": err.Error()}
+	}
+	return gin.H{"found": true, "success": true, "loan": loan}
+}
+
+func SimulatePrepayment(account string, request PrepaymentRequest) gin.H {
+	simulation, found, err := Simulate(account, request)
+	if !found {
+		return gin.H{"found": false}
+	}
+	if err != nil {
+		return gin.H{"found": true, "success": false, "message": err.Error()}
+	}
+	return gin.H{"found": true, "success": true, "simulation": simulation}
+}
+
+// FindLoan computes the schedule of the loan with the outstanding
+// principal on the given date, defaults to today
+func FindLoan(db *gorm.DB, account string, date string) (Loan, bool, error) {
+	loanConfig, found := findLoan(account)
+	if !found {
+		return Loan{}, false, nil
+	}
+
+	at := utils.EndOfToday()
+	if date != "" {
+		d, err := time.ParseInLocation("2006-01-02", date, config.TimeZone())
+		if err != nil {
+			return Loan{}, true, fmt.Errorf("Invalid date %s", date)
+		}
+		at = utils.EndOfDay(d)
+	}
+
+	loan, err := buildLoan(db, loanConfig, at)
+	return loan, true, err
+}
+
+func Simulate(account string, request PrepaymentRequest) (PrepaymentSimulation, bool, error) {
+	loanConfig, found := findLoan(account)
+	if !found {
+		return PrepaymentSimulation{}, false, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", request.Date, config.TimeZone())
+	if err != nil {
+		return PrepaymentSimulation{}, true, fmt.Errorf("Invalid date %s", request.Date)
+	}
+
+	prepayment := Prepayment{Date: date, Amount: request.Amount, Mode: request.Mode}
+	simulation, err := simulatePrepayment(loanConfig, prepayment)
+	return simulation, true, err
+}
+
+func findLoan(account string) (config.Loan, bool) {
+	return lo.Find(config.GetConfig().Loans, func(loan config.Loan) bool {
+		return loan.Account == account
+	})
+}
+
+func parseLoanTerms(loanConfig config.Loan) (LoanTerms, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", loanConfig.StartDate, config.TimeZone())
+	if err != nil {
+		return LoanTerms{}, err
+	}
+
+	changes := []RateChange{}
+	for _, change := range loanConfig.RateChanges {
+		date, err := time.ParseInLocation("2006-01-02", change.Date, config.TimeZone())
+		if err != nil {
+			return LoanTerms{}, err
+		}
+		changes = append(changes, RateChange{Date: date, Rate: decimal.NewFromFloat(change.Rate), EMI: decimal.NewFromFloat(change.EMI)})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Date.Before(changes[j].Date) })
+
+	return LoanTerms{
+		Principal:   decimal.NewFromFloat(loanConfig.Principal),
+		Rate:        decimal.NewFromFloat(loanConfig.Rate),
+		StartDate:   startDate,
+		Tenure:      loanConfig.Tenure,
+		EMI:         decimal.NewFromFloat(loanConfig.EMI),
+		RateChanges: changes,
+	}, nil
+}
+
+func buildLoan(db *gorm.DB, loanConfig config.Loan, date time.Time) (Loan, error) {
+	terms, err := parseLoanTerms(loanConfig)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	schedule, err := computeSchedule(terms, nil)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	postings := query.Init(db).Where("account = ?", loanConfig.Account).All()
+	rate := terms.Rate
+	for _, change := range terms.RateChanges {
+		if !change.Date.After(date) {
+			rate = change.Rate
+		}
+	}
+
+	return Loan{
+		Account:   loanConfig.Account,
+		Principal: terms.Principal,
+		Rate:      rate,
+		StartDate: terms.StartDate,
+		Schedule:  schedule,
+		Payments:  comparePayments(terms, schedule, postings, date),
+		Outstanding: Outstanding{
+			Date:      date,
+			Scheduled: scheduledBalance(terms.Principal, schedule, date),
+			Actual:    ledgerBalance(postings, date),
+		},
+	}, nil
+}
+
+// ledgerBalance returns the outstanding amount of the loan account as
+// per the journal
+func ledgerBalance(postings []posting.Posting, date time.Time) decimal.Decimal {
+	balance := decimal.Zero
+	for _, p := range postings {
+		if !p.Date.After(date) {
+			balance = balance.Add(p.Amount.Neg())
+		}
+	}
+	return balance
+}
+
+// comparePayments compares the payments made to the loan account with
+// the installments due till the given date. A payment is matched to the
+// installment if it's made within a month before the due date.
+func comparePayments(terms LoanTerms, schedule Schedule, postings []posting.Posting, date time.Time) []LoanPayment {
+	payments := []LoanPayment{}
+	previous := terms.StartDate
+	for _, installment := range schedule.Installments {
+		if installment.Date.After(date) {
+			break
+		}
+
+		actual := decimal.Zero
+		for _, p := range postings {
+			if p.Amount.IsPositive() && p.Date.After(previous) && !p.Date.After(installment.Date) {
+				actual = actual.Add(p.Amount)
+			}
+		}
+
+		payments = append(payments, LoanPayment{
+			Date:             installment.Date,
+			Scheduled:        installment.EMI,
+			Actual:           actual,
+			Difference:       actual.Sub(installment.EMI),
+			ScheduledBalance: installment.ClosingBalance,
+			ActualBalance:    ledgerBalance(postings, installment.Date),
+		})
+		previous = installment.Date
+	}
+	return payments
+}
+
+func simulatePrepayment(loanConfig config.Loan, prepayment Prepayment) (PrepaymentSimulation, error) {
+	if !lo.Contains([]string{ReduceEMI, ReduceTenure}, prepayment.Mode) {
+		return PrepaymentSimulation{}, fmt.Errorf("Invalid mode %s, expected %s or %s", prepayment.Mode, ReduceEMI, ReduceTenure)
+	}
+	if !prepayment.Amount.IsPositive() {
+		return PrepaymentSimulation{}, errors.New("Prepayment amount should be positive")
+	}
+
+	terms, err := parseLoanTerms(loanConfig)
+	if err != nil {
+		return PrepaymentSimulation{}, err
+	}
+	if !prepayment.Date.After(terms.StartDate) {
+		return PrepaymentSimulation{}, errors.New("Prepayment date should be after the start date of the loan")
+	}
+
+	current, err := computeSchedule(terms, nil)
+	if err != nil {
+		return PrepaymentSimulation{}, err
+	}
+	simulated, err := computeSchedule(terms, []Prepayment{prepayment})
+	if err != nil {
+		return PrepaymentSimulation{}, err
+	}
+
+	return PrepaymentSimulation{
+		Prepayment:    prepayment,
+		Current:       current,
+		Simulated:     simulated,
+		InterestSaved: current.TotalInterest.Sub(simulated.TotalInterest),
+		MonthsSaved:   current.Tenure - simulated.Tenure,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package liabilities
 
 import (
+	"github.com/ananthakumaran/paisa/internal/model/posting"
 	"github.com/ananthakumaran/paisa/internal/query"
 	"github.com/ananthakumaran/paisa/internal/service"
 	"github.com/gin-gonic/gin"
@@ -8,9 +9,14 @@ import (
 )
 
 func GetRepayment(db *gorm.DB) gin.H {
+	return gin.H{"repayments": Repayments(db)}
+}
+
+// Repayments returns the repayment postings of the liabilities along
+// with the interest expenses
+func Repayments(db *gorm.DB) []posting.Posting {
 	postings := query.Init(db).Like("Liabilities:%").Credit().All()
 	postings = service.PopulateMarketPrice(db, postings)
 	expenses := query.Init(db).Like("Expenses:Interest:%").All()
-	postings = append(postings, expenses...)
-	return gin.H{"repayments": postings}
+	return append(postings, expenses...)
 }
@@ -22,24 +22,45 @@ type Networth struct {
 	NetInvestmentAmount decimal.Decimal `json:"netInvestmentAmount"`
 }
 
-func GetNetworth(db *gorm.DB) gin.H {
-	postings := query.Init(db).Like("Assets:%", "Income:CapitalGains:%", "Liabilities:%").UntilToday().All()
+type NetworthReport struct {
+	Currency string           `json:"currency"`
+	XIRR     decimal.Decimal  `json:"xirr"`
+	Timeline []Networth       `json:"timeline"`
+	FXGains  []service.FXGain `json:"fx_gains"`
+}
 
-	postings = service.PopulateMarketPrice(db, postings)
-	networthTimeline := computeNetworthTimeline(db, postings, false)
-	xirr := service.XIRR(db, postings)
-	return gin.H{"networthTimeline": networthTimeline, "xirr": xirr}
+func GetNetworth(db *gorm.DB, converter *service.Converter) gin.H {
+	report := networthReport(db, converter)
+	return gin.H{"networthTimeline": report.Timeline, "xirr": report.XIRR, "fxGains": report.FXGains, "currency": report.Currency}
 }
 
-func GetCurrentNetworth(db *gorm.DB) gin.H {
+func networthReport(db *gorm.DB, converter *service.Converter) NetworthReport {
+	postings := networthPostings(db)
+	return NetworthReport{
+		Currency: converter.Currency,
+		XIRR:     service.XIRR(db, converter.Postings(postings)),
+		Timeline: computeNetworthTimeline(db, postings, false, converter),
+		FXGains:  service.ComputeFXGains(postings, converter),
+	}
+}
+
+func networthPostings(db *gorm.DB) []posting.Posting {
 	postings := query.Init(db).Like("Assets:%", "Income:CapitalGains:%", "Liabilities:%").UntilToday().All()
-	postings = service.PopulateMarketPrice(db, postings)
-	networth := computeNetworth(db, postings)
+	return service.PopulateMarketPrice(db, postings)
+}
+
+func GetCurrentNetworth(db *gorm.DB) gin.H {
+	postings := networthPostings(db)
+	networth := computeNetworth(db, postings, service.DefaultConverter(db))
 	xirr := service.XIRR(db, postings)
 	return gin.H{"networth": networth, "xirr": xirr}
 }
 
-func computeNetworth(db *gorm.DB, postings []posting.Posting) Networth {
+// computeNetworth values the holdings in the default currency and
+// converts the total on the valuation date, while the investments and
+// withdrawals are converted on the transaction date. The difference
+// in the exchange rate ends up in the gain.
+func computeNetworth(db *gorm.DB, postings []posting.Posting, converter *service.Converter) Networth {
 	var networth Networth
 
 	if len(postings) == 0 {
@@ -57,23 +78,26 @@ func computeNetworth(db *gorm.DB, postings []posting.Posting) Networth {
 		isStockSplit := service.IsStockSplit(db, p)
 		isCapitalGains := service.IsCapitalGains(p)
 
+		amount := converter.Convert(p.Amount, p.Date)
 		if isInterest || isInterestRepayment {
 			balance = balance.Add(p.Amount)
 		} else if isCapitalGains {
-			withdrawal = withdrawal.Add(p.Amount.Neg())
+			withdrawal = withdrawal.Add(amount.Neg())
 		} else {
 			if p.Amount.GreaterThan(decimal.Zero) && !isStockSplit {
-				investment = investment.Add(p.Amount)
+				investment = investment.Add(amount)
 			}
 
 			if p.Amount.LessThan(decimal.Zero) && !isStockSplit {
-				withdrawal = withdrawal.Add(p.Amount.Neg())
+				withdrawal = withdrawal.Add(amount.Neg())
 			}
 
 			balance = balance.Add(service.GetMarketPrice(db, p, now))
 		}
 	}
 
+	balance = converter.Convert(balance, now)
+
 	gain := balance.Add(withdrawal).Sub(investment)
 	netInvestment := investment.Sub(withdrawal)
 	networth = Networth{
@@ -88,7 +112,7 @@ func computeNetworth(db *gorm.DB, postings []posting.Posting) Networth {
 	return networth
 }
 
-func computeNetworthTimeline(db *gorm.DB, postings []posting.Posting, computeBalanceUnits bool) []Networth {
+func computeNetworthTimeline(db *gorm.DB, postings []posting.Posting, computeBalanceUnits bool, converter *service.Converter) []Networth {
 	var networths []Networth
 
 	var p posting.Posting
@@ -116,12 +140,13 @@ func computeNetworthTimeline(db *gorm.DB, postings []posting.Posting, computeBal
 			isInterest := service.IsInterest(db, p)
 			isCapitalGains := service.IsCapitalGains(p)
 
+			amount := converter.Convert(p.Amount, p.Date)
 			if p.Amount.GreaterThan(decimal.Zero) && !isInterest {
-				rs.investment = rs.investment.Add(p.Amount)
+				rs.investment = rs.investment.Add(amount)
 			}
 
 			if p.Amount.LessThan(decimal.Zero) && !isInterest {
-				rs.withdrawal = rs.withdrawal.Add(p.Amount.Neg())
+				rs.withdrawal = rs.withdrawal.Add(amount.Neg())
 			}
 
 			if !isCapitalGains {
@@ -165,6 +190,7 @@ func computeNetworthTimeline(db *gorm.DB, postings []posting.Posting, computeBal
 
 		}
 
+		balance = converter.Convert(balance, start)
 		gain := balance.Add(withdrawal).Sub(investment)
 		netInvestment := investment.Sub(withdrawal)
 		networths = append(networths, Networth{
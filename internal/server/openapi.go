@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ananthakumaran/paisa/internal/export"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	decimalType       = reflect.TypeOf(decimal.Decimal{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	pathParameter     = regexp.MustCompile(`:([^/]+)`)
+)
+
+// schemaGenerator builds the JSON schema of the go types the same way
+// encoding/json would serialize them. The structs are added to the
+// components and referenced by name.
+type schemaGenerator struct {
+	schemas map[string]map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{schemas: map[string]map[string]any{}, names: map[reflect.Type]string{}}
+}
+
+func nullable(schema map[string]any) map[string]any {
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == decimalType:
+		return map[string]any{"type": "number"}
+	case t.Kind() != reflect.Pointer && (t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)):
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nullable(map[string]any{"type": "string", "format": "byte"})
+		}
+		return nullable(map[string]any{"type": "array", "items": g.schema(t.Elem())})
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return nullable(map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())})
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/components/schemas/" + g.component(t)}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken || name == "" {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = string(unicode.ToUpper(rune(pkg[0]))) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// reserve the name before walking the fields to support recursive types
+	g.schemas[name] = map[string]any{}
+
+	properties := map[string]any{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+
+	g.schemas[name] = map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	return name
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.schema(field.Type)
+		if strings.Contains(options, "string") {
+			schema = map[string]any{"type": "string"}
+		}
+		properties[name] = schema
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// openAPIPath converts the gin path to the OpenAPI path template
+func openAPIPath(path string) string {
+	return pathParameter.ReplaceAllString(path, "{$1}")
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// buildOpenAPI generates the OpenAPI 3.1 document of the routes
+func buildOpenAPI(routes []apiRoute) map[string]any {
+	g := newSchemaGenerator()
+	errorSchema := g.schema(reflect.TypeOf(ErrorResponse{}))
+
+	paths := map[string]any{}
+	for _, route := range routes {
+		parameters := []any{}
+		for _, match := range pathParameter.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		for _, parameter := range route.Parameters {
+			parameters = append(parameters, map[string]any{
+				"name":        parameter.Name,
+				"in":          "query",
+				"description": parameter.Description,
+				"required":    parameter.Required,
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+
+		content := jsonContent(g.schema(route.Response))
+		if route.Report != "" {
+			for _, format := range export.Formats {
+				content[export.ContentType(format)] = map[string]any{"schema": map[string]any{"type": "string", "contentMediaType": export.ContentType(format)}}
+			}
+		}
+
+		operation := map[string]any{
+			"operationId": route.OperationID,
+			"summary":     route.Summary,
+			"parameters":  parameters,
+			"responses": map[string]any{
+				"200":     map[string]any{"description": "OK", "content": content},
+				"default": map[string]any{"description": "Error", "content": jsonContent(errorSchema)},
+			},
+		}
+		if route.Request != nil {
+			operation["requestBody"] = map[string]any{"required": true, "content": jsonContent(g.schema(route.Request))}
+		}
+
+		path := openAPIPath(route.Path)
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	schemas := map[string]any{}
+	for name, schema := range g.schemas {
+		schemas[name] = schema
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Paisa",
+			"version":     "1",
+			"description": "Versioned API of Paisa. The responses of the endpoints under /api/v1 are stable.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// periodExpr is a single expression of the Period metadata, see the
+// recurring docs for the syntax. Multiple expressions can be joined
+// using |
+type periodExpr struct {
+	dayOfMonth []func(date time.Time) bool
+	month      []func(date time.Time) bool
+	dayOfWeek  []func(date time.Time) bool
+}
+
+var monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+var weekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// maxPeriodSearch limits how far ahead the next occurrence is looked
+// up, an expression like 30 FEB ? never matches
+const maxPeriodSearch = 5 * 366
+
+func parsePeriod(period string) ([]periodExpr, error) {
+	var exprs []periodExpr
+	for _, part := range strings.Split(period, "|") {
+		fields := strings.Fields(strings.ToUpper(part))
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("Invalid period %q, expected 3 fields", part)
+		}
+
+		var expr periodExpr
+		var err error
+		if expr.dayOfMonth, err = parsePeriodField(fields[0], parseDayOfMonth); err != nil {
+			return nil, err
+		}
+		if expr.month, err = parsePeriodField(fields[1], parseMonth); err != nil {
+			return nil, err
+		}
+		if expr.dayOfWeek, err = parsePeriodField(fields[2], parseDayOfWeek); err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func parsePeriodField(field string, parseItem func(item string) (func(date time.Time) bool, error)) ([]func(date time.Time) bool, error) {
+	if field == "*" || field == "?" {
+		return nil, nil
+	}
+
+	var matchers []func(date time.Time) bool
+	for _, item := range strings.Split(field, ",") {
+		matcher, err := parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func parseRange(item string, parseValue func(value string) (int, error)) (int, int, error) {
+	from, to, isRange := strings.Cut(item, "-")
+	start, err := parseValue(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return start, start, nil
+	}
+	end, err := parseValue(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("Invalid range %q", item)
+	}
+	return start, end, nil
+}
+
+func parseNumber(min int, max int, names []string, offset int) func(value string) (int, error) {
+	return func(value string) (int, error) {
+		if i := lo.IndexOf(names, value); i != -1 {
+			return i + offset, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("Invalid value %q, expected %d-%d", value, min, max)
+		}
+		return n, nil
+	}
+}
+
+func parseDayOfMonth(item string) (func(date time.Time) bool, error) {
+	if item == "L" {
+		return func(date time.Time) bool { return date.AddDate(0, 0, 1).Day() == 1 }, nil
+	}
+
+	if day, ok := strings.CutSuffix(item, "W"); ok {
+		n, err := parseNumber(1, 31, nil, 0)(day)
+		if err != nil {
+			return nil, err
+		}
+		return func(date time.Time) bool {
+			target, ok := nearestWeekday(date, n)
+			return ok && date.Day() == target
+		}, nil
+	}
+
+	start, end, err := parseRange(item, parseNumber(1, 31, nil, 0))
+	if err != nil {
+		return nil, err
+	}
+	return func(date time.Time) bool { return date.Day() >= start && date.Day() <= end }, nil
+}
+
+// nearestWeekday returns the weekday closest to the given day of the
+// month without crossing over to another month.
+func nearestWeekday(date time.Time, day int) (int, bool) {
+	lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+	if day > lastDay {
+		return 0, false
+	}
+
+	switch time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, date.Location()).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2, true
+		}
+		return day - 1, true
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2, true
+		}
+		return day + 1, true
+	}
+	return day, true
+}
+
+func parseMonth(item string) (func(date time.Time) bool, error) {
+	start, end, err := parseRange(item, parseNumber(1, 12, monthNames, 1))
+	if err != nil {
+		return nil, err
+	}
+	return func(date time.Time) bool { return int(date.Month()) >= start && int(date.Month()) <= end }, nil
+}
+
+func parseDayOfWeek(item string) (func(date time.Time) bool, error) {
+	// last given weekday of the month, 5L is the last friday
+	if day, ok := strings.CutSuffix(item, "L"); ok {
+		n, err := parseNumber(0, 6, weekdayNames, 0)(lo.Ternary(day == "", "6", day))
+		if err != nil {
+			return nil, err
+		}
+		return func(date time.Time) bool {
+			return int(date.Weekday()) == n && date.AddDate(0, 0, 7).Month() != date.Month()
+		}, nil
+	}
+
+	start, end, err := parseRange(item, parseNumber(0, 7, weekdayNames, 0))
+	if err != nil {
+		return nil, err
+	}
+	return func(date time.Time) bool {
+		weekday := int(date.Weekday())
+		// both 0 and 7 are sunday
+		return (weekday >= start && weekday <= end) || (weekday == 0 && end == 7)
+	}, nil
+}
+
+func matchAny(matchers []func(date time.Time) bool, date time.Time) bool {
+	return lo.SomeBy(matchers, func(matcher func(date time.Time) bool) bool { return matcher(date) })
+}
+
+func (expr periodExpr) match(date time.Time) bool {
+	if len(expr.month) > 0 && !matchAny(expr.month, date) {
+		return false
+	}
+
+	switch {
+	case len(expr.dayOfMonth) == 0 && len(expr.dayOfWeek) == 0:
+		return true
+	case len(expr.dayOfWeek) == 0:
+		return matchAny(expr.dayOfMonth, date)
+	case len(expr.dayOfMonth) == 0:
+		return matchAny(expr.dayOfWeek, date)
+	}
+	// same as cron, either of them has to match if both are specified
+	return matchAny(expr.dayOfMonth, date) || matchAny(expr.dayOfWeek, date)
+}
+
+// nextPeriodDate returns the first date after the given date that
+// matches the period.
+func nextPeriodDate(period string, after time.Time) (time.Time, error) {
+	exprs, err := parsePeriod(period)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+	for i := 0; i < maxPeriodSearch; i++ {
+		date = date.AddDate(0, 0, 1)
+		if lo.SomeBy(exprs, func(expr periodExpr) bool { return expr.match(date) }) {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Period %q doesn't match any date", period)
+}
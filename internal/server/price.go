@@ -58,9 +58,12 @@ func ClearPriceCache(db *gorm.DB) gin.H {
 		return gin.H{"success": false, "message": err.Error()}
 	}
 
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
+
 	cache.Clear()
 
-	message, err := model.SyncJournal(db)
+	_, message, err := model.SyncJournal(db)
 	if err != nil {
 		return gin.H{"success": false, "message": message}
 	}
@@ -69,13 +72,19 @@ func ClearPriceCache(db *gorm.DB) gin.H {
 }
 
 func ClearPriceProviderCache(db *gorm.DB, code string) gin.H {
-	provider := scraper.GetProviderByCode(code)
+	provider, err := scraper.GetProviderByCode(code)
+	if err != nil {
+		return gin.H{"success": false, "message": err.Error()}
+	}
 	provider.ClearCache(db)
-	return gin.H{}
+	return gin.H{"success": true}
 }
 
 func GetPriceAutoCompletions(db *gorm.DB, request AutoCompleteRequest) gin.H {
-	provider := scraper.GetProviderByCode(request.Provider)
+	provider, err := scraper.GetProviderByCode(request.Provider)
+	if err != nil {
+		return gin.H{"completions": []price.AutoCompleteItem{}, "message": err.Error()}
+	}
 	completions := provider.AutoComplete(db, request.Field, request.Filters)
 
 	completions = lo.Filter(completions, func(completion price.AutoCompleteItem, _ int) bool {
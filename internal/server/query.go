@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	GroupByDay     = "day"
+	GroupByMonth   = "month"
+	GroupByYear    = "year"
+	GroupByAccount = "account"
+	GroupByPayee   = "payee"
+)
+
+var queryGroups = []string{GroupByDay, GroupByMonth, GroupByYear, GroupByAccount, GroupByPayee}
+
+type QueryTotal struct {
+	Commodity string          `json:"commodity"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+type QueryRegisterRow struct {
+	Key     string          `json:"key"`
+	Count   int             `json:"count"`
+	Amount  decimal.Decimal `json:"amount"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+type QueryResult struct {
+	Query    string             `json:"query"`
+	Group    string             `json:"group"`
+	Postings []posting.Posting  `json:"postings"`
+	Total    decimal.Decimal    `json:"total"`
+	Totals   []QueryTotal       `json:"totals"`
+	Register []QueryRegisterRow `json:"register"`
+}
+
+// RunQuery returns the postings matching the filter expression along
+// with the totals of each commodity and the register grouped by the
+// period, account or payee. The balance of the postings and the register
+// rows is the running total of the amount.
+func RunQuery(db *gorm.DB, filter string, group string) (QueryResult, error) {
+	if group == "" {
+		group = GroupByMonth
+	}
+	if !lo.Contains(queryGroups, group) {
+		return QueryResult{}, fmt.Errorf("Invalid group %s, expected one of day, month, year, account or payee", group)
+	}
+
+	q, err := query.Parse(db, filter)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	postings := q.All()
+	balance := decimal.Zero
+	for i := range postings {
+		balance = balance.Add(postings[i].Amount)
+		postings[i].Balance = balance
+	}
+
+	return QueryResult{
+		Query:    filter,
+		Group:    group,
+		Postings: postings,
+		Total:    balance,
+		Totals:   queryTotals(postings),
+		Register: queryRegister(postings, group),
+	}, nil
+}
+
+func queryTotals(postings []posting.Posting) []QueryTotal {
+	totals := []QueryTotal{}
+	for commodity, ps := range lo.GroupBy(postings, func(p posting.Posting) string { return p.Commodity }) {
+		totals = append(totals, QueryTotal{
+			Commodity: commodity,
+			Quantity:  utils.SumBy(ps, func(p posting.Posting) decimal.Decimal { return p.Quantity }),
+			Amount:    utils.SumBy(ps, func(p posting.Posting) decimal.Decimal { return p.Amount }),
+		})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Commodity < totals[j].Commodity })
+	return totals
+}
+
+func queryRegister(postings []posting.Posting, group string) []QueryRegisterRow {
+	grouped := lo.GroupBy(postings, func(p posting.Posting) string {
+		switch group {
+		case GroupByDay:
+			return p.Date.Format("2006-01-02")
+		case GroupByYear:
+			return p.Date.Format("2006")
+		case GroupByAccount:
+			return p.Account
+		case GroupByPayee:
+			return p.Payee
+		default:
+			return p.Date.Format("2006-01")
+		}
+	})
+
+	rows := []QueryRegisterRow{}
+	balance := decimal.Zero
+	for _, key := range utils.SortedKeys(grouped) {
+		amount := utils.SumBy(grouped[key], func(p posting.Posting) decimal.Decimal { return p.Amount })
+		balance = balance.Add(amount)
+		rows = append(rows, QueryRegisterRow{Key: key, Count: len(grouped[key]), Amount: amount, Balance: balance})
+	}
+	return rows
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunQuery(t *testing.T) {
+	db := openAPITestDB(t)
+
+	result, err := RunQuery(db, "acct:Expenses", "")
+	require.NoError(t, err)
+	assert.Equal(t, GroupByMonth, result.Group)
+	require.Len(t, result.Postings, 2)
+	assert.True(t, decimal.NewFromInt(7000).Equal(result.Postings[1].Balance))
+	assert.True(t, decimal.NewFromInt(7000).Equal(result.Total))
+	require.Len(t, result.Totals, 1)
+	assert.Equal(t, "INR", result.Totals[0].Commodity)
+
+	require.Len(t, result.Register, 2)
+	assert.Equal(t, "2023-01", result.Register[0].Key)
+	assert.True(t, decimal.NewFromInt(2000).Equal(result.Register[0].Amount))
+	assert.Equal(t, "2023-02", result.Register[1].Key)
+	assert.True(t, decimal.NewFromInt(7000).Equal(result.Register[1].Balance))
+
+	result, err = RunQuery(db, "not:acct:Assets date:2023-01", GroupByAccount)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Expenses:Food", "Income:Salary", "Liabilities:HomeLoan"}, []string{result.Register[0].Key, result.Register[1].Key, result.Register[2].Key})
+
+	_, err = RunQuery(db, "", "week")
+	assert.Error(t, err)
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/importer"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ReconcileRequest struct {
+	Account    string          `json:"account"`
+	Date       string          `json:"date"`
+	Balance    decimal.Decimal `json:"balance"`
+	Commodity  string          `json:"commodity"`
+	PostingIDs []uint          `json:"posting_ids"`
+	Finish     bool            `json:"finish"`
+}
+
+type Reconciliation struct {
+	Account          string            `json:"account"`
+	Commodity        string            `json:"commodity"`
+	Date             time.Time         `json:"date"`
+	StatementBalance decimal.Decimal   `json:"statement_balance"`
+	ClearedBalance   decimal.Decimal   `json:"cleared_balance"`
+	Balance          decimal.Decimal   `json:"balance"`
+	Difference       decimal.Decimal   `json:"difference"`
+	Uncleared        []posting.Posting `json:"uncleared"`
+}
+
+var (
+	postingLine     = regexp.MustCompile(`^([ \t]+)(?:[*!][ \t]*)?([^ \t;].*)$`)
+	beancountFlag   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[ \t]+)(?:txn|[!&#?%PSTCURM])([ \t]|$)`)
+	plainCommodity  = regexp.MustCompile(`^[\pL\p{Sc}_]+$`)
+	accountSplitter = regexp.MustCompile(`\s{2,}|\t`)
+)
+
+func GetReconciliation(db *gorm.DB, request ReconcileRequest) (Reconciliation, error) {
+	date, err := parseReconcileRequest(&request)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	return computeReconciliation(statementPostings(accountPostings(db, request), date), request, date, nil), nil
+}
+
+func parseReconcileRequest(request *ReconcileRequest) (time.Time, error) {
+	request.Account = strings.TrimSpace(request.Account)
+	if request.Account == "" {
+		return time.Time{}, errors.New("Account is required")
+	}
+
+	if request.Commodity == "" {
+		request.Commodity = config.DefaultCurrency()
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", request.Date, config.TimeZone())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid statement date %s", request.Date)
+	}
+	return date, nil
+}
+
+func accountPostings(db *gorm.DB, request ReconcileRequest) []posting.Posting {
+	return query.Init(db).Where("account = ? AND commodity = ?", request.Account, request.Commodity).All()
+}
+
+// statementPostings returns the postings till the end of the statement
+// date
+func statementPostings(postings []posting.Posting, date time.Time) []posting.Posting {
+	return lo.Filter(postings, func(p posting.Posting, _ int) bool {
+		return !p.Date.After(date)
+	})
+}
+
+// assertionPosition finds the transaction after which the balance
+// assertion should be added. The assertions are checked in the order
+// of the journal, so it has to be after the last transaction of the
+// statement period and before any transaction after it. Beancount
+// checks the assertions by date, so the order doesn't matter there.
+func assertionPosition(postings []posting.Posting, date time.Time) (posting.Posting, error) {
+	period := statementPostings(postings, date)
+	if len(period) == 0 {
+		return posting.Posting{}, errors.New("No postings found till the statement date")
+	}
+
+	latest := lo.MaxBy(period, func(a posting.Posting, b posting.Posting) bool { return a.Date.After(b.Date) })
+	last := lo.MaxBy(period, func(a posting.Posting, b posting.Posting) bool {
+		if a.FileName != latest.FileName {
+			return false
+		}
+		return b.FileName != latest.FileName || a.TransactionBeginLine > b.TransactionBeginLine
+	})
+
+	if config.GetConfig().LedgerCli == "beancount" {
+		return last, nil
+	}
+
+	for _, p := range postings {
+		if p.FileName == last.FileName && p.Date.After(date) && p.TransactionBeginLine < last.TransactionBeginLine {
+			return posting.Posting{}, fmt.Errorf("Transaction at %s:%d is after the statement date but comes before the transaction at line %d, sort the journal by date to add the balance assertion", p.FileName, p.TransactionBeginLine, last.TransactionBeginLine)
+		}
+	}
+	return last, nil
+}
+
+// computeReconciliation compares the cleared balance with the
+// statement balance, the postings in clearing are treated as cleared
+func computeReconciliation(postings []posting.Posting, request ReconcileRequest, date time.Time, clearing []uint) Reconciliation {
+	isCleared := func(p posting.Posting) bool {
+		return p.Status == "cleared" || lo.Contains(clearing, p.ID)
+	}
+
+	cleared := decimal.Zero
+	balance := decimal.Zero
+	uncleared := []posting.Posting{}
+	for _, p := range postings {
+		balance = balance.Add(p.Quantity)
+		if isCleared(p) {
+			cleared = cleared.Add(p.Quantity)
+		} else {
+			uncleared = append(uncleared, p)
+		}
+	}
+
+	return Reconciliation{
+		Account:          request.Account,
+		Commodity:        request.Commodity,
+		Date:             date,
+		StatementBalance: request.Balance,
+		ClearedBalance:   cleared,
+		Balance:          balance,
+		Difference:       request.Balance.Sub(cleared),
+		Uncleared:        uncleared,
+	}
+}
+
+// Reconcile marks the postings as cleared in the journal. On finish,
+// a balance assertion is added after the last transaction of the
+// statement period, it's only allowed once the cleared balance matches
+// the statement balance.
+func Reconcile(db *gorm.DB, request ReconcileRequest) gin.H {
+	if config.GetConfig().Readonly {
+		return gin.H{"success": false, "message": "Readonly mode"}
+	}
+
+	date, err := parseReconcileRequest(&request)
+	if err != nil {
+		return gin.H{"success": false, "message": err.Error()}
+	}
+
+	all := accountPostings(db, request)
+	postings := statementPostings(all, date)
+	byID := lo.KeyBy(postings, func(p posting.Posting) uint { return p.ID })
+	var clearing []posting.Posting
+	for _, id := range lo.Uniq(request.PostingIDs) {
+		p, found := byID[id]
+		if !found {
+			return gin.H{"success": false, "message": fmt.Sprintf("Posting %d is not part of the statement", id)}
+		}
+		if p.Status != "cleared" {
+			clearing = append(clearing, p)
+		}
+	}
+
+	reconciliation := computeReconciliation(postings, request, date, request.PostingIDs)
+	if request.Finish && !reconciliation.Difference.IsZero() {
+		return gin.H{"success": false, "message": fmt.Sprintf("Cleared balance %s doesn't match the statement balance %s", reconciliation.ClearedBalance, reconciliation.StatementBalance)}
+	}
+
+	updates := lo.GroupBy(clearing, func(p posting.Posting) string { return p.FileName })
+
+	var last posting.Posting
+	if request.Finish {
+		last, err = assertionPosition(all, date)
+		if err != nil {
+			return gin.H{"success": false, "message": err.Error()}
+		}
+		if _, ok := updates[last.FileName]; !ok {
+			updates[last.FileName] = []posting.Posting{}
+		}
+	}
+
+	originals := map[string][]string{}
+	for fileName, ps := range updates {
+		err := updateLedgerFile(fileName, func(lines []string) ([]string, error) {
+			originals[fileName] = append([]string{}, lines...)
+			for _, p := range ps {
+				if err := markPostingCleared(lines, p); err != nil {
+					return nil, err
+				}
+			}
+
+			if !request.Finish || last.FileName != fileName {
+				return lines, nil
+			}
+
+			_, at, err := transactionLines(lines, last.TransactionBeginLine)
+			if err != nil {
+				return nil, err
+			}
+			assertion := balanceAssertion(request.Account, request.Commodity, reconciliation.Balance, date)
+			return append(lines[:at], append(assertion, lines[at:]...)...), nil
+		})
+		if err != nil {
+			restoreLedgerFiles(originals)
+			return gin.H{"success": false, "message": err.Error()}
+		}
+	}
+
+	result := Sync(db, SyncRequest{Journal: true})
+	if result["success"] != true {
+		// leave the journal as it was, the backups are still available
+		restoreLedgerFiles(originals)
+		Sync(db, SyncRequest{Journal: true})
+	}
+	return result
+}
+
+func restoreLedgerFiles(originals map[string][]string) {
+	for fileName, lines := range originals {
+		err := updateLedgerFile(fileName, func(_ []string) ([]string, error) { return lines, nil })
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// transactionLines returns the range of line indexes of the
+// transaction that begins at the given line, the end is exclusive
+func transactionLines(lines []string, beginLine uint64) (int, int, error) {
+	begin := int(beginLine) - 1
+	if beginLine == 0 || begin >= len(lines) || !transactionHeader.MatchString(lines[begin]) {
+		return 0, 0, fmt.Errorf("Transaction at line %d has changed, sync and try again", beginLine)
+	}
+
+	end := begin + 1
+	for end < len(lines) && strings.TrimSpace(lines[end]) != "" && strings.ContainsAny(lines[end][:1], " \t") {
+		end++
+	}
+	return begin, end, nil
+}
+
+func isIndentedComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && trimmed != line && (strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#"))
+}
+
+func postingAccount(line string) string {
+	m := postingLine.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	account := strings.TrimSpace(accountSplitter.Split(strings.SplitN(m[2], ";", 2)[0], 2)[0])
+	if len(account) > 2 && (account[0] == '(' || account[0] == '[') {
+		account = account[1 : len(account)-1]
+	}
+	return account
+}
+
+// markPostingCleared sets the cleared flag on the lines of the posting
+// account within the transaction. Beancount only reports the flag of
+// the transaction, so the flag is set on the transaction instead.
+func markPostingCleared(lines []string, p posting.Posting) error {
+	begin, end, err := transactionLines(lines, p.TransactionBeginLine)
+	if err != nil {
+		return err
+	}
+
+	var found []int
+	for i := begin + 1; i < end; i++ {
+		if isIndentedComment(lines[i]) || postingAccount(lines[i]) != p.Account {
+			continue
+		}
+		found = append(found, i)
+	}
+
+	if len(found) == 0 {
+		return fmt.Errorf("Posting of %s not found in the transaction at %s:%d", p.Account, p.FileName, p.TransactionBeginLine)
+	}
+
+	if config.GetConfig().LedgerCli == "beancount" {
+		lines[begin] = beancountFlag.ReplaceAllString(lines[begin], "${1}*${2}")
+		return nil
+	}
+
+	for _, i := range found {
+		lines[i] = clearPostingLine(lines[i])
+	}
+	return nil
+}
+
+// clearPostingLine adds the cleared flag to the posting, the spaces
+// are taken from the separator to keep the amount aligned
+func clearPostingLine(line string) string {
+	m := postingLine.FindStringSubmatch(line)
+	cleared := m[1] + "* " + m[2]
+	extra := len(cleared) - len(line)
+	if extra <= 0 {
+		return cleared
+	}
+
+	rest := m[2]
+	if index := accountSplitter.FindStringIndex(rest); index != nil && !strings.Contains(rest[index[0]:index[1]], "\t") && index[1]-index[0]-extra >= 2 {
+		rest = rest[:index[0]] + rest[index[0]+extra:]
+	}
+	return m[1] + "* " + rest
+}
+
+// balanceAssertion builds the assertion of the balance at the end of
+// the statement date. Beancount checks the balance at the beginning of
+// the day, so it's dated the next day.
+func balanceAssertion(account string, commodity string, balance decimal.Decimal, date time.Time) []string {
+	if config.GetConfig().LedgerCli == "beancount" {
+		return []string{"", fmt.Sprintf("%s balance %s  %s %s", date.AddDate(0, 0, 1).Format("2006-01-02"), account, balance.String(), commodity)}
+	}
+
+	if !plainCommodity.MatchString(commodity) {
+		commodity = `"` + commodity + `"`
+	}
+
+	transaction := fmt.Sprintf("%s * Statement balance\n    %s  0 %s = %s %s", date.Format("2006/01/02"), account, commodity, balance.String(), commodity)
+	return append([]string{""}, strings.Split(importer.Format(transaction, config.GetConfig().AmountAlignmentColumn), "\n")...)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadLedgerCliConfig(t *testing.T, ledgerCli string) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+ledger_cli: `+ledgerCli+`
+`), "/tmp/paisa.yaml"))
+}
+
+func TestClearPostingLine(t *testing.T) {
+	assert.Equal(t, "    * Assets:Checking         -100 INR", clearPostingLine("    Assets:Checking           -100 INR"))
+	assert.Equal(t, "    * Assets:Checking         -100 INR", clearPostingLine("    ! Assets:Checking         -100 INR"))
+	assert.Equal(t, "    * Assets:Checking  -100 INR", clearPostingLine("    Assets:Checking  -100 INR"))
+	assert.Equal(t, "\t* Assets:Checking\t-100 INR", clearPostingLine("\tAssets:Checking\t-100 INR"))
+	assert.Equal(t, "    * Assets:Checking", clearPostingLine("    Assets:Checking"))
+}
+
+func TestMarkPostingCleared(t *testing.T) {
+	loadLedgerCliConfig(t, "ledger")
+	lines := strings.Split(`2023/01/01 Rent
+    ; Recurring: Rent
+    Expenses:Rent                  1000 INR
+    Assets:Checking  ; paid online
+
+2023/01/02 Groceries
+    Expenses:Food                   200 INR
+    Assets:Checking`, "\n")
+
+	require.NoError(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Checking", TransactionBeginLine: 1}))
+	assert.Equal(t, "    * Assets:Checking  ; paid online", lines[3])
+	assert.Equal(t, "    Assets:Checking", lines[7])
+
+	_, end, err := transactionLines(lines, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, end)
+
+	assert.Error(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Savings", TransactionBeginLine: 6}))
+	assert.Error(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Checking", TransactionBeginLine: 2}))
+}
+
+func TestMarkPostingClearedBeancount(t *testing.T) {
+	loadLedgerCliConfig(t, "beancount")
+	lines := strings.Split(`2023-01-01 ! "Landlord" "Rent"
+  recurring: "Rent"
+  Expenses:Rent                  1000 INR
+  Assets:Checking  ; paid online
+
+2023-01-02 txn "Groceries"
+  Expenses:Food                   200 INR
+  Assets:Checking
+
+2023-01-03 * "Salary"
+  Income:Salary                 -5000 INR
+  Assets:Checking`, "\n")
+
+	require.NoError(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Checking", TransactionBeginLine: 1}))
+	assert.Equal(t, `2023-01-01 * "Landlord" "Rent"`, lines[0])
+	assert.Equal(t, "  Assets:Checking  ; paid online", lines[3])
+
+	require.NoError(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Checking", TransactionBeginLine: 6}))
+	assert.Equal(t, `2023-01-02 * "Groceries"`, lines[5])
+	assert.Equal(t, "  Assets:Checking", lines[7])
+
+	require.NoError(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Checking", TransactionBeginLine: 10}))
+	assert.Equal(t, `2023-01-03 * "Salary"`, lines[9])
+
+	assert.Error(t, markPostingCleared(lines, posting.Posting{Account: "Assets:Savings", TransactionBeginLine: 6}))
+}
+
+func TestBalanceAssertion(t *testing.T) {
+	loadLedgerCliConfig(t, "ledger")
+	assert.Equal(t, []string{"", "2023/01/31 * Statement balance", "    Assets:Checking                                0 INR = 3800 INR"},
+		balanceAssertion("Assets:Checking", "INR", decimal.NewFromInt(3800), parseDate("2023-01-31")))
+
+	loadLedgerCliConfig(t, "beancount")
+	assert.Equal(t, []string{"", "2023-02-01 balance Assets:Checking  3800 INR"},
+		balanceAssertion("Assets:Checking", "INR", decimal.NewFromInt(3800), parseDate("2023-01-31")))
+}
+
+func TestComputeReconciliation(t *testing.T) {
+	postings := []posting.Posting{
+		{ID: 1, Status: "cleared", Quantity: decimal.NewFromInt(5000)},
+		{ID: 2, Status: "unmarked", Quantity: decimal.NewFromInt(-1000)},
+		{ID: 3, Status: "pending", Quantity: decimal.NewFromInt(-200)},
+	}
+
+	request := ReconcileRequest{Account: "Assets:Checking", Balance: decimal.NewFromInt(4000)}
+	reconciliation := computeReconciliation(postings, request, parseDate("2023-01-31"), nil)
+	assert.Equal(t, "5000", reconciliation.ClearedBalance.String())
+	assert.Equal(t, "3800", reconciliation.Balance.String())
+	assert.Equal(t, "-1000", reconciliation.Difference.String())
+	assert.Len(t, reconciliation.Uncleared, 2)
+
+	reconciliation = computeReconciliation(postings, request, parseDate("2023-01-31"), []uint{2})
+	assert.True(t, reconciliation.Difference.IsZero())
+	require.Len(t, reconciliation.Uncleared, 1)
+	assert.Equal(t, uint(3), reconciliation.Uncleared[0].ID)
+}
+
+func TestAssertionPosition(t *testing.T) {
+	loadLedgerCliConfig(t, "ledger")
+	postings := []posting.Posting{
+		{FileName: "main.ledger", TransactionBeginLine: 1, Date: parseDate("2023-01-05")},
+		{FileName: "main.ledger", TransactionBeginLine: 5, Date: parseDate("2023-01-20")},
+		{FileName: "main.ledger", TransactionBeginLine: 9, Date: parseDate("2023-01-10")},
+		{FileName: "main.ledger", TransactionBeginLine: 13, Date: parseDate("2023-02-03")},
+	}
+
+	p, err := assertionPosition(postings, parseDate("2023-01-31"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), p.TransactionBeginLine)
+
+	postings[3].TransactionBeginLine = 3
+	_, err = assertionPosition(postings, parseDate("2023-01-31"))
+	assert.Error(t, err)
+
+	_, err = assertionPosition(postings, parseDate("2022-12-31"))
+	assert.Error(t, err)
+
+	// beancount doesn't depend on the order of the journal
+	loadLedgerCliConfig(t, "beancount")
+	p, err = assertionPosition(postings, parseDate("2023-01-31"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), p.TransactionBeginLine)
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type RecurringSuggestion struct {
+	Key            string                    `json:"key"`
+	Account        string                    `json:"account"`
+	Period         string                    `json:"period"`
+	TagPeriod      string                    `json:"tag_period"`
+	Interval       int                       `json:"interval"`
+	Amount         decimal.Decimal           `json:"amount"`
+	PreviousAmount decimal.Decimal           `json:"previous_amount"`
+	Increased      bool                      `json:"increased"`
+	ChangedOn      time.Time                 `json:"changed_on"`
+	NextDate       time.Time                 `json:"next_date"`
+	Missed         []time.Time               `json:"missed"`
+	Transactions   []transaction.Transaction `json:"transactions"`
+}
+
+type RecurringTagRequest struct {
+	Key            string   `json:"key"`
+	Period         string   `json:"period"`
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+type recurringPeriod struct {
+	name           string
+	days           float64
+	tolerance      float64
+	minOccurrences int
+	next           func(date time.Time) time.Time
+}
+
+var recurringPeriods = []recurringPeriod{
+	{name: "weekly", days: 7, tolerance: 1.5, minOccurrences: 4, next: func(d time.Time) time.Time { return d.AddDate(0, 0, 7) }},
+	{name: "monthly", days: 30.44, tolerance: 4, minOccurrences: 3, next: func(d time.Time) time.Time { return d.AddDate(0, 1, 0) }},
+	{name: "quarterly", days: 91.31, tolerance: 8, minOccurrences: 3, next: func(d time.Time) time.Time { return d.AddDate(0, 3, 0) }},
+	{name: "yearly", days: 365.25, tolerance: 15, minOccurrences: 2, next: func(d time.Time) time.Time { return d.AddDate(1, 0, 0) }},
+}
+
+var (
+	payeeNoise  = regexp.MustCompile(`[^\pL\s]+`)
+	payeeDigits = regexp.MustCompile(`\s*\d+\s*`)
+
+	transactionHeader = regexp.MustCompile(`^\d{4}[/-]\d{2}[/-]\d{2}`)
+)
+
+// a sequence that missed more than this many occurrences till today is
+// considered cancelled
+const maxOverdue = 2
+
+func GetRecurringSuggestions(db *gorm.DB) gin.H {
+	now := utils.EndOfToday()
+	postings := lo.Filter(query.Init(db).All(), func(p posting.Posting, _ int) bool {
+		return p.Date.Before(now)
+	})
+	return gin.H{"suggestions": DetectRecurringTransactions(transaction.Build(postings), now)}
+}
+
+// DetectRecurringTransactions finds the untagged transactions that
+// repeat at a regular interval. The transactions are grouped by payee
+// and accounts, then split by amount and checked for a weekly,
+// monthly, quarterly or yearly period.
+func DetectRecurringTransactions(transactions []transaction.Transaction, now time.Time) []RecurringSuggestion {
+	transactions = lo.Filter(transactions, func(t transaction.Transaction, _ int) bool {
+		return t.TagRecurring == "" && transactionAmount(t).IsPositive()
+	})
+
+	groups := lo.GroupBy(transactions, func(t transaction.Transaction) string {
+		accounts := lo.Uniq(lo.Map(t.Postings, func(p posting.Posting, _ int) string { return p.Account }))
+		sort.Strings(accounts)
+		return normalizePayee(t.Payee) + "|" + strings.Join(accounts, ",")
+	})
+
+	suggestions := []RecurringSuggestion{}
+	for _, group := range groups {
+		for _, cluster := range clusterByAmount(group) {
+			if suggestion, ok := detectPeriod(cluster, now); ok {
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if len(suggestions[i].Transactions) != len(suggestions[j].Transactions) {
+			return len(suggestions[i].Transactions) > len(suggestions[j].Transactions)
+		}
+		return suggestions[i].Key < suggestions[j].Key
+	})
+	return suggestions
+}
+
+func normalizePayee(payee string) string {
+	return strings.Join(strings.Fields(strings.ToLower(payeeNoise.ReplaceAllString(payee, " "))), " ")
+}
+
+func transactionAmount(t transaction.Transaction) decimal.Decimal {
+	return utils.SumBy(t.Postings, func(p posting.Posting) decimal.Decimal {
+		if p.Amount.IsPositive() {
+			return p.Amount
+		}
+		return decimal.Zero
+	})
+}
+
+func primaryAccount(t transaction.Transaction) string {
+	return lo.MaxBy(t.Postings, func(a posting.Posting, b posting.Posting) bool {
+		return a.Amount.GreaterThan(b.Amount)
+	}).Account
+}
+
+// clusterByAmount splits the transactions into clusters of similar
+// amount. Clusters that follow one another in time are joined back, as
+// that is usually a change in price.
+func clusterByAmount(transactions []transaction.Transaction) [][]transaction.Transaction {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactionAmount(transactions[i]).LessThan(transactionAmount(transactions[j]))
+	})
+
+	var clusters [][]transaction.Transaction
+	for _, t := range transactions {
+		if len(clusters) > 0 {
+			last := clusters[len(clusters)-1]
+			previous := transactionAmount(last[len(last)-1])
+			if transactionAmount(t).LessThanOrEqual(previous.Mul(decimal.NewFromFloat(1.25))) {
+				clusters[len(clusters)-1] = append(last, t)
+				continue
+			}
+		}
+		clusters = append(clusters, []transaction.Transaction{t})
+	}
+
+	for _, cluster := range clusters {
+		sortTransactionsByDate(cluster)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i][0].Date.Before(clusters[j][0].Date) })
+
+	var joined [][]transaction.Transaction
+	for _, cluster := range clusters {
+		if len(joined) > 0 {
+			last := joined[len(joined)-1]
+			if last[len(last)-1].Date.Before(cluster[0].Date) {
+				joined[len(joined)-1] = append(last, cluster...)
+				continue
+			}
+		}
+		joined = append(joined, cluster)
+	}
+	return joined
+}
+
+func sortTransactionsByDate(transactions []transaction.Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Date.Before(transactions[j].Date) })
+}
+
+// detectPeriod checks if the interval between the transactions is a
+// multiple of one of the periods. Most of the intervals should be a
+// single period, the rest are considered missed occurrences.
+func detectPeriod(transactions []transaction.Transaction, now time.Time) (RecurringSuggestion, bool) {
+	intervals := make([]float64, len(transactions)-1)
+	for i := 1; i < len(transactions); i++ {
+		intervals[i-1] = transactions[i].Date.Sub(transactions[i-1].Date).Hours() / 24
+	}
+
+	for _, period := range recurringPeriods {
+		if len(transactions) < period.minOccurrences {
+			continue
+		}
+
+		single := 0
+		var missed []time.Time
+		fits := lo.EveryBy(lo.Range(len(intervals)), func(i int) bool {
+			n := math.Round(intervals[i] / period.days)
+			if n < 1 || math.Abs(intervals[i]-n*period.days) > period.tolerance*n {
+				return false
+			}
+			if n == 1 {
+				single++
+			}
+			date := transactions[i].Date
+			for k := 1; k < int(n); k++ {
+				date = period.next(date)
+				missed = append(missed, date)
+			}
+			return true
+		})
+		if !fits || float64(single) < math.Ceil(float64(len(intervals))*2/3) {
+			continue
+		}
+
+		last := transactions[len(transactions)-1]
+		next := period.next(last.Date)
+		overdue := 0
+		for next.AddDate(0, 0, int(period.tolerance)).Before(now) {
+			missed = append(missed, next)
+			next = period.next(next)
+			overdue++
+		}
+		if overdue > maxOverdue {
+			return RecurringSuggestion{}, false
+		}
+
+		// the amount before the most recent change in price
+		amount := transactionAmount(last)
+		previous := amount
+		changedOn := time.Time{}
+		for i := len(transactions) - 2; i >= 0; i-- {
+			if !transactionAmount(transactions[i]).Equal(amount) {
+				previous = transactionAmount(transactions[i])
+				changedOn = transactions[i+1].Date
+				break
+			}
+		}
+		return RecurringSuggestion{
+			Key:            recurringKey(last),
+			Account:        primaryAccount(last),
+			Period:         period.name,
+			TagPeriod:      tagPeriod(period.name, last.Date),
+			Interval:       int(math.Round(period.days)),
+			Amount:         amount,
+			PreviousAmount: previous,
+			Increased:      amount.GreaterThan(previous),
+			ChangedOn:      changedOn,
+			NextDate:       next,
+			Missed:         lo.Ternary(missed == nil, []time.Time{}, missed),
+			Transactions:   lo.Reverse(transactions),
+		}, true
+	}
+	return RecurringSuggestion{}, false
+}
+
+func recurringKey(t transaction.Transaction) string {
+	key := strings.TrimSpace(payeeDigits.ReplaceAllString(t.Payee, " "))
+	key = strings.Join(strings.Fields(key), " ")
+	if key == "" {
+		return primaryAccount(t)
+	}
+	return key
+}
+
+// tagPeriod builds the Period tag value based on the last occurrence.
+func tagPeriod(period string, date time.Time) string {
+	day := fmt.Sprint(date.Day())
+	if utils.IsSameDate(date, utils.EndOfMonth(date)) {
+		day = "L"
+	}
+
+	switch period {
+	case "weekly":
+		return fmt.Sprintf("? * %d", date.Weekday())
+	case "monthly":
+		return day + " * ?"
+	case "quarterly":
+		months := lo.Map(lo.Range(4), func(i int, _ int) int {
+			return (int(date.Month())-1+i*3)%12 + 1
+		})
+		sort.Ints(months)
+		return day + " " + strings.Join(lo.Map(months, func(m int, _ int) string { return fmt.Sprint(m) }), ",") + " ?"
+	case "yearly":
+		return fmt.Sprintf("%s %d ?", day, date.Month())
+	}
+	return ""
+}
+
+// TagRecurringTransactions adds the Recurring and Period metadata to
+// the transactions in the journal.
+func TagRecurringTransactions(db *gorm.DB, request RecurringTagRequest) gin.H {
+	if config.GetConfig().Readonly {
+		return gin.H{"success": false, "message": "Readonly mode"}
+	}
+
+	if strings.TrimSpace(request.Key) == "" || strings.ContainsAny(request.Key+request.Period, "\n;") {
+		return gin.H{"success": false, "message": "Invalid key or period"}
+	}
+
+	var transactions []transaction.Transaction
+	for _, id := range request.TransactionIDs {
+		t, found := transaction.GetById(db, id)
+		if !found {
+			return gin.H{"success": false, "message": fmt.Sprintf("Transaction %s not found", id)}
+		}
+		transactions = append(transactions, t)
+	}
+
+	tags := recurringTags(strings.TrimSpace(request.Key), strings.TrimSpace(request.Period))
+	for fileName, ts := range lo.GroupBy(transactions, func(t transaction.Transaction) string { return t.FileName }) {
+		// insert from the bottom so that the line numbers stay valid
+		sort.Slice(ts, func(i, j int) bool { return ts[i].BeginLine > ts[j].BeginLine })
+		err := updateLedgerFile(fileName, func(lines []string) ([]string, error) {
+			for _, t := range ts {
+				if t.BeginLine == 0 || int(t.EndLine) > len(lines) || !transactionHeader.MatchString(lines[t.BeginLine-1]) {
+					return nil, fmt.Errorf("Transaction at %s:%d has changed, sync and try again", fileName, t.BeginLine)
+				}
+				lines = append(lines[:t.BeginLine], append(append([]string{}, tags...), lines[t.BeginLine:]...)...)
+			}
+			return lines, nil
+		})
+		if err != nil {
+			return gin.H{"success": false, "message": err.Error()}
+		}
+	}
+
+	return Sync(db, SyncRequest{Journal: true})
+}
+
+// recurringTags builds the metadata lines, beancount uses the metadata
+// syntax instead of the tags in the comment.
+func recurringTags(key string, period string) []string {
+	if config.GetConfig().LedgerCli == "beancount" {
+		tags := []string{"  recurring: " + strconv.Quote(key)}
+		if period != "" {
+			tags = append(tags, "  period: "+strconv.Quote(period))
+		}
+		return tags
+	}
+
+	tags := []string{"    ; Recurring: " + key}
+	if period != "" {
+		tags = append(tags, "    ; Period: "+period)
+	}
+	return tags
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTransaction(date string, payee string, account string, amount int64) transaction.Transaction {
+	d, _ := time.Parse("2006-01-02", date)
+	id := fmt.Sprintf("%s-%s-%d", date, payee, amount)
+	return transaction.Transaction{
+		ID:    id,
+		Date:  d,
+		Payee: payee,
+		Postings: []posting.Posting{
+			{TransactionID: id, Date: d, Payee: payee, Account: account, Amount: decimal.NewFromInt(amount)},
+			{TransactionID: id, Date: d, Payee: payee, Account: "Assets:Checking", Amount: decimal.NewFromInt(-amount)},
+		},
+	}
+}
+
+func TestDetectRecurringTransactions(t *testing.T) {
+	now, _ := time.Parse("2006-01-02", "2023-07-20")
+	transactions := []transaction.Transaction{
+		buildTransaction("2023-01-05", "NETFLIX 1234", "Expenses:Entertainment", 499),
+		buildTransaction("2023-02-05", "NETFLIX 5678", "Expenses:Entertainment", 499),
+		buildTransaction("2023-03-06", "NETFLIX 9012", "Expenses:Entertainment", 499),
+		buildTransaction("2023-05-05", "NETFLIX 3456", "Expenses:Entertainment", 649),
+		buildTransaction("2023-06-05", "NETFLIX 7890", "Expenses:Entertainment", 649),
+		buildTransaction("2023-07-05", "NETFLIX 1111", "Expenses:Entertainment", 649),
+
+		buildTransaction("2022-03-31", "Insurance", "Expenses:Insurance", 12000),
+		buildTransaction("2023-03-31", "Insurance", "Expenses:Insurance", 12500),
+
+		buildTransaction("2023-01-02", "Grocery", "Expenses:Food", 1200),
+		buildTransaction("2023-01-09", "Grocery", "Expenses:Food", 5000),
+		buildTransaction("2023-01-13", "Grocery", "Expenses:Food", 300),
+		buildTransaction("2023-02-21", "Grocery", "Expenses:Food", 2200),
+
+		buildTransaction("2022-01-10", "Gym", "Expenses:Fitness", 2000),
+		buildTransaction("2022-02-10", "Gym", "Expenses:Fitness", 2000),
+		buildTransaction("2022-03-10", "Gym", "Expenses:Fitness", 2000),
+	}
+
+	suggestions := DetectRecurringTransactions(transactions, now)
+	require.Len(t, suggestions, 2)
+
+	netflix := suggestions[0]
+	assert.Equal(t, "NETFLIX", netflix.Key)
+	assert.Equal(t, "Expenses:Entertainment", netflix.Account)
+	assert.Equal(t, "monthly", netflix.Period)
+	assert.Equal(t, "5 * ?", netflix.TagPeriod)
+	assert.Len(t, netflix.Transactions, 6)
+	assert.True(t, netflix.Increased)
+	assert.Equal(t, "499", netflix.PreviousAmount.String())
+	assert.Equal(t, "649", netflix.Amount.String())
+	assert.Equal(t, "2023-05-05", netflix.ChangedOn.Format("2006-01-02"))
+	assert.Equal(t, "2023-08-05", netflix.NextDate.Format("2006-01-02"))
+	require.Len(t, netflix.Missed, 1)
+	assert.Equal(t, "2023-04-06", netflix.Missed[0].Format("2006-01-02"))
+
+	insurance := suggestions[1]
+	assert.Equal(t, "yearly", insurance.Period)
+	assert.Equal(t, "L 3 ?", insurance.TagPeriod)
+	assert.Equal(t, "2024-03-31", insurance.NextDate.Format("2006-01-02"))
+	assert.Empty(t, insurance.Missed)
+}
+
+func TestTagPeriod(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2023-05-15")
+	assert.Equal(t, "? * 1", tagPeriod("weekly", date))
+	assert.Equal(t, "15 2,5,8,11 ?", tagPeriod("quarterly", date))
+	assert.Equal(t, "15 5 ?", tagPeriod("yearly", date))
+}
+
+func TestRecurringTags(t *testing.T) {
+	loadLedgerCliConfig(t, "ledger")
+	assert.Equal(t, []string{"    ; Recurring: Netflix", "    ; Period: 5 * ?"}, recurringTags("Netflix", "5 * ?"))
+	assert.Equal(t, []string{"    ; Recurring: Netflix"}, recurringTags("Netflix", ""))
+
+	loadLedgerCliConfig(t, "beancount")
+	assert.Equal(t, []string{`  recurring: "Netflix"`, `  period: "5 * ?"`}, recurringTags("Netflix", "5 * ?"))
+	assert.Equal(t, []string{`  recurring: "Rent \"Home\""`}, recurringTags(`Rent "Home"`, ""))
+}
@@ -0,0 +1,532 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/accounting"
+	"github.com/ananthakumaran/paisa/internal/export"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/transaction"
+	"github.com/ananthakumaran/paisa/internal/query"
+	"github.com/ananthakumaran/paisa/internal/server/liabilities"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/ananthakumaran/paisa/internal/taxation"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportNetworth             = "networth"
+	ReportExpense              = "expense"
+	ReportIncome               = "income"
+	ReportCashFlow             = "cash_flow"
+	ReportIncomeStatement      = "income_statement"
+	ReportBudget               = "budget"
+	ReportGain                 = "gain"
+	ReportCapitalGains         = "capital_gains"
+	ReportLotSelection         = "lot_selection"
+	ReportHarvest              = "harvest"
+	ReportAllocation           = "allocation"
+	ReportRecurring            = "recurring"
+	ReportCreditCards          = "credit_cards"
+	ReportLiabilitiesBalance   = "liabilities_balance"
+	ReportLiabilitiesInterest  = "liabilities_interest"
+	ReportLiabilitiesRepayment = "liabilities_repayment"
+	ReportLoans                = "loans"
+)
+
+var ReportNames = []string{ReportNetworth, ReportExpense, ReportIncome, ReportCashFlow, ReportIncomeStatement, ReportBudget, ReportGain, ReportCapitalGains, ReportLotSelection, ReportHarvest, ReportAllocation, ReportRecurring, ReportCreditCards, ReportLiabilitiesBalance, ReportLiabilitiesInterest, ReportLiabilitiesRepayment, ReportLoans}
+
+// ReportFilter limits the rows of the report. The zero dates leave the
+// range open and the account matches the account and its children. The
+// currency defaults to the default currency.
+type ReportFilter struct {
+	From     time.Time
+	To       time.Time
+	Account  string
+	Currency string
+}
+
+func (f ReportFilter) includes(date time.Time) bool {
+	return f.overlaps(date, date)
+}
+
+func (f ReportFilter) overlaps(start time.Time, end time.Time) bool {
+	return (f.From.IsZero() || !end.Before(f.From)) && (f.To.IsZero() || !start.After(f.To))
+}
+
+func (f ReportFilter) matches(account string) bool {
+	return f.Account == "" || utils.IsSameOrParent(account, f.Account)
+}
+
+// BuildReport runs the same computation as the report page and
+// flattens the result into tables, the first table is the primary one
+func BuildReport(db *gorm.DB, name string, filter ReportFilter) ([]export.Table, error) {
+	converter, err := service.NewConverter(db, filter.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// the reports of the current state can't be limited by date
+	if lo.Contains([]string{ReportGain, ReportHarvest, ReportLiabilitiesBalance}, name) && (!filter.From.IsZero() || !filter.To.IsZero()) {
+		return nil, fmt.Errorf("%s report is as of today, it doesn't support the date filters", name)
+	}
+
+	switch name {
+	case ReportNetworth:
+		return networthTables(db, converter, filter), nil
+	case ReportExpense:
+		return expenseTables(db, converter, filter), nil
+	case ReportIncome:
+		return incomeTables(db, converter, filter), nil
+	case ReportCashFlow:
+		if filter.Account != "" {
+			return nil, fmt.Errorf("%s report is across all the accounts, it doesn't support the account filter", name)
+		}
+		return cashFlowTables(db, converter, filter), nil
+	case ReportIncomeStatement:
+		return incomeStatementTables(db, converter, filter), nil
+	case ReportBudget:
+		return budgetTables(db, filter), nil
+	case ReportGain:
+		return gainTables(db, filter), nil
+	case ReportCapitalGains:
+		return capitalGainsTables(db, filter)
+	case ReportLotSelection:
+		return lotSelectionTables(db, filter)
+	case ReportHarvest:
+		return harvestTables(db, filter)
+	case ReportAllocation:
+		if !filter.From.IsZero() {
+			return nil, fmt.Errorf("%s report is as of a date, it doesn't support the start date", name)
+		}
+		return allocationTables(db, filter), nil
+	case ReportRecurring:
+		return recurringTables(db, filter), nil
+	case ReportCreditCards:
+		return creditCardTables(db, filter), nil
+	case ReportLiabilitiesBalance:
+		return liabilitiesBalanceTables(db, filter), nil
+	case ReportLiabilitiesInterest:
+		return liabilitiesInterestTables(db, filter), nil
+	case ReportLiabilitiesRepayment:
+		return liabilitiesRepaymentTables(db, filter), nil
+	case ReportLoans:
+		return loanTables(db, filter)
+	default:
+		return nil, fmt.Errorf("Invalid report %s, expected one of %s", name, strings.Join(ReportNames, ", "))
+	}
+}
+
+// networthTables has the networth at the end of each month
+func networthTables(db *gorm.DB, converter *service.Converter, filter ReportFilter) []export.Table {
+	postings := lo.Filter(networthPostings(db), func(p posting.Posting, _ int) bool { return filter.matches(p.Account) })
+	timeline := computeNetworthTimeline(db, postings, false, converter)
+
+	table := export.Table{Name: "networth", Columns: []string{"date", "investment", "withdrawal", "gain", "balance", "net_investment"}}
+	for i, networth := range timeline {
+		last := i == len(timeline)-1 || timeline[i+1].Date.Format("2006-01") != networth.Date.Format("2006-01")
+		if last && filter.includes(networth.Date) {
+			table.Add(networth.Date, networth.InvestmentAmount, networth.WithdrawalAmount, networth.GainAmount, networth.BalanceAmount, networth.NetInvestmentAmount)
+		}
+	}
+	return []export.Table{table}
+}
+
+func expenseTables(db *gorm.DB, converter *service.Converter, filter ReportFilter) []export.Table {
+	expenses := lo.Filter(expenseReport(db, converter).Expenses, func(p posting.Posting, _ int) bool {
+		return filter.includes(p.Date) && filter.matches(p.Account)
+	})
+
+	table := export.Table{Name: "expense", Columns: []string{"month", "account", "amount"}}
+	byMonth := utils.GroupByMonth(expenses)
+	for _, month := range utils.SortedKeys(byMonth) {
+		byAccount := lo.GroupBy(byMonth[month], func(p posting.Posting) string { return p.Account })
+		for _, account := range utils.SortedKeys(byAccount) {
+			table.Add(month, account, utils.SumBy(byAccount[account], func(p posting.Posting) decimal.Decimal { return p.Amount }))
+		}
+	}
+	return []export.Table{table}
+}
+
+// incomeTables has the income of each account in each month, the tax
+// paid and the yearly totals
+func incomeTables(db *gorm.DB, converter *service.Converter, filter ReportFilter) []export.Table {
+	report, _ := incomeReport(db, converter)
+
+	table := export.Table{Name: "income", Columns: []string{"month", "account", "amount"}}
+	for _, income := range report.IncomeTimeline {
+		byAccount := lo.GroupBy(lo.Filter(income.Postings, func(p posting.Posting, _ int) bool {
+			return filter.includes(p.Date) && filter.matches(p.Account)
+		}), func(p posting.Posting) string { return p.Account })
+		for _, account := range utils.SortedKeys(byAccount) {
+			table.Add(income.Date.Format("2006-01"), account, utils.SumBy(byAccount[account], func(p posting.Posting) decimal.Decimal { return p.Amount.Neg() }))
+		}
+	}
+
+	tax := export.Table{Name: "tax", Columns: []string{"financial_year", "account", "amount"}}
+	for _, t := range report.TaxTimeline {
+		byAccount := lo.GroupBy(lo.Filter(t.Postings, func(p posting.Posting, _ int) bool {
+			return filter.includes(p.Date) && filter.matches(p.Account)
+		}), func(p posting.Posting) string { return p.Account })
+		for _, account := range utils.SortedKeys(byAccount) {
+			tax.Add(utils.FYHuman(t.StartDate), account, utils.SumBy(byAccount[account], func(p posting.Posting) decimal.Decimal { return p.Amount }))
+		}
+	}
+
+	yearly := export.Table{Name: "yearly", Columns: []string{"financial_year", "gross_income", "net_tax", "net_income"}}
+	for _, card := range report.YearlyCards {
+		if filter.overlaps(card.StartDate, card.EndDate) {
+			yearly.Add(utils.FYHuman(card.StartDate), card.GrossIncome, card.NetTax, card.NetIncome)
+		}
+	}
+	return []export.Table{table, tax, yearly}
+}
+
+func cashFlowTables(db *gorm.DB, converter *service.Converter, filter ReportFilter) []export.Table {
+	table := export.Table{Name: "cash_flow", Columns: []string{"month", "income", "expenses", "liabilities", "investment", "tax", "checking", "balance"}}
+	for _, cashFlow := range cashFlowReport(db, converter).CashFlows {
+		if filter.overlaps(cashFlow.Date, utils.EndOfMonth(cashFlow.Date)) {
+			table.Add(cashFlow.Date.Format("2006-01"), cashFlow.Income, cashFlow.Expenses, cashFlow.Liabilities, cashFlow.Investment, cashFlow.Tax, cashFlow.Checking, cashFlow.Balance)
+		}
+	}
+	return []export.Table{table}
+}
+
+func incomeStatementTables(db *gorm.DB, converter *service.Converter, filter ReportFilter) []export.Table {
+	yearly := incomeStatementReport(db, converter).Yearly
+
+	table := export.Table{Name: "income_statement", Columns: []string{"financial_year", "section", "account", "amount"}}
+	balances := export.Table{Name: "balances", Columns: []string{"financial_year", "starting_balance", "ending_balance"}}
+	for _, fy := range utils.SortedKeys(yearly) {
+		statement := yearly[fy]
+		if !filter.overlaps(statement.Date, utils.EndOfFinancialYear(statement.Date)) {
+			continue
+		}
+
+		balances.Add(fy, statement.StartingBalance, statement.EndingBalance)
+		sections := []struct {
+			name     string
+			accounts map[string]decimal.Decimal
+		}{
+			{"income", statement.Income},
+			{"interest", statement.Interest},
+			{"equity", statement.Equity},
+			{"pnl", statement.Pnl},
+			{"liabilities", statement.Liabilities},
+			{"tax", statement.Tax},
+			{"expenses", statement.Expenses},
+		}
+		for _, section := range sections {
+			for _, account := range utils.SortedKeys(section.accounts) {
+				if filter.matches(account) {
+					table.Add(fy, section.name, account, section.accounts[account])
+				}
+			}
+		}
+	}
+	return []export.Table{table, balances}
+}
+
+func budgetTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	budgetsByMonth := budgetReport(db).BudgetsByMonth
+
+	table := export.Table{Name: "budget", Columns: []string{"month", "account", "envelope", "forecast", "actual", "rollover", "available"}}
+	envelopes := export.Table{Name: "envelopes", Columns: []string{"month", "envelope", "forecast", "actual", "available"}}
+	for _, month := range utils.SortedKeys(budgetsByMonth) {
+		budget := budgetsByMonth[month]
+		if !filter.overlaps(budget.Date, utils.EndOfMonth(budget.Date)) {
+			continue
+		}
+
+		for _, account := range budget.Accounts {
+			if filter.matches(account.Account) {
+				table.Add(month, account.Account, account.Envelope, account.Forecast, account.Actual, account.Rollover, account.Available)
+			}
+		}
+		for _, envelope := range budget.Envelopes {
+			envelopes.Add(month, envelope.Name, envelope.Forecast, envelope.Actual, envelope.Available)
+		}
+	}
+	return []export.Table{table, envelopes}
+}
+
+// gainTables has the current gain of each account
+func gainTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	table := export.Table{Name: "gain", Columns: []string{"account", "investment", "withdrawal", "gain", "balance", "net_investment", "xirr"}}
+	for _, gain := range computeGains(db) {
+		if filter.matches(gain.Account) {
+			networth := gain.Networth
+			table.Add(gain.Account, networth.InvestmentAmount, networth.WithdrawalAmount, networth.GainAmount, networth.BalanceAmount, networth.NetInvestmentAmount, gain.XIRR)
+		}
+	}
+	return []export.Table{table}
+}
+
+// financialYearRange supports both 2023-24 and 2023 - 24 forms
+func financialYearRange(fy string) (time.Time, time.Time) {
+	return utils.ParseFY(strings.SplitN(fy, "-", 2)[0])
+}
+
+func capitalGainsTables(db *gorm.DB, filter ReportFilter) ([]export.Table, error) {
+	report, err := capitalGainsReport(db)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.Table{Name: "capital_gains", Columns: []string{"financial_year", "account", "tax_category", "units", "purchase_price", "sell_price", "gain", "taxable", "short_term_tax", "long_term_tax", "slab_tax"}}
+	sales := export.Table{Name: "sales", Columns: []string{"financial_year", "account", "purchase_date", "sell_date", "units", "purchase_price", "sell_price", "gain", "taxable"}}
+	exemptions := export.Table{Name: "exemptions", Columns: []string{"financial_year", "limit", "used", "remaining", "tax_saved"}}
+
+	fys := lo.Uniq(lo.FlatMap(lo.Values(report.CapitalGains), func(capitalGain CapitalGain, _ int) []string { return lo.Keys(capitalGain.FY) }))
+	fys = lo.Filter(fys, func(fy string, _ int) bool { return filter.overlaps(financialYearRange(fy)) })
+	sort.Strings(fys)
+
+	for _, fy := range fys {
+		for _, account := range utils.SortedKeys(report.CapitalGains) {
+			capitalGain := report.CapitalGains[account]
+			fyCapitalGain, ok := capitalGain.FY[fy]
+			if !ok || !filter.matches(account) {
+				continue
+			}
+
+			tax := fyCapitalGain.Tax
+			table.Add(fy, account, capitalGain.TaxCategory, fyCapitalGain.Units, fyCapitalGain.PurchasePrice, fyCapitalGain.SellPrice, tax.Gain, tax.Taxable, tax.ShortTerm, tax.LongTerm, tax.Slab)
+			for _, pair := range fyCapitalGain.PostingPairs {
+				sales.Add(fy, account, pair.Purchase.Date, pair.Sell.Date, pair.Purchase.Quantity, pair.Purchase.Amount, pair.Purchase.Quantity.Mul(pair.Sell.Price()), pair.Tax.Gain, pair.Tax.Taxable)
+			}
+		}
+
+		if exemption, ok := report.Exemptions[fy]; ok {
+			exemptions.Add(fy, exemption.Limit, exemption.Used, exemption.Remaining, exemption.TaxSaved)
+		}
+	}
+	return []export.Table{table, sales, exemptions}, nil
+}
+
+// lotSelectionTables has the tax of each financial year with each lot
+// selection method and the current method of each account
+func lotSelectionTables(db *gorm.DB, filter ReportFilter) ([]export.Table, error) {
+	regime, err := taxation.CurrentRegime()
+	if err != nil {
+		return nil, err
+	}
+	comparison, current := computeLotSelectionComparison(db, regime, filter)
+
+	table := export.Table{Name: "lot_selection", Columns: []string{"financial_year", "lot_selection", "gain", "taxable", "short_term_tax", "long_term_tax", "slab_tax"}}
+	for _, fy := range utils.SortedKeys(comparison) {
+		if !filter.overlaps(financialYearRange(fy)) {
+			continue
+		}
+		for _, selection := range accounting.LotSelections {
+			if tax, ok := comparison[fy][selection]; ok {
+				table.Add(fy, string(selection), tax.Gain, tax.Taxable, tax.ShortTerm, tax.LongTerm, tax.Slab)
+			}
+		}
+	}
+
+	accounts := export.Table{Name: "accounts", Columns: []string{"account", "lot_selection"}}
+	for _, account := range utils.SortedKeys(current) {
+		accounts.Add(account, string(current[account]))
+	}
+	return []export.Table{table, accounts}, nil
+}
+
+// harvestTables has the harvestable units of each account, the lots
+// and the plan to use up the exemption of the current financial year
+func harvestTables(db *gorm.DB, filter ReportFilter) ([]export.Table, error) {
+	regime, err := taxation.CurrentRegime()
+	if err != nil {
+		return nil, err
+	}
+	harvestables := computeHarvestables(db, regime)
+
+	table := export.Table{Name: "harvest", Columns: []string{"account", "tax_category", "total_units", "harvestable_units", "unrealized_gain", "taxable_unrealized_gain", "current_unit_price", "current_unit_date"}}
+	lots := export.Table{Name: "lots", Columns: []string{"account", "purchase_date", "units", "purchase_unit_price", "purchase_price", "current_price", "gain", "taxable"}}
+	for _, account := range utils.SortedKeys(harvestables) {
+		if !filter.matches(account) {
+			continue
+		}
+
+		harvestable := harvestables[account]
+		table.Add(account, harvestable.TaxCategory, harvestable.TotalUnits, harvestable.HarvestableUnits, harvestable.UnrealizedGain, harvestable.TaxableUnrealizedGain, harvestable.CurrentUnitPrice, harvestable.CurrentUnitDate)
+		for _, lot := range harvestable.HarvestBreakdown {
+			lots.Add(account, lot.PurchaseDate, lot.Units, lot.PurchaseUnitPrice, lot.PurchasePrice, lot.CurrentPrice, lot.Tax.Gain, lot.Tax.Taxable)
+		}
+	}
+
+	plan := export.Table{Name: "plan", Columns: []string{"account", "purchase_date", "units", "purchase_unit_price", "current_unit_price", "gain", "taxable"}}
+	for _, lot := range computeHarvestPlan(db, regime).Lots {
+		if filter.matches(lot.Account) {
+			plan.Add(lot.Account, lot.PurchaseDate, lot.Units, lot.PurchaseUnitPrice, lot.CurrentUnitPrice, lot.Tax.Gain, lot.Tax.Taxable)
+		}
+	}
+	return []export.Table{table, lots, plan}, nil
+}
+
+// allocationTables has the allocation as of the end date, defaults to
+// today
+func allocationTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	date := utils.EndOfToday()
+	if !filter.To.IsZero() {
+		date = filter.To
+	}
+
+	postings := query.Init(db).Like("Assets:%").Where("date <= ?", date).All()
+	postings = lo.FilterMap(postings, func(p posting.Posting, _ int) (posting.Posting, bool) {
+		p.MarketAmount = service.GetMarketPrice(db, p, date)
+		return p, filter.matches(p.Account)
+	})
+
+	aggregates := lo.Filter(lo.Values(computeAggregate(db, postings, date)), func(aggregate Aggregate, _ int) bool { return !aggregate.Date.IsZero() })
+	sort.Slice(aggregates, func(i, j int) bool { return aggregates[i].Account < aggregates[j].Account })
+	total := utils.SumBy(aggregates, func(aggregate Aggregate) decimal.Decimal { return aggregate.MarketAmount })
+
+	table := export.Table{Name: "allocation", Columns: []string{"account", "market_amount", "percentage"}}
+	for _, aggregate := range aggregates {
+		percentage := decimal.Zero
+		if !total.IsZero() {
+			percentage = aggregate.MarketAmount.Div(total).Mul(decimal.NewFromInt(100))
+		}
+		table.Add(aggregate.Account, aggregate.MarketAmount, percentage)
+	}
+
+	targets := export.Table{Name: "targets", Columns: []string{"name", "target", "current"}}
+	for _, target := range computeAllocationTargets(db, postings) {
+		targets.Add(target.Name, target.Target, target.Current)
+	}
+	return []export.Table{table, targets}
+}
+
+// recurringTables has the recurring transactions and their postings
+func recurringTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	table := export.Table{Name: "recurring", Columns: []string{"key", "period", "interval", "count", "first_date", "last_date"}}
+	postings := export.Table{Name: "postings", Columns: []string{"key", "date", "payee", "account", "amount"}}
+	for _, ts := range ComputeRecurringTransactions(query.Init(db).All()) {
+		transactions := lo.Filter(ts.Transactions, func(t transaction.Transaction, _ int) bool {
+			return filter.includes(t.Date) && lo.SomeBy(t.Postings, func(p posting.Posting) bool { return filter.matches(p.Account) })
+		})
+		if len(transactions) == 0 {
+			continue
+		}
+
+		sort.Slice(transactions, func(i, j int) bool { return transactions[i].Date.Before(transactions[j].Date) })
+		table.Add(ts.Key, ts.Period, ts.Interval, len(transactions), transactions[0].Date, transactions[len(transactions)-1].Date)
+		for _, t := range transactions {
+			for _, p := range t.Postings {
+				postings.Add(ts.Key, t.Date, t.Payee, p.Account, p.Amount)
+			}
+		}
+	}
+	return []export.Table{table, postings}
+}
+
+func creditCardTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	cards := export.Table{Name: "credit_cards", Columns: []string{"account", "network", "number", "credit_limit", "balance", "health", "on_time", "late", "partial", "unpaid", "utilization", "finance_charges", "late_fees", "other_charges"}}
+	bills := export.Table{Name: "bills", Columns: []string{"account", "statement_start_date", "statement_end_date", "due_date", "paid_date", "opening_balance", "debits", "credits", "closing_balance", "minimum_due", "paid_by_due_date", "payment_status", "utilization", "finance_charges", "late_fees", "other_charges"}}
+
+	for _, card := range creditCards(db) {
+		if !filter.matches(card.Account) {
+			continue
+		}
+
+		health := card.Health
+		cards.Add(card.Account, card.Network, card.Number, card.CreditLimit, card.Balance, health.Status, health.OnTime, health.Late, health.Partial, health.Unpaid, health.Utilization, health.FinanceCharges, health.LateFees, health.OtherCharges)
+		for _, bill := range card.Bills {
+			if !filter.overlaps(bill.StatementStartDate, bill.StatementEndDate) {
+				continue
+			}
+
+			var paidDate any
+			if bill.PaidDate != nil {
+				paidDate = *bill.PaidDate
+			}
+			bills.Add(card.Account, bill.StatementStartDate, bill.StatementEndDate, bill.DueDate, paidDate, bill.OpeningBalance, bill.Debits, bill.Credits, bill.ClosingBalance, bill.MinimumDue, bill.PaidByDueDate, bill.PaymentStatus, bill.Utilization, bill.FinanceCharges, bill.LateFees, bill.OtherCharges)
+		}
+	}
+	return []export.Table{cards, bills}
+}
+
+func liabilitiesBalanceTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	breakdowns := liabilities.Breakdowns(db)
+
+	table := export.Table{Name: "liabilities_balance", Columns: []string{"account", "drawn_amount", "repaid_amount", "interest_amount", "balance_amount", "apr"}}
+	for _, account := range utils.SortedKeys(breakdowns) {
+		if filter.matches(account) {
+			breakdown := breakdowns[account]
+			table.Add(account, breakdown.DrawnAmount, breakdown.RepaidAmount, breakdown.InterestAmount, breakdown.BalanceAmount, breakdown.APR)
+		}
+	}
+	return []export.Table{table}
+}
+
+// liabilitiesInterestTables has the drawn, repaid and the interest
+// amount of each account at the end of each month
+func liabilitiesInterestTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	interests := lo.Filter(liabilities.Interests(db), func(interest liabilities.Interest, _ int) bool { return filter.matches(interest.Account) })
+	sort.Slice(interests, func(i, j int) bool { return interests[i].Account < interests[j].Account })
+
+	table := export.Table{Name: "liabilities_interest", Columns: []string{"account", "date", "drawn_amount", "repaid_amount", "interest_amount"}}
+	apr := export.Table{Name: "apr", Columns: []string{"account", "apr"}}
+	for _, interest := range interests {
+		apr.Add(interest.Account, interest.APR)
+		timeline := interest.OverviewTimeline
+		for i, overview := range timeline {
+			last := i == len(timeline)-1 || timeline[i+1].Date.Format("2006-01") != overview.Date.Format("2006-01")
+			if last && filter.includes(overview.Date) {
+				table.Add(interest.Account, overview.Date, overview.DrawnAmount, overview.RepaidAmount, overview.InterestAmount)
+			}
+		}
+	}
+	return []export.Table{table, apr}
+}
+
+func liabilitiesRepaymentTables(db *gorm.DB, filter ReportFilter) []export.Table {
+	repayments := lo.Filter(liabilities.Repayments(db), func(p posting.Posting, _ int) bool {
+		return filter.includes(p.Date) && filter.matches(p.Account)
+	})
+	sort.SliceStable(repayments, func(i, j int) bool { return repayments[i].Date.Before(repayments[j].Date) })
+
+	table := export.Table{Name: "liabilities_repayment", Columns: []string{"date", "payee", "account", "amount"}}
+	for _, p := range repayments {
+		table.Add(p.Date, p.Payee, p.Account, p.Amount)
+	}
+	return []export.Table{table}
+}
+
+// loanTables has the loans with the amortization schedule and the
+// actual payments
+func loanTables(db *gorm.DB, filter ReportFilter) ([]export.Table, error) {
+	loans, err := liabilities.BuildLoans(db)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.Table{Name: "loans", Columns: []string{"account", "principal", "rate", "start_date", "end_date", "tenure", "emi", "total_interest", "total_payment", "scheduled_outstanding", "actual_outstanding"}}
+	installments := export.Table{Name: "installments", Columns: []string{"account", "number", "date", "rate", "opening_balance", "emi", "interest", "principal", "prepayment", "closing_balance"}}
+	payments := export.Table{Name: "payments", Columns: []string{"account", "date", "scheduled", "actual", "difference", "scheduled_balance", "actual_balance"}}
+	for _, loan := range loans {
+		if !filter.matches(loan.Account) {
+			continue
+		}
+
+		schedule := loan.Schedule
+		table.Add(loan.Account, loan.Principal, loan.Rate, loan.StartDate, schedule.EndDate, schedule.Tenure, schedule.EMI, schedule.TotalInterest, schedule.TotalPayment, loan.Outstanding.Scheduled, loan.Outstanding.Actual)
+		for _, installment := range schedule.Installments {
+			if filter.includes(installment.Date) {
+				installments.Add(loan.Account, installment.Number, installment.Date, installment.Rate, installment.OpeningBalance, installment.EMI, installment.Interest, installment.Principal, installment.Prepayment, installment.ClosingBalance)
+			}
+		}
+		for _, payment := range loan.Payments {
+			if filter.includes(payment.Date) {
+				payments.Add(loan.Account, payment.Date, payment.Scheduled, payment.Actual, payment.Difference, payment.ScheduledBalance, payment.ActualBalance)
+			}
+		}
+	}
+	return []export.Table{table, installments, payments}, nil
+}
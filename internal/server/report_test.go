@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/export"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReport(t *testing.T) {
+	db := openAPITestDB(t)
+
+	for _, name := range ReportNames {
+		tables, err := BuildReport(db, name, ReportFilter{})
+		require.NoError(t, err, name)
+		require.NotEmpty(t, tables, name)
+		for _, table := range tables {
+			for _, row := range table.Rows {
+				assert.Len(t, row, len(table.Columns), table.Name)
+			}
+		}
+	}
+
+	tables, err := BuildReport(db, ReportExpense, ReportFilter{From: parseDate("2023-02-01")})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"2023-02", "Expenses:Shopping", decimal.NewFromInt(5000)}}, tables[0].Rows)
+	assert.Equal(t, []map[string]any{{"month": "2023-02", "account": "Expenses:Shopping", "amount": decimal.NewFromInt(5000)}}, tables[0].Records())
+
+	tables, err = BuildReport(db, ReportExpense, ReportFilter{To: utils.EndOfDay(parseDate("2023-01-31")), Account: "Expenses:Food"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"2023-01", "Expenses:Food", decimal.NewFromInt(2000)}}, tables[0].Rows)
+
+	tables, err = BuildReport(db, ReportIncomeStatement, ReportFilter{Account: "Income"})
+	require.NoError(t, err)
+	require.Len(t, tables[0].Rows, 1)
+	assert.Equal(t, "Income:Salary", tables[0].Rows[0][2])
+
+	tables, err = BuildReport(db, ReportIncome, ReportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"2023-01", "Income:Salary", decimal.NewFromInt(100000)}}, tables[0].Rows)
+
+	tables, err = BuildReport(db, ReportLoans, ReportFilter{From: parseDate("2023-03-01"), To: utils.EndOfDay(parseDate("2023-03-31"))})
+	require.NoError(t, err)
+	require.Len(t, tables[1].Rows, 1)
+	assert.Equal(t, 2, tables[1].Rows[0][1])
+
+	tables, err = BuildReport(db, ReportLiabilitiesRepayment, ReportFilter{Account: "Liabilities:HomeLoan"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{parseDate("2023-02-05"), "EMI", "Liabilities:HomeLoan", decimal.NewFromInt(88848)}}, tables[0].Rows)
+
+	_, err = BuildReport(db, ReportAllocation, ReportFilter{From: parseDate("2023-01-01")})
+	assert.Error(t, err)
+
+	_, err = BuildReport(db, ReportGain, ReportFilter{To: parseDate("2023-01-01")})
+	assert.Error(t, err)
+
+	_, err = BuildReport(db, ReportCashFlow, ReportFilter{Account: "Assets"})
+	assert.Error(t, err)
+
+	_, err = BuildReport(db, "unknown", ReportFilter{})
+	assert.Error(t, err)
+}
+
+func TestReportExport(t *testing.T) {
+	db := openAPITestDB(t)
+	router := Build(db, false)
+
+	for _, target := range []string{"/api/expense?format=csv&from=2023-02-01", "/api/v1/expense?format=csv&from=2023-02-01"} {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code, target)
+		assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="expense.csv"`, recorder.Header().Get("Content-Disposition"))
+		assert.Equal(t, "month,account,amount\n2023-02,Expenses:Shopping,5000\n", recorder.Body.String())
+	}
+
+	for _, target := range []string{"/api/income?format=csv", "/api/v1/income?format=csv"} {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code, target)
+		assert.Equal(t, "month,account,amount\n2023-01,Income:Salary,100000\n", recorder.Body.String())
+	}
+
+	for _, path := range []string{"/api/gain", "/api/cash_flow", "/api/recurring", "/api/harvest", "/api/capital_gains/lot_selection", "/api/liabilities/balance", "/api/liabilities/interest", "/api/liabilities/repayment", "/api/liabilities/loans", "/api/v1/cash_flow", "/api/v1/recurring", "/api/v1/liabilities/loans"} {
+		request := httptest.NewRequest(http.MethodGet, path+"?format=xlsx", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code, path)
+		assert.Equal(t, export.ContentType(export.XLSX), recorder.Header().Get("Content-Type"), path)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/capital_gains?format=parquet&table=sales", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `attachment; filename="capital_gains-sales.parquet"`, recorder.Header().Get("Content-Disposition"))
+
+	request = httptest.NewRequest(http.MethodGet, "/api/capital_gains?format=xlsx", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `attachment; filename="capital_gains.xlsx"`, recorder.Header().Get("Content-Disposition"))
+
+	request = httptest.NewRequest(http.MethodGet, "/api/allocation?format=csv&from=2023-01-01", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -48,6 +48,12 @@ type ScheduleAL struct {
 }
 
 func GetScheduleAL(db *gorm.DB) gin.H {
+	return gin.H{"schedule_als": computeScheduleALs(db)}
+}
+
+// computeScheduleALs returns the Schedule AL as of the end of each
+// financial year
+func computeScheduleALs(db *gorm.DB) map[string]ScheduleAL {
 	postings := query.Init(db).Like("Assets:%", "Liabilities:%").All()
 	var scheduleALs map[string]ScheduleAL = make(map[string]ScheduleAL)
 
@@ -64,7 +70,7 @@ func GetScheduleAL(db *gorm.DB) gin.H {
 		scheduleALs[utils.FYHuman(start)] = ScheduleAL{Entries: computeScheduleAL(postings), Date: start}
 	}
 
-	return gin.H{"schedule_als": scheduleALs}
+	return scheduleALs
 }
 
 func computeScheduleAL(postings []posting.Posting) []ScheduleALEntry {
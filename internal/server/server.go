@@ -22,6 +22,7 @@ import (
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"github.com/throttled/throttled/v2"
 	"github.com/throttled/throttled/v2/store/memstore"
@@ -33,7 +34,7 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 
 	router := gin.New()
 	if enableCompression {
-		router.Use(gzip.Gzip(gzip.DefaultCompression))
+		router.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/api/events"})))
 	}
 
 	router.Use(Logger(log.StandardLogger()), gin.Recovery())
@@ -109,14 +110,26 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 		c.JSON(200, Sync(db, syncRequest))
 	})
 
+	router.GET("/api/events", func(c *gin.Context) {
+		StreamEvents(c)
+	})
+
 	router.GET("/api/dashboard", func(c *gin.Context) {
 		c.JSON(200, GetDashboard(db))
 	})
 
-	router.GET("/api/networth", func(c *gin.Context) {
-		c.JSON(200, GetNetworth(db))
+	router.GET("/api/currencies", func(c *gin.Context) {
+		c.JSON(200, GetCurrencies(db))
 	})
 
+	router.GET("/api/networth", reportHandler(db, ReportNetworth, func(c *gin.Context) {
+		converter, ok := reportingCurrency(db, c)
+		if !ok {
+			return
+		}
+		c.JSON(200, GetNetworth(db, converter))
+	}))
+
 	router.GET("/api/assets/balance", func(c *gin.Context) {
 		c.JSON(200, assets.GetBalance(db))
 	})
@@ -124,36 +137,100 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 	router.GET("/api/investment", func(c *gin.Context) {
 		c.JSON(200, GetInvestment(db))
 	})
-	router.GET("/api/gain", func(c *gin.Context) {
+	router.GET("/api/gain", reportHandler(db, ReportGain, func(c *gin.Context) {
 		c.JSON(200, GetGain(db))
-	})
+	}))
 	router.GET("/api/gain/:account", func(c *gin.Context) {
 		account := c.Param("account")
 		c.JSON(200, GetAccountGain(db, account))
 	})
-	router.GET("/api/income", func(c *gin.Context) {
-		c.JSON(200, GetIncome(db))
-	})
-	router.GET("/api/expense", func(c *gin.Context) {
-		c.JSON(200, GetExpense(db))
-	})
+	router.GET("/api/income", reportHandler(db, ReportIncome, func(c *gin.Context) {
+		converter, ok := reportingCurrency(db, c)
+		if !ok {
+			return
+		}
+		c.JSON(200, GetIncome(db, converter))
+	}))
+	router.GET("/api/expense", reportHandler(db, ReportExpense, func(c *gin.Context) {
+		converter, ok := reportingCurrency(db, c)
+		if !ok {
+			return
+		}
+		c.JSON(200, GetExpense(db, converter))
+	}))
 
-	router.GET("/api/budget", func(c *gin.Context) {
+	router.GET("/api/budget", reportHandler(db, ReportBudget, func(c *gin.Context) {
 		c.JSON(200, GetBudget(db))
+	}))
+
+	router.GET("/api/budget/alerts", func(c *gin.Context) {
+		c.JSON(200, GetBudgetAlerts(db))
 	})
 
-	router.GET("/api/cash_flow", func(c *gin.Context) {
-		c.JSON(200, GetCashFlow(db))
+	router.GET("/api/cash_flow", reportHandler(db, ReportCashFlow, func(c *gin.Context) {
+		converter, ok := reportingCurrency(db, c)
+		if !ok {
+			return
+		}
+		c.JSON(200, GetCashFlow(db, converter))
+	}))
+	router.GET("/api/income_statement", reportHandler(db, ReportIncomeStatement, func(c *gin.Context) {
+		converter, ok := reportingCurrency(db, c)
+		if !ok {
+			return
+		}
+		c.JSON(200, GetIncomeStatement(db, converter))
+	}))
+	router.GET("/api/recurring", reportHandler(db, ReportRecurring, func(c *gin.Context) {
+		c.JSON(200, GetRecurringTransactions(db))
+	}))
+	router.GET("/api/reconcile", func(c *gin.Context) {
+		balance, err := decimal.NewFromString(c.DefaultQuery("balance", "0"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid balance"})
+			return
+		}
+		reconciliation, err := GetReconciliation(db, ReconcileRequest{Account: c.Query("account"), Date: c.Query("date"), Commodity: c.Query("commodity"), Balance: balance})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"reconciliation": reconciliation})
 	})
-	router.GET("/api/income_statement", func(c *gin.Context) {
-		c.JSON(200, GetIncomeStatement(db))
+	router.POST("/api/reconcile", func(c *gin.Context) {
+		var request ReconcileRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, Reconcile(db, request))
 	})
-	router.GET("/api/recurring", func(c *gin.Context) {
-		c.JSON(200, GetRecurringTransactions(db))
+	router.GET("/api/query", func(c *gin.Context) {
+		result, err := RunQuery(db, c.Query("q"), c.Query("group"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, result)
 	})
-	router.GET("/api/allocation", func(c *gin.Context) {
-		c.JSON(200, GetAllocation(db))
+	router.GET("/api/calendar.ics", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(GetCalendar(db)))
+	})
+	router.GET("/api/recurring/suggestions", func(c *gin.Context) {
+		c.JSON(200, GetRecurringSuggestions(db))
+	})
+	router.POST("/api/recurring/tag", func(c *gin.Context) {
+		var request RecurringTagRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, TagRecurringTransactions(db, request))
 	})
+	router.GET("/api/allocation", reportHandler(db, ReportAllocation, func(c *gin.Context) {
+		c.JSON(200, GetAllocation(db))
+	}))
 	router.GET("/api/portfolio_allocation", func(c *gin.Context) {
 		c.JSON(200, GetPortfolioAllocation(db))
 	})
@@ -201,13 +278,31 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 	router.GET("/api/transaction", func(c *gin.Context) {
 		c.JSON(200, GetTransactions(db))
 	})
-	router.GET("/api/harvest", func(c *gin.Context) {
-		c.JSON(200, GetHarvest(db))
-	})
+	router.GET("/api/harvest", reportHandler(db, ReportHarvest, func(c *gin.Context) {
+		response, err := GetHarvest(db)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, response)
+	}))
 
-	router.GET("/api/capital_gains", func(c *gin.Context) {
-		c.JSON(200, GetCapitalGains(db))
-	})
+	router.GET("/api/capital_gains", reportHandler(db, ReportCapitalGains, func(c *gin.Context) {
+		response, err := GetCapitalGains(db)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, response)
+	}))
+	router.GET("/api/capital_gains/lot_selection", reportHandler(db, ReportLotSelection, func(c *gin.Context) {
+		response, err := GetLotSelectionComparison(db)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, response)
+	}))
 
 	router.GET("/api/schedule_al", func(c *gin.Context) {
 		c.JSON(200, GetScheduleAL(db))
@@ -216,16 +311,33 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 		c.JSON(200, GetDiagnosis(db))
 	})
 
-	router.GET("/api/liabilities/interest", func(c *gin.Context) {
+	router.GET("/api/liabilities/interest", reportHandler(db, ReportLiabilitiesInterest, func(c *gin.Context) {
 		c.JSON(200, liabilities.GetInterest(db))
-	})
+	}))
 
-	router.GET("/api/liabilities/balance", func(c *gin.Context) {
+	router.GET("/api/liabilities/balance", reportHandler(db, ReportLiabilitiesBalance, func(c *gin.Context) {
 		c.JSON(200, liabilities.GetBalance(db))
-	})
+	}))
 
-	router.GET("/api/liabilities/repayment", func(c *gin.Context) {
+	router.GET("/api/liabilities/repayment", reportHandler(db, ReportLiabilitiesRepayment, func(c *gin.Context) {
 		c.JSON(200, liabilities.GetRepayment(db))
+	}))
+
+	router.GET("/api/liabilities/loans", reportHandler(db, ReportLoans, func(c *gin.Context) {
+		c.JSON(200, liabilities.GetLoans(db))
+	}))
+
+	router.GET("/api/liabilities/loans/:account", func(c *gin.Context) {
+		c.JSON(200, liabilities.GetLoan(db, c.Param("account"), c.Query("date")))
+	})
+
+	router.POST("/api/liabilities/loans/:account/prepayment", func(c *gin.Context) {
+		var request liabilities.PrepaymentRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, liabilities.SimulatePrepayment(c.Param("account"), request))
 	})
 
 	router.GET("/api/logs", func(c *gin.Context) {
@@ -359,17 +471,35 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 		c.JSON(200, gin.H{"success": true})
 	})
 
+	router.POST("/api/import", func(c *gin.Context) {
+		c.JSON(Import(db, c))
+	})
+
 	router.GET("/api/goals", func(c *gin.Context) {
 		c.JSON(200, gin.H{"goals": goal.GetGoalSummaries(db)})
 	})
 
+	router.GET("/api/goals/:type/:name/simulate", func(c *gin.Context) {
+		if c.Param("type") != "retirement" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Simulation is only available for retirement goals"})
+			return
+		}
+
+		simulation, err := goal.GetRetirementSimulation(db, c.Param("name"), c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, simulation)
+	})
+
 	router.GET("/api/goals/:type/:name", func(c *gin.Context) {
 		c.JSON(200, goal.GetGoalDetails(db, c.Param("type"), c.Param("name")))
 	})
 
-	router.GET("/api/credit_cards", func(c *gin.Context) {
+	router.GET("/api/credit_cards", reportHandler(db, ReportCreditCards, func(c *gin.Context) {
 		c.JSON(200, GetCreditCards(db))
-	})
+	}))
 
 	router.GET("/api/credit_cards/:account", func(c *gin.Context) {
 		c.JSON(200, GetCreditCard(db, c.Param("account")))
@@ -403,7 +533,13 @@ func Build(db *gorm.DB, enableCompression bool) *gin.Engine {
 		c.JSON(200, GetLoansDashboard(db))
 	})
 
+	buildAPIV1(router, db)
+
 	router.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/v1/") {
+			respond(c, ErrorResponse{}, notFound(fmt.Sprintf("%s %s not found", c.Request.Method, c.Request.URL.Path)))
+			return
+		}
 		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(web.Index))
 	})
 
@@ -449,9 +585,23 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// calendar apps can't set custom headers, they are asked to
+		// use basic auth instead
+		calendar := c.Request.URL.Path == "/api/calendar.ics"
+		unauthorized := func(message string) {
+			if calendar {
+				c.Header("WWW-Authenticate", `Basic realm="paisa", charset="UTF-8"`)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+		}
+
 		tokens := strings.SplitN(c.Request.Header.Get("X-Auth"), ":", 2)
+		if username, password, ok := c.Request.BasicAuth(); ok && calendar && len(tokens) != 2 {
+			// the user interface sends the hash of the password
+			tokens = []string{username, utils.Sha256(password)}
+		}
 		if len(tokens) != 2 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
+			unauthorized("Invalid Token")
 			return
 		}
 
@@ -465,7 +615,7 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 		}
 
 		rateLimiter.RateLimitCtx(c.Request.Context(), "user", 1)
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		unauthorized("Invalid username or password")
 		return
 
 	}
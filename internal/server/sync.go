@@ -1,6 +1,8 @@
 package server
 
 import (
+	"sync"
+
 	"github.com/ananthakumaran/paisa/internal/cache"
 	"github.com/ananthakumaran/paisa/internal/model"
 	"github.com/gin-gonic/gin"
@@ -13,14 +15,34 @@ type SyncRequest struct {
 	Portfolios bool `json:"portfolios"`
 }
 
+var syncMutex sync.Mutex
+
 func Sync(db *gorm.DB, request SyncRequest) gin.H {
-	cache.Clear()
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
+
+	changed := request.Prices || request.Portfolios
+	defer func() {
+		if changed {
+			events.Publish(Event{Type: "data_changed"})
+		}
+	}()
+
+	if request.Prices || request.Portfolios {
+		cache.Clear()
+	}
 
+	response := gin.H{"success": true}
 	if request.Journal {
-		message, err := model.SyncJournal(db)
+		result, message, err := model.SyncJournal(db)
 		if err != nil {
+			cache.Clear()
 			return gin.H{"success": false, "message": message}
 		}
+		cache.Invalidate(result)
+		refreshWatcher()
+		response["journal"] = result
+		changed = changed || result.Postings.Changed() || result.PricesChanged
 	}
 
 	if request.Prices {
@@ -41,5 +63,5 @@ func Sync(db *gorm.DB, request SyncRequest) gin.H {
 		}
 	}
 
-	return gin.H{"success": true}
+	return response
 }
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/ledger"
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/lo"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const watchDebounce = 500 * time.Millisecond
+
+type journalWatcher struct {
+	db      *gorm.DB
+	watcher *fsnotify.Watcher
+	mutex   sync.Mutex
+	files   map[string]bool
+	dirs    map[string]bool
+}
+
+// activeWatcher is refreshed after every journal sync, so that the
+// files included after the start are watched as well
+var activeWatcher *journalWatcher
+
+// Watch syncs the journal whenever the journal or one of its included
+// files changes and notifies the connected browsers. Changes to the
+// sheets directory only notify the browsers.
+func Watch(db *gorm.DB) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	w := &journalWatcher{db: db, watcher: watcher, dirs: make(map[string]bool)}
+	w.refresh()
+	activeWatcher = w
+	go w.loop()
+	return nil
+}
+
+func refreshWatcher() {
+	if activeWatcher != nil {
+		activeWatcher.refresh()
+	}
+}
+
+// refresh watches the directories of the journal files and the sheets
+// directory along with its sub directories, the directories that are
+// already watched are skipped
+func (w *journalWatcher) refresh() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	files := ledger.Files(config.GetJournalPath())
+	w.files = lo.SliceToMap(files, func(file string) (string, bool) { return file, true })
+
+	dirs := lo.Map(files, func(file string, _ int) string { return filepath.Dir(file) })
+	if sheetDir, err := filepath.Abs(config.GetSheetDir()); err == nil {
+		filepath.WalkDir(sheetDir, func(path string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+	}
+
+	for _, dir := range lo.Uniq(dirs) {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			log.Warnf("Failed to watch %s: %s", dir, err)
+			continue
+		}
+		log.Infof("Watching %s for changes", dir)
+		w.dirs[dir] = true
+	}
+}
+
+func (w *journalWatcher) isJournal(path string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.files[path]
+}
+
+func (w *journalWatcher) isSheet(path string) bool {
+	sheetDir, err := filepath.Abs(config.GetSheetDir())
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(path, sheetDir+string(filepath.Separator)) && !strings.Contains(filepath.Base(path), ".backup.")
+}
+
+func (w *journalWatcher) loop() {
+	var fire <-chan time.Time
+	journalChanged, sheetsChanged := false, false
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			path, err := filepath.Abs(event.Name)
+			if err != nil {
+				continue
+			}
+
+			switch {
+			case w.isJournal(path):
+				journalChanged = true
+			case w.isSheet(path):
+				sheetsChanged = true
+				if info, err := os.Stat(path); err == nil && info.IsDir() && event.Op.Has(fsnotify.Create) {
+					w.refresh()
+				}
+			default:
+				continue
+			}
+			fire = time.After(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn(err)
+		case <-fire:
+			fire = nil
+			if journalChanged {
+				log.Info("Journal changed, syncing")
+				response := Sync(w.db, SyncRequest{Journal: true})
+				if success, _ := response["success"].(bool); !success {
+					message, _ := response["message"].(string)
+					events.Publish(Event{Type: "sync_error", Message: message})
+				}
+			}
+			if sheetsChanged {
+				events.Publish(Event{Type: "sheets_changed"})
+			}
+			journalChanged, sheetsChanged = false, false
+		}
+	}
+}
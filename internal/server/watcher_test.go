@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherRefresh(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	journal := filepath.Join(dir, "main.ledger")
+	require.NoError(t, os.WriteFile(journal, []byte(""), 0644))
+	require.NoError(t, config.LoadConfig([]byte(fmt.Sprintf("journal_path: %s\ndb_path: paisa.db\nsheets_directory: %s\n", journal, filepath.Join(dir, "sheets"))), ""))
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	w := &journalWatcher{watcher: watcher, dirs: make(map[string]bool)}
+	w.refresh()
+	activeWatcher = w
+	defer func() { activeWatcher = nil }()
+	assert.True(t, w.isJournal(journal))
+
+	included := filepath.Join(dir, "2024", "jan.ledger")
+	require.NoError(t, os.MkdirAll(filepath.Dir(included), 0755))
+	require.NoError(t, os.WriteFile(included, []byte(""), 0644))
+	require.NoError(t, os.WriteFile(journal, []byte("include 2024/jan.ledger\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sheets", "2024"), 0755))
+
+	refreshWatcher()
+	assert.True(t, w.isJournal(included))
+	assert.True(t, w.dirs[filepath.Dir(included)])
+	assert.True(t, w.dirs[filepath.Join(dir, "sheets", "2024")])
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/commodity"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/google/btree"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ReportingCurrencies returns the currencies the reports can be shown
+// in: the default currency and the commodities of type currency.
+func ReportingCurrencies() []string {
+	currencies := lo.Map(commodity.FindByType(config.Currency), func(c config.Commodity, _ int) string { return c.Name })
+	return lo.Uniq(append([]string{config.DefaultCurrency()}, currencies...))
+}
+
+// IsCurrencyCommodity reports whether the commodity is money, as
+// opposed to an investment.
+func IsCurrencyCommodity(name string) bool {
+	return utils.IsCurrency(name) || commodity.FindByName(name).Type == config.Currency
+}
+
+// Converter converts amounts in the default currency to the reporting
+// currency, using the exchange rate on the given date. The rates are
+// the prices of the currency, stored like any other commodity price.
+type Converter struct {
+	db       *gorm.DB
+	Currency string
+	mu       sync.Mutex
+	rates    map[string]*btree.BTree
+}
+
+func DefaultConverter(db *gorm.DB) *Converter {
+	return &Converter{db: db, Currency: config.DefaultCurrency(), rates: make(map[string]*btree.BTree)}
+}
+
+// NewConverter returns the converter to one of the reporting
+// currencies, the other commodities are rejected even if they have
+// prices.
+func NewConverter(db *gorm.DB, currency string) (*Converter, error) {
+	converter := DefaultConverter(db)
+	if currency == "" || utils.IsCurrency(currency) {
+		return converter, nil
+	}
+
+	if !lo.Contains(ReportingCurrencies(), currency) || converter.loadRates(currency).Len() == 0 {
+		return nil, fmt.Errorf("No exchange rate found for %s", currency)
+	}
+	converter.Currency = currency
+	return converter, nil
+}
+
+func (c *Converter) IsDefault() bool {
+	return utils.IsCurrency(c.Currency)
+}
+
+func (c *Converter) loadRates(currency string) *btree.BTree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tree, ok := c.rates[currency]; ok {
+		return tree
+	}
+
+	var prices []price.Price
+	result := c.db.Where("commodity_name = ?", currency).Find(&prices)
+	if result.Error != nil {
+		log.Fatal(result.Error)
+	}
+
+	tree := btree.New(2)
+	for _, p := range prices {
+		if !p.Value.IsZero() {
+			tree.ReplaceOrInsert(p)
+		}
+	}
+	c.rates[currency] = tree
+	return tree
+}
+
+// Rate returns the value of one unit of the currency in the default
+// currency. Dates before the first known rate use the first rate.
+func (c *Converter) Rate(currency string, date time.Time) decimal.Decimal {
+	if utils.IsCurrency(currency) {
+		return decimal.NewFromInt(1)
+	}
+
+	tree := c.loadRates(currency)
+	if tree.Len() == 0 {
+		log.Warnf("No exchange rate found for %s", currency)
+		return decimal.NewFromInt(1)
+	}
+
+	pc := utils.BTreeDescendFirstLessOrEqual(tree, price.Price{Date: date})
+	if pc.Value.IsZero() {
+		pc = tree.Min().(price.Price)
+	}
+	return pc.Value
+}
+
+// Convert converts the amount in default currency to the reporting
+// currency as on the date.
+func (c *Converter) Convert(amount decimal.Decimal, date time.Time) decimal.Decimal {
+	if c.IsDefault() {
+		return amount
+	}
+	return amount.Div(c.Rate(c.Currency, date))
+}
+
+// Postings converts the amount at the transaction date and the market
+// amount at the end of today. Postings already in the reporting
+// currency keep their quantity as the amount.
+func (c *Converter) Postings(postings []posting.Posting) []posting.Posting {
+	if c.IsDefault() {
+		return postings
+	}
+
+	today := utils.EndOfToday()
+	return lo.Map(postings, func(p posting.Posting, _ int) posting.Posting {
+		if p.Commodity == c.Currency {
+			p.Amount = p.Quantity
+			if !p.MarketAmount.IsZero() {
+				p.MarketAmount = p.Quantity
+			}
+		} else {
+			p.Amount = c.Convert(p.Amount, p.Date)
+			p.MarketAmount = c.Convert(p.MarketAmount, today)
+		}
+		return p
+	})
+}
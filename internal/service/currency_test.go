@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func date(str string) time.Time {
+	d, _ := time.ParseInLocation("2006-01-02", str, config.TimeZone())
+	return d
+}
+
+func openCurrencyDB(t *testing.T) *gorm.DB {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+commodities:
+  - name: USD
+    type: currency
+    price:
+      provider: com-yahoo
+      code: USDINR=X
+`), "/tmp/paisa.yaml"))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&price.Price{}))
+
+	for d, value := range map[string]int64{"2023-01-01": 80, "2023-06-01": 82, "2023-12-01": 84} {
+		require.NoError(t, db.Create(&price.Price{Date: date(d), CommodityType: config.Currency, CommodityID: "USDINR=X", CommodityName: "USD", Value: decimal.NewFromInt(value)}).Error)
+	}
+	return db
+}
+
+func TestConverter(t *testing.T) {
+	db := openCurrencyDB(t)
+
+	assert.Equal(t, []string{"INR", "USD"}, ReportingCurrencies())
+	assert.True(t, IsCurrencyCommodity("USD"))
+	assert.False(t, IsCurrencyCommodity("AAPL"))
+
+	_, err := NewConverter(db, "EUR")
+	assert.EqualError(t, err, "No exchange rate found for EUR")
+
+	require.NoError(t, db.Create(&price.Price{Date: date("2023-01-01"), CommodityType: config.Stock, CommodityID: "AAPL", CommodityName: "AAPL", Value: decimal.NewFromInt(12000)}).Error)
+	_, err = NewConverter(db, "AAPL")
+	assert.EqualError(t, err, "No exchange rate found for AAPL")
+
+	converter, err := NewConverter(db, "")
+	require.NoError(t, err)
+	assert.True(t, converter.IsDefault())
+
+	converter, err = NewConverter(db, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "80", converter.Rate("USD", date("2022-01-01")).String())
+	assert.Equal(t, "82", converter.Rate("USD", date("2023-07-01")).String())
+	assert.Equal(t, "100", converter.Convert(decimal.NewFromInt(8200), date("2023-07-01")).String())
+
+	postings := converter.Postings([]posting.Posting{
+		{Date: date("2023-02-01"), Commodity: "INR", Quantity: decimal.NewFromInt(1600), Amount: decimal.NewFromInt(1600)},
+		{Date: date("2023-02-01"), Commodity: "USD", Quantity: decimal.NewFromInt(10), Amount: decimal.NewFromInt(805)},
+	})
+	assert.Equal(t, "20", postings[0].Amount.String())
+	assert.Equal(t, "10", postings[1].Amount.String())
+}
+
+func TestComputeFXGains(t *testing.T) {
+	db := openCurrencyDB(t)
+	converter := DefaultConverter(db)
+
+	postings := []posting.Posting{
+		{Date: date("2023-01-01"), Account: "Assets:Checking:USD", Commodity: "USD", Quantity: decimal.NewFromInt(100), Amount: decimal.NewFromInt(8000)},
+		{Date: date("2023-06-01"), Account: "Assets:Checking:USD", Commodity: "USD", Quantity: decimal.NewFromInt(100), Amount: decimal.NewFromInt(8200)},
+		{Date: date("2023-06-01"), Account: "Assets:Checking:USD", Commodity: "USD", Quantity: decimal.NewFromInt(-150), Amount: decimal.NewFromInt(-12300)},
+		{Date: date("2023-06-01"), Account: "Expenses:Travel", Commodity: "USD", Quantity: decimal.NewFromInt(150), Amount: decimal.NewFromInt(12300)},
+		{Date: date("2023-06-01"), Account: "Assets:Checking", Commodity: "INR", Quantity: decimal.NewFromInt(1000), Amount: decimal.NewFromInt(1000)},
+	}
+
+	gains := ComputeFXGains(postings, converter)
+	require.Len(t, gains, 1)
+	gain := gains[0]
+	assert.Equal(t, "USD", gain.Currency)
+	assert.Equal(t, "50", gain.Units.String())
+	assert.Equal(t, "4100", gain.Cost.String())
+	// 100 USD bought at 80 and 50 at 82 were spent at 82
+	assert.Equal(t, "200", gain.Realized.String())
+	assert.Equal(t, "4200", gain.MarketValue.String())
+	assert.Equal(t, "100", gain.Unrealized.String())
+
+	converter, err := NewConverter(db, "USD")
+	require.NoError(t, err)
+	gains = ComputeFXGains(postings, converter)
+	require.Len(t, gains, 1)
+	assert.Equal(t, "INR", gains[0].Currency)
+	assert.Equal(t, "1000", gains[0].Units.String())
+}
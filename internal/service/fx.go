@@ -0,0 +1,95 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+// FXGain is the gain from holding money in a currency other than the
+// reporting currency, all amounts in the reporting currency.
+type FXGain struct {
+	Currency    string          `json:"currency"`
+	Units       decimal.Decimal `json:"units"`
+	Cost        decimal.Decimal `json:"cost"`
+	MarketValue decimal.Decimal `json:"marketValue"`
+	Realized    decimal.Decimal `json:"realized"`
+	Unrealized  decimal.Decimal `json:"unrealized"`
+}
+
+type fxLot struct {
+	quantity decimal.Decimal
+	cost     decimal.Decimal
+}
+
+// ComputeFXGains matches the asset and liability postings of each
+// foreign currency, account by account, in FIFO order. Spending or
+// converting a currency realizes the gain on the matched lots, the
+// lots still held carry the unrealized gain.
+func ComputeFXGains(postings []posting.Posting, converter *Converter) []FXGain {
+	postings = lo.Filter(postings, func(p posting.Posting, _ int) bool {
+		category := utils.FirstName(p.Account)
+		return (category == "Assets" || category == "Liabilities") &&
+			p.Commodity != converter.Currency &&
+			IsCurrencyCommodity(p.Commodity)
+	})
+
+	today := utils.EndOfToday()
+	gains := make(map[string]*FXGain)
+	for _, ps := range lo.GroupBy(postings, func(p posting.Posting) string { return p.Account + ":" + p.Commodity }) {
+		currency := ps[0].Commodity
+		gain, ok := gains[currency]
+		if !ok {
+			gain = &FXGain{Currency: currency}
+			gains[currency] = gain
+		}
+
+		var lots []fxLot
+		for _, p := range ps {
+			if p.Quantity.IsZero() {
+				continue
+			}
+
+			value := converter.Convert(p.Amount, p.Date)
+			quantity := p.Quantity
+			for len(lots) > 0 && !quantity.IsZero() && lots[0].quantity.Sign() != quantity.Sign() {
+				lot := lots[0]
+				used := decimal.Min(lot.quantity.Abs(), quantity.Abs())
+				lotCost := lot.cost.Mul(used).Div(lot.quantity.Abs())
+				portion := value.Mul(used).Div(quantity.Abs())
+				gain.Realized = gain.Realized.Sub(portion.Add(lotCost))
+
+				value = value.Sub(portion)
+				quantity = quantity.Sub(used.Mul(decimal.NewFromInt(int64(quantity.Sign()))))
+				if used.Equal(lot.quantity.Abs()) {
+					lots = lots[1:]
+				} else {
+					lots[0] = fxLot{
+						quantity: lot.quantity.Sub(used.Mul(decimal.NewFromInt(int64(lot.quantity.Sign())))),
+						cost:     lot.cost.Sub(lotCost),
+					}
+				}
+			}
+
+			if !quantity.IsZero() {
+				lots = append(lots, fxLot{quantity: quantity, cost: value})
+			}
+		}
+
+		for _, lot := range lots {
+			gain.Units = gain.Units.Add(lot.quantity)
+			gain.Cost = gain.Cost.Add(lot.cost)
+		}
+	}
+
+	result := lo.Map(lo.Values(gains), func(gain *FXGain, _ int) FXGain {
+		gain.MarketValue = converter.Convert(gain.Units.Mul(converter.Rate(gain.Currency, today)), today)
+		gain.Unrealized = gain.MarketValue.Sub(gain.Cost)
+		return *gain
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
+}
@@ -41,6 +41,16 @@ func ClearInterestCache() {
 	irepaymentCache = interestRepaymentCache{}
 }
 
+func InvalidateInterestCache(accounts []string) {
+	if lo.SomeBy(accounts, func(account string) bool { return utils.IsParent(account, "Income:Interest") }) {
+		icache = interestCache{}
+	}
+
+	if lo.SomeBy(accounts, func(account string) bool { return utils.IsParent(account, "Expenses:Interest") }) {
+		irepaymentCache = interestRepaymentCache{}
+	}
+}
+
 func CapitalGainsSourceAccount(account string) string {
 	parts := strings.Split(account, ":")
 	return "Assets:" + strings.Join(parts[2:], ":")
@@ -71,6 +71,25 @@ func ClearPriceCache() {
 	pcache = priceCache{}
 }
 
+// InvalidatePriceCache clears the price cache when the journal prices
+// changed or when a posting uses a commodity the cache doesn't know.
+func InvalidatePriceCache(pricesChanged bool, commodities []string) {
+	if pricesChanged {
+		ClearPriceCache()
+		return
+	}
+
+	if pcache.pricesTree == nil {
+		return
+	}
+
+	if lo.SomeBy(commodities, func(commodity string) bool {
+		return !utils.IsCurrency(commodity) && (pcache.pricesTree[commodity] == nil || pcache.postingPricesTree[commodity] == nil)
+	}) {
+		ClearPriceCache()
+	}
+}
+
 func GetUnitPrice(db *gorm.DB, commodity string, date time.Time) price.Price {
 	pcache.Do(func() { loadPriceCache(db) })
 
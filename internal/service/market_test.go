@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/posting"
+	"github.com/ananthakumaran/paisa/internal/model/price"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestInvalidatePriceCacheOnFirstPosting(t *testing.T) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+default_currency: INR
+commodities:
+  - name: ABC
+    type: stock
+    price:
+      provider: com-yahoo
+      code: ABC
+`), "/tmp/paisa.yaml"))
+	ClearPriceCache()
+	t.Cleanup(ClearPriceCache)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&price.Price{}, &posting.Posting{}))
+
+	require.NoError(t, db.Create(&price.Price{Date: date("2023-01-01"), CommodityType: config.Stock, CommodityID: "ABC", CommodityName: "ABC", Value: decimal.NewFromInt(100)}).Error)
+	assert.Equal(t, "100", GetUnitPrice(db, "ABC", date("2023-02-01")).Value.String())
+
+	require.NoError(t, db.Create(&posting.Posting{TransactionID: "1", Date: date("2023-02-01"), Account: "Assets:Equity:ABC", Commodity: "ABC", Quantity: decimal.NewFromInt(10), Amount: decimal.NewFromInt(1100)}).Error)
+	require.NoError(t, db.Create(&price.Price{Date: date("2023-02-01"), CommodityType: config.Unknown, CommodityID: "ABC", CommodityName: "ABC", Value: decimal.NewFromInt(110)}).Error)
+	InvalidatePriceCache(false, []string{"INR", "ABC"})
+
+	prices := GetAllPrices(db, "ABC")
+	require.Len(t, prices, 2)
+	assert.Equal(t, "110", prices[0].Value.String())
+	assert.Equal(t, "100", prices[1].Value.String())
+}
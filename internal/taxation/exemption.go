@@ -0,0 +1,61 @@
+package taxation
+
+import (
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+)
+
+// Exemption tracks the long term gain that is not taxed in a financial
+// year. The limit is shared by all the tax categories with an
+// exemption, losses reduce the exemption used by the gains.
+type Exemption struct {
+	FY        string          `json:"fy"`
+	Limit     decimal.Decimal `json:"limit"`
+	Used      decimal.Decimal `json:"used"`
+	Remaining decimal.Decimal `json:"remaining"`
+	TaxSaved  decimal.Decimal `json:"tax_saved"`
+	gain      decimal.Decimal
+	rate      Rate
+}
+
+// NewExemption returns the exemption of the financial year of the
+// date. It uses the highest exemption among the rules in effect at the
+// end of the financial year, or today for the current year.
+func (r *Regime) NewExemption(date time.Time) Exemption {
+	end := utils.EndOfFinancialYear(date)
+	if end.After(utils.EndOfToday()) {
+		end = utils.EndOfToday()
+	}
+
+	limit := decimal.Zero
+	for _, rules := range r.rules {
+		rule, _, found := lo.FindLastIndexOf(rules, func(rule Rule) bool { return !end.Before(rule.EffectiveFrom) })
+		if found && rule.LongTermExemption.GreaterThan(limit) {
+			limit = rule.LongTermExemption
+		}
+	}
+
+	return Exemption{FY: utils.FY(date), Limit: limit, Used: decimal.Zero, Remaining: limit, TaxSaved: decimal.Zero}
+}
+
+// ExemptionRule returns the rule of the sale if its gain counts towards
+// the exemption.
+func (r *Regime) ExemptionRule(category config.TaxCategoryType, purchaseDate time.Time, sellDate time.Time) (Rule, bool) {
+	rule, found := r.Rule(category, purchaseDate, sellDate)
+	if !found || rule.Exempt || rule.LongTermExemption.IsZero() || rule.LongTermRate.Slab || !rule.IsLongTerm(purchaseDate, sellDate) {
+		return Rule{}, false
+	}
+	return rule, true
+}
+
+func (e *Exemption) Use(rule Rule, taxable decimal.Decimal) {
+	e.gain = e.gain.Add(taxable)
+	e.rate = rule.LongTermRate
+	e.Used = decimal.Min(decimal.Max(e.gain, decimal.Zero), e.Limit)
+	e.Remaining = e.Limit.Sub(e.Used)
+	e.TaxSaved = e.rate.apply(e.Used)
+}
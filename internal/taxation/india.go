@@ -0,0 +1,38 @@
+package taxation
+
+import (
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/samber/lo"
+)
+
+// India is the default jurisdiction. It covers the grandfathering of
+// equity gains till 31 Jan 2018, the removal of indexation for debt
+// funds bought from 1 Apr 2023 and the changes in the July 2024 budget.
+var India = config.TaxJurisdiction{
+	Name: "india",
+	Rules: lo.Flatten([][]config.TaxRule{
+		indiaEquityRules(config.Equity),
+		indiaEquityRules(config.Equity65),
+		{
+			{Category: config.Equity35, LongTermAfter: 1095, ShortTermRate: config.Slab, LongTermRate: "20"},
+			{Category: config.Equity35, EffectiveFrom: "2024-07-23", LongTermAfter: 730, ShortTermRate: config.Slab, LongTermRate: "12.5"},
+		},
+		{
+			{Category: config.Debt, PurchasedBefore: "2023-04-01", LongTermAfter: 1095, ShortTermRate: config.Slab, LongTermRate: "20", Indexation: config.Yes},
+			{Category: config.Debt, PurchasedFrom: "2023-04-01", ShortTermRate: config.Slab, LongTermRate: config.Slab},
+			{Category: config.Debt, EffectiveFrom: "2024-07-23", PurchasedBefore: "2023-04-01", LongTermAfter: 730, ShortTermRate: config.Slab, LongTermRate: "12.5"},
+		},
+		{
+			{Category: config.UnlistedEquity, LongTermAfter: 730, ShortTermRate: config.Slab, LongTermRate: "20", Indexation: config.Yes},
+			{Category: config.UnlistedEquity, EffectiveFrom: "2024-07-23", LongTermAfter: 730, ShortTermRate: config.Slab, LongTermRate: "12.5"},
+		},
+	}),
+}
+
+func indiaEquityRules(category config.TaxCategoryType) []config.TaxRule {
+	return []config.TaxRule{
+		{Category: category, Exempt: config.Yes},
+		{Category: category, EffectiveFrom: "2018-02-01", LongTermAfter: 365, ShortTermRate: "15", LongTermRate: "10", LongTermExemption: 100000, GrandfatherDate: "2018-02-01"},
+		{Category: category, EffectiveFrom: "2024-07-23", LongTermAfter: 365, ShortTermRate: "20", LongTermRate: "12.5", LongTermExemption: 125000, GrandfatherDate: "2018-02-01"},
+	}
+}
@@ -0,0 +1,203 @@
+package taxation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/cii"
+	"github.com/ananthakumaran/paisa/internal/service"
+	"github.com/ananthakumaran/paisa/internal/utils"
+	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type Rate struct {
+	Slab    bool
+	Percent decimal.Decimal
+}
+
+func (r Rate) apply(taxable decimal.Decimal) decimal.Decimal {
+	return taxable.Mul(r.Percent.Div(decimal.NewFromInt(100)))
+}
+
+type Rule struct {
+	Category          config.TaxCategoryType
+	EffectiveFrom     time.Time
+	PurchasedFrom     time.Time
+	PurchasedBefore   time.Time
+	LongTermAfter     time.Duration
+	ShortTermRate     Rate
+	LongTermRate      Rate
+	LongTermExemption decimal.Decimal
+	Indexation        bool
+	GrandfatherDate   time.Time
+	Exempt            bool
+}
+
+func (r Rule) matches(purchaseDate time.Time, sellDate time.Time) bool {
+	return !sellDate.Before(r.EffectiveFrom) &&
+		(r.PurchasedFrom.IsZero() || !purchaseDate.Before(r.PurchasedFrom)) &&
+		(r.PurchasedBefore.IsZero() || purchaseDate.Before(r.PurchasedBefore))
+}
+
+func (r Rule) IsLongTerm(purchaseDate time.Time, sellDate time.Time) bool {
+	return sellDate.Sub(purchaseDate) > r.LongTermAfter
+}
+
+// Regime is the set of rules of a jurisdiction. Each tax category has
+// a list of rules ordered by the effective date, the sale uses the
+// latest rule in effect on the sell date that matches the purchase date.
+type Regime struct {
+	Name  string
+	rules map[config.TaxCategoryType][]Rule
+}
+
+func NewRegime(jurisdiction config.TaxJurisdiction) (*Regime, error) {
+	regime := &Regime{Name: jurisdiction.Name, rules: make(map[config.TaxCategoryType][]Rule)}
+	for _, r := range jurisdiction.Rules {
+		rule, err := parseRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid tax rule in %s: %w", jurisdiction.Name, err)
+		}
+		regime.rules[rule.Category] = append(regime.rules[rule.Category], rule)
+	}
+
+	for _, rules := range regime.rules {
+		sort.SliceStable(rules, func(i, j int) bool { return rules[i].EffectiveFrom.Before(rules[j].EffectiveFrom) })
+	}
+	return regime, nil
+}
+
+func parseRule(r config.TaxRule) (Rule, error) {
+	rule := Rule{
+		Category:          r.Category,
+		LongTermAfter:     time.Duration(r.LongTermAfter) * 24 * time.Hour,
+		LongTermExemption: decimal.NewFromFloat(r.LongTermExemption),
+		Indexation:        r.Indexation == config.Yes,
+		Exempt:            r.Exempt == config.Yes,
+	}
+
+	var err error
+	for _, date := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{r.EffectiveFrom, &rule.EffectiveFrom},
+		{r.PurchasedFrom, &rule.PurchasedFrom},
+		{r.PurchasedBefore, &rule.PurchasedBefore},
+		{r.GrandfatherDate, &rule.GrandfatherDate},
+	} {
+		if date.value == "" {
+			continue
+		}
+		*date.target, err = time.ParseInLocation("2006-01-02", date.value, config.TimeZone())
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	rule.ShortTermRate, err = parseRate(r.ShortTermRate)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule.LongTermRate, err = parseRate(r.LongTermRate)
+	if err != nil {
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+func parseRate(rate config.TaxRate) (Rate, error) {
+	if rate == config.Slab {
+		return Rate{Slab: true}, nil
+	}
+
+	if rate == "" {
+		return Rate{Percent: decimal.Zero}, nil
+	}
+
+	percent, err := decimal.NewFromString(string(rate))
+	if err != nil {
+		return Rate{}, fmt.Errorf("Invalid tax rate %s", rate)
+	}
+	return Rate{Percent: percent}, nil
+}
+
+// Jurisdictions returns the default jurisdictions along with the ones
+// defined in the config. A jurisdiction in the config replaces the
+// default one with the same name.
+func Jurisdictions() []config.TaxJurisdiction {
+	jurisdictions := config.GetConfig().TaxJurisdictions
+	return append(jurisdictions, lo.Filter([]config.TaxJurisdiction{India}, func(j config.TaxJurisdiction, _ int) bool {
+		_, found := lo.Find(jurisdictions, func(c config.TaxJurisdiction) bool { return c.Name == j.Name })
+		return !found
+	})...)
+}
+
+// CurrentRegime returns the regime of the configured jurisdiction,
+// falling back to India when it's not found.
+func CurrentRegime() (*Regime, error) {
+	name := config.GetConfig().TaxJurisdiction
+	jurisdiction, found := lo.Find(Jurisdictions(), func(j config.TaxJurisdiction) bool { return j.Name == name })
+	if !found {
+		log.Warnf("Tax jurisdiction %s not found, using %s", name, India.Name)
+		jurisdiction = India
+	}
+
+	return NewRegime(jurisdiction)
+}
+
+func (r *Regime) Rule(category config.TaxCategoryType, purchaseDate time.Time, sellDate time.Time) (Rule, bool) {
+	rule, _, found := lo.FindLastIndexOf(r.rules[category], func(rule Rule) bool {
+		return rule.matches(purchaseDate, sellDate)
+	})
+	return rule, found
+}
+
+func (r *Regime) Calculate(db *gorm.DB, quantity decimal.Decimal, commodity config.Commodity, purchasePrice decimal.Decimal, purchaseDate time.Time, sellPrice decimal.Decimal, sellDate time.Time) Tax {
+	gain := sellPrice.Mul(quantity).Sub(purchasePrice.Mul(quantity))
+
+	rule, found := r.Rule(commodity.TaxCategory, purchaseDate, sellDate)
+	if !found {
+		return Tax{Gain: gain, Taxable: gain, ShortTerm: decimal.Zero, LongTerm: decimal.Zero, Slab: decimal.Zero}
+	}
+
+	if rule.Exempt {
+		return Tax{Gain: gain, Taxable: decimal.Zero, ShortTerm: decimal.Zero, LongTerm: decimal.Zero, Slab: decimal.Zero}
+	}
+
+	isLongTerm := rule.IsLongTerm(purchaseDate, sellDate)
+
+	if !rule.GrandfatherDate.IsZero() && purchaseDate.Before(rule.GrandfatherDate) {
+		purchasePrice = service.GetUnitPrice(db, commodity.Name, rule.GrandfatherDate).Value
+	}
+
+	if rule.Indexation && isLongTerm && purchaseDate.After(CII_START_DATE) {
+		purchasePrice = purchasePrice.Mul(decimal.NewFromInt(int64(cii.GetIndex(db, utils.FY(sellDate)))).Div(decimal.NewFromInt(int64(cii.GetIndex(db, utils.FY(purchaseDate))))))
+	}
+
+	taxable := sellPrice.Mul(quantity).Sub(purchasePrice.Mul(quantity))
+	shortTerm := decimal.Zero
+	longTerm := decimal.Zero
+	slab := decimal.Zero
+
+	rate := rule.ShortTermRate
+	if isLongTerm {
+		rate = rule.LongTermRate
+	}
+
+	if rate.Slab {
+		slab = taxable
+	} else if isLongTerm {
+		longTerm = rate.apply(taxable)
+	} else {
+		shortTerm = rate.apply(taxable)
+	}
+
+	return Tax{Gain: gain, Taxable: taxable, ShortTerm: shortTerm, LongTerm: longTerm, Slab: slab}
+}
@@ -0,0 +1,173 @@
+package taxation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/ananthakumaran/paisa/internal/model/cii"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func date(str string) time.Time {
+	d, _ := time.ParseInLocation("2006-01-02", str, config.TimeZone())
+	return d
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&cii.CII{}))
+	cii.UpsertAll(db, []*cii.CII{
+		{FinancialYear: "2019-20", CostInflationIndex: 289},
+		{FinancialYear: "2023-24", CostInflationIndex: 348},
+	})
+	return db
+}
+
+func loadConfig(t *testing.T, extra string) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+`+extra), "/tmp/paisa.yaml"))
+}
+
+func calculate(regime *Regime, db *gorm.DB, category config.TaxCategoryType, purchaseDate string, sellDate string) Tax {
+	commodity := config.Commodity{Name: "FUND", TaxCategory: category}
+	return regime.Calculate(db, decimal.NewFromInt(10), commodity, decimal.NewFromInt(100), date(purchaseDate), decimal.NewFromInt(200), date(sellDate))
+}
+
+func TestIndiaRegime(t *testing.T) {
+	loadConfig(t, "")
+	db := openDB(t)
+	regime, err := CurrentRegime()
+	require.NoError(t, err)
+	assert.Equal(t, "india", regime.Name)
+
+	tax := calculate(regime, db, config.Equity65, "2018-03-01", "2019-01-01")
+	assert.Equal(t, "150", tax.ShortTerm.String())
+
+	tax = calculate(regime, db, config.Equity65, "2022-01-01", "2024-07-22")
+	assert.Equal(t, "100", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Equity65, "2022-01-01", "2024-07-23")
+	assert.Equal(t, "125", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Equity, "2024-01-01", "2024-08-01")
+	assert.Equal(t, "200", tax.ShortTerm.String())
+
+	tax = calculate(regime, db, config.Equity, "2017-01-01", "2017-12-01")
+	assert.True(t, tax.Taxable.IsZero())
+	assert.Equal(t, "1000", tax.Gain.String())
+
+	// indexed cost is 100 * 348 / 289
+	tax = calculate(regime, db, config.Debt, "2019-05-01", "2023-06-01")
+	assert.Equal(t, "795.8477508650519", tax.Taxable.String())
+	assert.Equal(t, "159.16955017301038", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Debt, "2019-05-01", "2024-08-01")
+	assert.Equal(t, "1000", tax.Taxable.String())
+	assert.Equal(t, "125", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Debt, "2023-05-01", "2026-06-01")
+	assert.Equal(t, "1000", tax.Slab.String())
+	assert.True(t, tax.LongTerm.IsZero())
+
+	tax = calculate(regime, db, config.Equity35, "2021-01-01", "2023-06-01")
+	assert.Equal(t, "1000", tax.Slab.String())
+
+	tax = calculate(regime, db, config.Equity35, "2021-01-01", "2024-08-01")
+	assert.Equal(t, "125", tax.LongTerm.String())
+
+	tax = calculate(regime, db, "", "2021-01-01", "2024-08-01")
+	assert.Equal(t, "1000", tax.Taxable.String())
+	assert.True(t, tax.LongTerm.Add(tax.ShortTerm).Add(tax.Slab).IsZero())
+}
+
+func TestCustomJurisdiction(t *testing.T) {
+	loadConfig(t, `
+tax_jurisdiction: us
+tax_jurisdictions:
+  - name: us
+    rules:
+      - category: equity
+        long_term_after: 365
+        short_term_rate: slab
+        long_term_rate: 15
+      - category: equity
+        effective_from: "2025-01-01"
+        long_term_after: 365
+        short_term_rate: slab
+        long_term_rate: 20
+`)
+	db := openDB(t)
+	regime, err := CurrentRegime()
+	require.NoError(t, err)
+	assert.Equal(t, "us", regime.Name)
+
+	tax := calculate(regime, db, config.Equity, "2023-01-01", "2023-06-01")
+	assert.Equal(t, "1000", tax.Slab.String())
+
+	tax = calculate(regime, db, config.Equity, "2023-01-01", "2024-06-01")
+	assert.Equal(t, "150", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Equity, "2023-01-01", "2025-06-01")
+	assert.Equal(t, "200", tax.LongTerm.String())
+
+	tax = calculate(regime, db, config.Debt, "2023-01-01", "2025-06-01")
+	assert.Equal(t, "1000", tax.Taxable.String())
+	assert.True(t, tax.Slab.IsZero())
+
+	loadConfig(t, "tax_jurisdiction: unknown\n")
+	regime, err = CurrentRegime()
+	require.NoError(t, err)
+	assert.Equal(t, "india", regime.Name)
+}
+
+func TestInvalidRule(t *testing.T) {
+	_, err := NewRegime(config.TaxJurisdiction{Name: "bad", Rules: []config.TaxRule{{Category: config.Equity, LongTermRate: "ten"}}})
+	assert.EqualError(t, err, "Invalid tax rule in bad: Invalid tax rate ten")
+
+	err = config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+tax_jurisdiction: bad
+tax_jurisdictions:
+  - name: bad
+    rules:
+      - category: equity
+        effective_from: "2024-02-30"
+`), "/tmp/paisa.yaml")
+	assert.Error(t, err)
+}
+
+func TestExemption(t *testing.T) {
+	loadConfig(t, "")
+	regime, err := CurrentRegime()
+	require.NoError(t, err)
+
+	exemption := regime.NewExemption(date("2023-06-01"))
+	assert.Equal(t, "2023-24", exemption.FY)
+	assert.Equal(t, "100000", exemption.Limit.String())
+	assert.Equal(t, "125000", regime.NewExemption(date("2024-06-01")).Limit.String())
+
+	_, eligible := regime.ExemptionRule(config.Equity65, date("2023-01-01"), date("2023-06-01"))
+	assert.False(t, eligible)
+	_, eligible = regime.ExemptionRule(config.Debt, date("2019-01-01"), date("2023-06-01"))
+	assert.False(t, eligible)
+
+	rule, eligible := regime.ExemptionRule(config.Equity65, date("2021-01-01"), date("2023-06-01"))
+	require.True(t, eligible)
+	exemption.Use(rule, decimal.NewFromInt(60000))
+	assert.Equal(t, "40000", exemption.Remaining.String())
+	exemption.Use(rule, decimal.NewFromInt(-20000))
+	assert.Equal(t, "40000", exemption.Used.String())
+	exemption.Use(rule, decimal.NewFromInt(90000))
+	assert.Equal(t, "100000", exemption.Used.String())
+	assert.True(t, exemption.Remaining.IsZero())
+	assert.Equal(t, "10000", exemption.TaxSaved.String())
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/onrik/gorm-logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+// OpenDB opens the PostgreSQL database if db_dsn is configured,
+// otherwise the SQLite database at db_path
+func OpenDB() (*gorm.DB, error) {
+	if dsn := config.GetDBDSN(); dsn != "" {
+		return OpenPostgres(dsn)
+	}
+	return OpenSQLite(config.GetDBPath())
+}
+
+func OpenSQLite(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{Logger: gorm_logrus.New()})
+}
+
+func OpenPostgres(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gorm_logrus.New()})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Callback().Query().After("gorm:query").Register("paisa:time_zone", inTimeZone)
+	return db, err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// inTimeZone moves the loaded times to the configured time zone. SQLite
+// keeps the offset the time was stored with, but the PostgreSQL driver
+// loads them in the local time zone, which would move the postings near
+// midnight to the previous or next day if the time zones are different.
+func inTimeZone(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	location := config.TimeZone()
+	ctx := db.Statement.Context
+	update := func(record reflect.Value) {
+		for _, field := range db.Statement.Schema.Fields {
+			if field.FieldType != timeType {
+				continue
+			}
+			if value, zero := field.ValueOf(ctx, record); !zero {
+				field.Set(ctx, record, value.(time.Time).In(location))
+			}
+		}
+	}
+
+	records := reflect.Indirect(db.Statement.ReflectValue)
+	switch records.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < records.Len(); i++ {
+			update(reflect.Indirect(records.Index(i)))
+		}
+	case reflect.Struct:
+		update(records)
+	}
+}
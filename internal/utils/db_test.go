@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ananthakumaran/paisa/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInTimeZone(t *testing.T) {
+	require.NoError(t, config.LoadConfig([]byte(`
+journal_path: main.ledger
+db_path: paisa.db
+time_zone: Asia/Kolkata
+`), ""))
+
+	type record struct {
+		ID        uint
+		Date      time.Time
+		UpdatedAt time.Time
+	}
+
+	db, err := OpenSQLite(":memory:")
+	require.NoError(t, err)
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("paisa:time_zone", inTimeZone))
+	require.NoError(t, db.AutoMigrate(&record{}))
+
+	// 2024-01-31 in India, but 2024-01-30 in UTC
+	date := time.Date(2024, 1, 30, 18, 30, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&record{Date: date}).Error)
+
+	var records []record
+	require.NoError(t, db.Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.Equal(t, "2024-01-31", records[0].Date.Format("2006-01-02"))
+	assert.True(t, date.Equal(records[0].Date))
+
+	var single record
+	require.NoError(t, db.First(&single).Error)
+	assert.Equal(t, "Asia/Kolkata", single.Date.Location().String())
+}
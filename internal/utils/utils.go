@@ -13,13 +13,10 @@ import (
 
 	"github.com/ananthakumaran/paisa/internal/config"
 	"github.com/google/btree"
-	"github.com/onrik/gorm-logrus"
 	"github.com/samber/lo"
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/exp/constraints"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 func BTreeDescendFirstLessOrEqual[I btree.Item](tree *btree.BTree, item I) I {
@@ -109,6 +106,10 @@ func IsSameDate(a time.Time, b time.Time) bool {
 	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
 }
 
+func BeginningOfDay(date time.Time) time.Time {
+	return toDate(date)
+}
+
 func EndOfDay(date time.Time) time.Time {
 	return toDate(date).AddDate(0, 0, 1).Add(-time.Nanosecond)
 }
@@ -274,11 +275,6 @@ func UnQuote(str string) string {
 	return str
 }
 
-func OpenDB() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(config.GetDBPath()), &gorm.Config{Logger: gorm_logrus.New()})
-	return db, err
-}
-
 func Dos2Unix(str string) string {
 	return strings.ReplaceAll(str, "\r\n", "\n")
 }
@@ -4,7 +4,7 @@ import _, { round } from "lodash";
 import COLORS from "./colors";
 import { formatCurrency, formatFloat, restName, tooltip, type Harvestable } from "./utils";
 
-export function renderHarvestables(harvestables: Harvestable[]) {
+export function renderHarvestables(harvestables: Harvestable[], taxableGain: number) {
   const id = "#d3-harvestables";
   const root = d3.select(id);
 
@@ -32,7 +32,7 @@ export function renderHarvestables(harvestables: Harvestable[]) {
     .append("div")
     .each(function (h) {
       const self = d3.select(this);
-      const [units, amount, taxableGain] = unitsRequiredFromGain(h, 100000);
+      const [units, amount, taxableGain] = unitsRequiredFromGain(h, taxableGain);
       self.append("span").html("If you redeem&nbsp;");
       const unitsSpan = self.append("span").text(formatFloat(units));
       self.append("span").html("&nbsp;units you will get ₹");
@@ -1,5 +1,6 @@
 import * as toast from "bulma-toast";
-import { ajax } from "./utils";
+import _ from "lodash";
+import { ajax, authToken } from "./utils";
 
 export async function sync(request: Record<string, any>) {
   const { success, message } = await ajax("/api/sync", {
@@ -15,3 +16,82 @@ export async function sync(request: Record<string, any>) {
     });
   }
 }
+
+interface ServerEvent {
+  type: string;
+  message?: string;
+}
+
+// EventSource can't send the X-Auth header, so the stream is read
+// through fetch instead.
+export function listen(onEvent: (event: ServerEvent) => void) {
+  const controller = new AbortController();
+
+  async function connect() {
+    const headers: Record<string, string> = {};
+    const token = authToken();
+    if (!_.isEmpty(token)) {
+      headers["X-Auth"] = token;
+    }
+
+    const response = await fetch("/api/events", { headers, signal: controller.signal });
+    if (!response.ok || !response.body) {
+      throw new Error(`Failed to connect to event stream: ${response.status}`);
+    }
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        return;
+      }
+
+      buffer += value;
+      const messages = buffer.split("\n\n");
+      buffer = messages.pop();
+      for (const message of messages) {
+        const data = message
+          .split("\n")
+          .filter((line) => line.startsWith("data:"))
+          .map((line) => line.slice(5))
+          .join("\n");
+        if (!_.isEmpty(data)) {
+          onEvent(JSON.parse(data));
+        }
+      }
+    }
+  }
+
+  async function loop() {
+    while (!controller.signal.aborted) {
+      try {
+        await connect();
+      } catch (e) {
+        if (controller.signal.aborted) {
+          return;
+        }
+      }
+      await new Promise((resolve) => setTimeout(resolve, 5000));
+    }
+  }
+
+  loop();
+  return () => controller.abort();
+}
+
+export function handleServerEvent(event: ServerEvent, refresh: () => void) {
+  switch (event.type) {
+    case "data_changed":
+    case "sheets_changed":
+      refresh();
+      break;
+    case "sync_error":
+      toast.toast({
+        message: `<b>Failed to sync</b>\n${event.message}`,
+        type: "is-danger",
+        duration: 10000
+      });
+      break;
+  }
+}
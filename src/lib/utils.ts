@@ -21,6 +21,7 @@ export interface AutoCompleteField {
   label: string;
   help: string;
   inputType: string;
+  optional: boolean;
 }
 
 export interface PriceProvider {
@@ -352,6 +353,30 @@ export interface CapitalGain {
   fy: { [key: string]: FYCapitalGain };
 }
 
+export interface Exemption {
+  fy: string;
+  limit: number;
+  used: number;
+  remaining: number;
+  tax_saved: number;
+}
+
+export interface HarvestPlanLot {
+  account: string;
+  units: number;
+  purchase_date: string;
+  purchase_unit_price: number;
+  current_unit_price: number;
+  tax: Tax;
+}
+
+export interface HarvestPlan {
+  exemption: Exemption;
+  lots: HarvestPlanLot[];
+  amount: number;
+  taxable_gain: number;
+}
+
 export interface Issue {
   level: string;
   summary: string;
@@ -548,6 +573,31 @@ export interface CreditCardBill {
   paidDate: dayjs.Dayjs;
   postings: Posting[];
   transactions: Transaction[];
+  minimumDue: number;
+  paidByDueDate: number;
+  paymentStatus: "no_dues" | "upcoming" | "paid_on_time" | "paid_late" | "partial" | "unpaid";
+  utilization: number;
+  peakUtilization: number;
+  financeCharges: number;
+  lateFees: number;
+  otherCharges: number;
+  charges: Posting[];
+}
+
+export interface CreditCardHealth {
+  status: "good" | "fair" | "poor";
+  onTime: number;
+  late: number;
+  partial: number;
+  unpaid: number;
+  utilization: number;
+  averageUtilization: number;
+  peakUtilization: number;
+  utilizationThreshold: number;
+  billsOverThreshold: number;
+  financeCharges: number;
+  lateFees: number;
+  otherCharges: number;
 }
 
 export interface CreditCardSummary {
@@ -559,6 +609,7 @@ export interface CreditCardSummary {
   creditLimit: number;
   expirationDate: dayjs.Dayjs;
   yearlySpends: { [year: string]: { [month: string]: number } };
+  health: CreditCardHealth;
 }
 
 export interface GoalSummary {
@@ -590,10 +641,18 @@ type RequestOptions = RequestInit & {
 export function ajax(
   route: "/api/config"
 ): Promise<{ config: UserConfig; schema: JSONSchema7; now: dayjs.Dayjs; accounts: string[] }>;
-export function ajax(route: "/api/harvest"): Promise<{ harvestables: Record<string, Harvestable> }>;
 export function ajax(
-  route: "/api/capital_gains"
-): Promise<{ capital_gains: Record<string, CapitalGain> }>;
+  route: "/api/harvest"
+): Promise<{ harvestables: Record<string, Harvestable>; harvest_plan: HarvestPlan }>;
+export function ajax(route: "/api/capital_gains"): Promise<{
+  capital_gains: Record<string, CapitalGain>;
+  exemptions: Record<string, Exemption>;
+}>;
+export function ajax(route: "/api/capital_gains/lot_selection"): Promise<{
+  lot_selections: string[];
+  comparison: Record<string, Record<string, Tax>>;
+  current: Record<string, string>;
+}>;
 export function ajax(route: "/api/schedule_al"): Promise<{
   schedule_als: Record<string, ScheduleAL>;
 }>;
@@ -904,6 +963,10 @@ export async function login(username: string, password: string) {
   return await ajax("/api/ping");
 }
 
+export function authToken() {
+  return localStorage.getItem(tokenKey);
+}
+
 export function isLoggedIn() {
   return !_.isEmpty(localStorage.getItem(tokenKey));
 }